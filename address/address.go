@@ -0,0 +1,115 @@
+// Package address valida e normaliza os dados de endereço brasileiros: CEP
+// (gravado só com os 8 dígitos) e estado (gravado como a sigla da UF,
+// aceitando também o nome por extenso, com ou sem acento).
+package address
+
+import (
+	"api/search"
+	"errors"
+	"strings"
+)
+
+const cepLength = 8
+
+var (
+	ErrInvalidCEP   = errors.New("CEP inválido")
+	ErrInvalidState = errors.New("estado inválido; use a sigla da UF ou o nome do estado")
+)
+
+// Nomes das unidades federativas pela sigla
+var stateNames = map[string]string{
+	"AC": "Acre", "AL": "Alagoas", "AP": "Amapá", "AM": "Amazonas", "BA": "Bahia",
+	"CE": "Ceará", "DF": "Distrito Federal", "ES": "Espírito Santo", "GO": "Goiás",
+	"MA": "Maranhão", "MT": "Mato Grosso", "MS": "Mato Grosso do Sul", "MG": "Minas Gerais",
+	"PA": "Pará", "PB": "Paraíba", "PR": "Paraná", "PE": "Pernambuco", "PI": "Piauí",
+	"RJ": "Rio de Janeiro", "RN": "Rio Grande do Norte", "RS": "Rio Grande do Sul",
+	"RO": "Rondônia", "RR": "Roraima", "SC": "Santa Catarina", "SP": "São Paulo",
+	"SE": "Sergipe", "TO": "Tocantins",
+}
+
+// Siglas pelo nome do estado sem acento e em minúsculas
+var statesByName = func() map[string]string {
+	byName := make(map[string]string, len(stateNames))
+	for uf, name := range stateNames {
+		byName[search.Fold(name)] = uf
+	}
+	return byName
+}()
+
+// cepRange é uma faixa de CEPs (pelos 5 primeiros dígitos) de uma UF
+type cepRange struct {
+	start, end int
+	state      string
+}
+
+// Faixas de CEP de cada UF definidas pelos Correios
+var cepRanges = []cepRange{
+	{1000, 19999, "SP"}, {20000, 28999, "RJ"}, {29000, 29999, "ES"}, {30000, 39999, "MG"},
+	{40000, 48999, "BA"}, {49000, 49999, "SE"}, {50000, 56999, "PE"}, {57000, 57999, "AL"},
+	{58000, 58999, "PB"}, {59000, 59999, "RN"}, {60000, 63999, "CE"}, {64000, 64999, "PI"},
+	{65000, 65999, "MA"}, {66000, 68899, "PA"}, {68900, 68999, "AP"}, {69000, 69299, "AM"},
+	{69300, 69399, "RR"}, {69400, 69899, "AM"}, {69900, 69999, "AC"}, {70000, 72799, "DF"},
+	{72800, 72999, "GO"}, {73000, 73699, "DF"}, {73700, 76799, "GO"}, {76800, 76999, "RO"},
+	{77000, 77999, "TO"}, {78000, 78899, "MT"}, {79000, 79999, "MS"}, {80000, 87999, "PR"},
+	{88000, 89999, "SC"}, {90000, 99999, "RS"},
+}
+
+// NormalizeCEP valida o CEP, com ou sem hífen e ponto, e o devolve só com os
+// 8 dígitos
+func NormalizeCEP(value string) (string, error) {
+	var builder strings.Builder
+	for _, r := range strings.TrimSpace(value) {
+		switch {
+		case r >= '0' && r <= '9':
+			builder.WriteRune(r)
+		case r == '-' || r == '.' || r == ' ':
+		default:
+			return "", ErrInvalidCEP
+		}
+	}
+
+	cep := builder.String()
+	if len(cep) != cepLength || StateForCEP(cep) == "" {
+		return "", ErrInvalidCEP
+	}
+	return cep, nil
+}
+
+// NormalizeState converte a sigla (em qualquer caixa) ou o nome do estado
+// ("São Paulo", "sao paulo") para a sigla da UF
+func NormalizeState(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if uf := strings.ToUpper(value); stateNames[uf] != "" {
+		return uf, nil
+	}
+	if uf, ok := statesByName[strings.Join(strings.Fields(search.Fold(value)), " ")]; ok {
+		return uf, nil
+	}
+	return "", ErrInvalidState
+}
+
+// StateName devolve o nome do estado pela sigla da UF
+func StateName(uf string) string {
+	return stateNames[uf]
+}
+
+// StateForCEP devolve a UF da faixa a que o CEP normalizado pertence, ou
+// vazio quando o CEP não está em nenhuma faixa
+func StateForCEP(cep string) string {
+	if len(cep) != cepLength {
+		return ""
+	}
+	prefix := 0
+	for _, r := range cep[:5] {
+		if r < '0' || r > '9' {
+			return ""
+		}
+		prefix = prefix*10 + int(r-'0')
+	}
+	for _, r := range cepRanges {
+		if prefix >= r.start && prefix <= r.end {
+			return r.state
+		}
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+// Command documentcheck lista os cadastros com CPF ou CNPJ inválido (formato
+// ou dígitos verificadores) e os que ficam duplicados depois da normalização
+// da migração 014_document_normalization.sql, para correção manual. Termina
+// com status 1 quando encontra algum problema.
+package main
+
+import (
+	"api/document"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// documentColumn é uma coluna de documento a verificar
+type documentColumn struct {
+	table     string
+	column    string
+	normalize func(string) (string, error)
+}
+
+var documentColumns = []documentColumn{
+	{"users", "cpf", document.NormalizeCPF},
+	{"vendors", "cnpj", document.NormalizeCNPJ},
+	{"buyers", "cnpj", document.NormalizeCNPJ},
+}
+
+// normalizedKey repete a normalização da migração (sem pontuação, em
+// maiúsculas), usada para encontrar duplicados mesmo entre documentos inválidos
+func normalizedKey(value string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "", "-", "", "/", "", " ", "").Replace(strings.TrimSpace(value)))
+}
+
+func checkColumn(db *sql.DB, check documentColumn) (int, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT id, %s FROM %s WHERE %s IS NOT NULL AND TRIM(%s) <> '' ORDER BY id",
+		check.column, check.table, check.column, check.column))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	problems := 0
+	byDocument := make(map[string][]int)
+	for rows.Next() {
+		var id int
+		var value string
+		if err := rows.Scan(&id, &value); err != nil {
+			return problems, err
+		}
+		if _, err := check.normalize(value); err != nil {
+			fmt.Printf("%s #%d: %s inválido (%q)\n", check.table, id, check.column, value)
+			problems++
+		}
+		key := normalizedKey(value)
+		byDocument[key] = append(byDocument[key], id)
+	}
+	if err := rows.Err(); err != nil {
+		return problems, err
+	}
+
+	documents := make([]string, 0, len(byDocument))
+	for key, ids := range byDocument {
+		if len(ids) > 1 {
+			documents = append(documents, key)
+		}
+	}
+	sort.Strings(documents)
+	for _, key := range documents {
+		fmt.Printf("%s: %s %s repetido nos cadastros %v\n", check.table, check.column, key, byDocument[key])
+		problems++
+	}
+	return problems, nil
+}
+
+func main() {
+	dsn := flag.String("dsn", "root:84990999@tcp(localhost:3306)/agrofood", "Conexão com o banco MySQL")
+	flag.Parse()
+
+	db, err := sql.Open("mysql", *dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	problems := 0
+	for _, check := range documentColumns {
+		found, err := checkColumn(db, check)
+		if err != nil {
+			log.Fatalf("Erro ao verificar %s.%s: %v", check.table, check.column, err)
+		}
+		problems += found
+	}
+
+	if problems > 0 {
+		fmt.Printf("%d problema(s) encontrado(s)\n", problems)
+		os.Exit(1)
+	}
+	fmt.Println("Nenhum documento inválido ou duplicado")
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Nome da role com acesso às rotas administrativas
+const adminRoleName = "admin"
+
+// Cabeçalhos com a identidade do usuário que está fazendo a requisição,
+// definidos pelo gateway que autentica o usuário
+const (
+	callerUserHeader      = "X-User-ID"
+	callerTimestampHeader = "X-User-Timestamp"
+	callerSignatureHeader = "X-User-Signature"
+)
+
+// Diferença máxima aceita entre o horário da assinatura e o do servidor
+const callerSignatureMaxAge = 5 * time.Minute
+
+// gatewaySecret devolve o segredo compartilhado com o gateway
+// (AUTH_GATEWAY_SECRET). Sem ele o cabeçalho X-User-ID é aceito sem
+// verificação, o que só serve para desenvolvimento.
+func gatewaySecret() string {
+	return os.Getenv("AUTH_GATEWAY_SECRET")
+}
+
+// WarnUnverifiedIdentity avisa na inicialização quando a identidade dos
+// usuários não é verificada
+func WarnUnverifiedIdentity() {
+	if gatewaySecret() == "" {
+		log.Println("AUTH_GATEWAY_SECRET não definido: X-User-ID será aceito sem assinatura e as rotas /admin ficam indisponíveis")
+	}
+}
+
+// signCallerIdentity calcula a assinatura do gateway para o usuário e o
+// horário (Unix, em segundos) informados
+func signCallerIdentity(secret, userID, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(userID + ":" + timestamp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// callerIdentityVerified confere a assinatura HMAC-SHA256 de "id:timestamp"
+// enviada pelo gateway em X-User-Signature, recusando assinaturas antigas
+func callerIdentityVerified(c *fiber.Ctx, secret string) bool {
+	timestamp := c.Get(callerTimestampHeader)
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := time.Since(time.Unix(signedAt, 0))
+	if age > callerSignatureMaxAge || age < -callerSignatureMaxAge {
+		return false
+	}
+	expected := signCallerIdentity(secret, c.Get(callerUserHeader), timestamp)
+	return hmac.Equal([]byte(expected), []byte(c.Get(callerSignatureHeader)))
+}
+
+// callerUserID lê o ID do usuário autenticado enviado no cabeçalho X-User-ID.
+// Com AUTH_GATEWAY_SECRET definido, o ID só é aceito com a assinatura do
+// gateway válida.
+func callerUserID(c *fiber.Ctx) (int, bool) {
+	userID, err := strconv.Atoi(c.Get(callerUserHeader))
+	if err != nil || userID < 1 {
+		return 0, false
+	}
+	if secret := gatewaySecret(); secret != "" && !callerIdentityVerified(c, secret) {
+		return 0, false
+	}
+	return userID, true
+}
+
+// isAdminUser indica se o usuário possui a role de administrador
+func isAdminUser(q sqlQueryer, userID int) (bool, error) {
+	var count int
+	err := q.QueryRow(`
+		SELECT COUNT(*)
+		FROM users u
+		INNER JOIN roles r ON u.roles_id = r.id
+		WHERE u.id = ? AND LOWER(r.name) = ?`, userID, adminRoleName).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// authorizeAdmin confere se quem chama é administrador e devolve o ID dele.
+// A identidade precisa estar assinada pelo gateway: sem AUTH_GATEWAY_SECRET
+// as operações administrativas ficam indisponíveis, já que qualquer cliente
+// poderia enviar o ID de um administrador.
+func authorizeAdmin(q sqlQueryer, c *fiber.Ctx) (int, error) {
+	if gatewaySecret() == "" {
+		return 0, &requestError{503, "Rotas administrativas exigem AUTH_GATEWAY_SECRET configurado"}
+	}
+
+	userID, ok := callerUserID(c)
+	if !ok {
+		return 0, &requestError{401, "Usuário não identificado"}
+	}
+
+	admin, err := isAdminUser(q, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !admin {
+		return 0, &requestError{403, "Acesso restrito a administradores"}
+	}
+	return userID, nil
+}
+
+// RequireAdmin restringe as rotas do grupo a administradores, seguindo
+// authorizeAdmin
+func RequireAdmin(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := authorizeAdmin(db, c)
+		if err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		c.Locals("admin_user_id", userID)
+		return c.Next()
+	}
+}
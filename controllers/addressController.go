@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"api/address"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Quantidade de CEPs gravados por comando na importação da base
+const cepImportBatchSize = 500
+
+// Quantidade máxima de linhas com erro detalhadas no relatório da importação
+const maxReportedCEPErrors = 100
+
+// Colunas aceitas na planilha de CEPs, com os nomes alternativos em português
+var cepImportColumns = map[string]string{
+	"cep":          "cep",
+	"street":       "street",
+	"logradouro":   "street",
+	"neighborhood": "neighborhood",
+	"bairro":       "neighborhood",
+	"city":         "city",
+	"cidade":       "city",
+	"localidade":   "city",
+	"state":        "state",
+	"uf":           "state",
+	"estado":       "state",
+	"latitude":     "latitude",
+	"lat":          "latitude",
+	"longitude":    "longitude",
+	"lng":          "longitude",
+	"lon":          "longitude",
+}
+
+// CEPAddress é o endereço de um CEP na base local
+type CEPAddress struct {
+	CEP          string  `json:"cep"`
+	Street       *string `json:"street"`
+	Neighborhood *string `json:"neighborhood"`
+	City         string  `json:"city"`
+	State        string  `json:"state"`
+	StateName    string  `json:"state_name"`
+	// Coordenadas do CEP, usadas nas regiões de entrega por raio
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+// CEPImportError é uma linha da planilha de CEPs que não pôde ser importada
+type CEPImportError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// normalizeAddress valida e normaliza o CEP e o estado de um endereço. Campos
+// vazios continuam vazios; quando os dois são informados, o CEP precisa
+// pertencer à faixa do estado.
+func normalizeAddress(cep, state string) (string, string, error) {
+	var err error
+	if strings.TrimSpace(cep) != "" {
+		if cep, err = address.NormalizeCEP(cep); err != nil {
+			return "", "", &requestError{400, err.Error()}
+		}
+	}
+	if strings.TrimSpace(state) != "" {
+		if state, err = address.NormalizeState(state); err != nil {
+			return "", "", &requestError{400, err.Error()}
+		}
+	}
+	if cep != "" && state != "" && address.StateForCEP(cep) != state {
+		return "", "", &requestError{400, "O CEP informado não pertence ao estado " + state}
+	}
+	return cep, state, nil
+}
+
+// normalizeAddressFields valida e normaliza o CEP e o estado enviados em uma
+// atualização parcial, substituindo os valores no mapa
+func normalizeAddressFields(updateData map[string]interface{}, cepField, stateField string) error {
+	values := make(map[string]string, 2)
+	for _, field := range []string{cepField, stateField} {
+		raw, present := updateData[field]
+		if !present {
+			continue
+		}
+		text, ok := raw.(string)
+		if !ok {
+			return &requestError{400, "O campo " + field + " deve ser texto"}
+		}
+		values[field] = text
+	}
+
+	cep, state, err := normalizeAddress(values[cepField], values[stateField])
+	if err != nil {
+		return err
+	}
+	if _, present := values[cepField]; present {
+		updateData[cepField] = cep
+	}
+	if _, present := values[stateField]; present {
+		updateData[stateField] = state
+	}
+	return nil
+}
+
+// parseCoordinates lê a latitude e a longitude opcionais de uma linha da base
+// de CEPs, aceitando vírgula como separador decimal
+func parseCoordinates(latitude, longitude string) (*float64, *float64, error) {
+	if latitude == "" && longitude == "" {
+		return nil, nil, nil
+	}
+	lat, errLat := strconv.ParseFloat(strings.Replace(latitude, ",", ".", 1), 64)
+	lng, errLng := strconv.ParseFloat(strings.Replace(longitude, ",", ".", 1), 64)
+	if errLat != nil || errLng != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return nil, nil, fmt.Errorf("latitude e longitude inválidas")
+	}
+	return &lat, &lng, nil
+}
+
+// normalizeShippingAddress valida e normaliza o CEP e o estado de entrega
+func (r *CheckoutRequest) normalizeShippingAddress() error {
+	cep, state, err := normalizeAddress(r.ShippingCEP, r.ShippingState)
+	if err != nil {
+		return err
+	}
+	r.ShippingCEP, r.ShippingState = cep, state
+	return nil
+}
+
+// saveCEPBatch grava um lote de CEPs, atualizando os que já existem
+func saveCEPBatch(tx *sql.Tx, batch []CEPAddress) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(batch)*7)
+	for _, item := range batch {
+		args = append(args, item.CEP, item.Street, item.Neighborhood, item.City, item.State, item.Latitude, item.Longitude)
+	}
+	_, err := tx.Exec(`
+		INSERT INTO cep_addresses (cep, street, neighborhood, city, state, latitude, longitude)
+		VALUES `+strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?, ?, ?, ?), ", len(batch)), ", ")+`
+		ON DUPLICATE KEY UPDATE street = VALUES(street), neighborhood = VALUES(neighborhood),
+			city = VALUES(city), state = VALUES(state), latitude = VALUES(latitude), longitude = VALUES(longitude)`, args...)
+	return err
+}
+
+// @Summary Consultar CEP
+// @Description Resolve o CEP para logradouro, bairro, cidade e estado a partir da base local, para o preenchimento automático de endereços. O CEP pode ser enviado com ou sem hífen
+// @Tags Addresses
+// @Param cep path string true "CEP"
+// @Success 200 {object} CEPAddress
+// @Failure 400 {object} map[string]string "CEP inválido"
+// @Failure 404 {object} map[string]interface{} "CEP não encontrado na base, com o estado da faixa do CEP"
+// @Failure 500 {object} map[string]string "Erro ao consultar CEP"
+// @Router /addresses/cep/{cep} [get]
+func GetAddressByCEP(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cep, err := address.NormalizeCEP(c.Params("cep"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		var result CEPAddress
+		err = db.QueryRow("SELECT cep, street, neighborhood, city, state, latitude, longitude FROM cep_addresses WHERE cep = ?", cep).
+			Scan(&result.CEP, &result.Street, &result.Neighborhood, &result.City, &result.State, &result.Latitude, &result.Longitude)
+		if err == sql.ErrNoRows {
+			// A faixa do CEP ainda permite preencher o estado
+			state := address.StateForCEP(cep)
+			return c.Status(404).JSON(fiber.Map{
+				"message":    "CEP não encontrado na base de endereços",
+				"cep":        cep,
+				"state":      state,
+				"state_name": address.StateName(state),
+			})
+		} else if err != nil {
+			log.Println("Erro ao consultar CEP:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao consultar CEP"})
+		}
+		result.StateName = address.StateName(result.State)
+
+		return c.Status(200).JSON(result)
+	}
+}
+
+// @Summary Importar base de CEPs
+// @Description Carrega ou atualiza a base local de CEPs a partir de uma planilha CSV ou XLSX com cabeçalho. Colunas: cep, street (logradouro), neighborhood (bairro), city (cidade ou localidade), state (uf ou estado) e, opcionalmente, latitude e longitude (lat, lng), usadas nas regiões de entrega por raio. As linhas válidas são gravadas e as inválidas, relatadas
+// @Tags Admin
+// @Accept multipart/form-data
+// @Produce json
+// @Param X-User-ID header int true "ID do administrador"
+// @Param file formData file true "Planilha .csv ou .xlsx"
+// @Param format formData string false "Formato (csv ou xlsx); padrão pela extensão do arquivo"
+// @Success 200 {object} map[string]interface{} "Quantidade importada e linhas com erro"
+// @Failure 400 {object} map[string]string "Planilha inválida"
+// @Failure 500 {object} map[string]string "Erro ao importar CEPs"
+// @Router /admin/addresses/cep/import [post]
+func ImportCEPAddresses(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		rows, err := readSpreadsheetUpload(c)
+		if err != nil {
+			return respondError(c, err, "Erro ao ler planilha:", "Erro ao importar CEPs")
+		}
+
+		// Mapeia as colunas pelo cabeçalho
+		columns := make(map[string]int)
+		for i, name := range rows[0] {
+			if column, ok := cepImportColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+				columns[column] = i
+			}
+		}
+		for _, required := range []string{"cep", "city", "state"} {
+			if _, ok := columns[required]; !ok {
+				return c.Status(400).JSON(fiber.Map{"error": "A planilha precisa das colunas cep, city e state"})
+			}
+		}
+		cell := func(row []string, column string) string {
+			index, ok := columns[column]
+			if !ok || index >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[index])
+		}
+		optional := func(value string) *string {
+			if value == "" {
+				return nil
+			}
+			return &value
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao importar CEPs"})
+		}
+		defer tx.Rollback()
+
+		imported, failed := 0, 0
+		importErrors := []CEPImportError{}
+		batch := make([]CEPAddress, 0, cepImportBatchSize)
+		for i, row := range rows[1:] {
+			rowNumber := i + 2
+			item := CEPAddress{
+				Street:       optional(cell(row, "street")),
+				Neighborhood: optional(cell(row, "neighborhood")),
+				City:         cell(row, "city"),
+			}
+
+			var rowErr error
+			if cell(row, "cep") == "" || item.City == "" || cell(row, "state") == "" {
+				rowErr = fmt.Errorf("cep, city e state são obrigatórios")
+			} else {
+				item.CEP, item.State, rowErr = normalizeAddress(cell(row, "cep"), cell(row, "state"))
+			}
+			if rowErr == nil {
+				item.Latitude, item.Longitude, rowErr = parseCoordinates(cell(row, "latitude"), cell(row, "longitude"))
+			}
+			if rowErr != nil {
+				failed++
+				if len(importErrors) < maxReportedCEPErrors {
+					importErrors = append(importErrors, CEPImportError{Row: rowNumber, Error: rowErr.Error()})
+				}
+				continue
+			}
+
+			batch = append(batch, item)
+			if len(batch) == cepImportBatchSize {
+				if err := saveCEPBatch(tx, batch); err != nil {
+					log.Println("Erro ao gravar CEPs:", err)
+					return c.Status(500).JSON(fiber.Map{"error": "Erro ao importar CEPs"})
+				}
+				imported += len(batch)
+				batch = batch[:0]
+			}
+		}
+		if err := saveCEPBatch(tx, batch); err != nil {
+			log.Println("Erro ao gravar CEPs:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao importar CEPs"})
+		}
+		imported += len(batch)
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao confirmar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao importar CEPs"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{
+			"imported": imported,
+			"failed":   failed,
+			"errors":   importErrors,
+		})
+	}
+}
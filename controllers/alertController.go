@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"api/pagination"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Tipos de alerta de estoque
+const (
+	AlertLowStock    = "low_stock"
+	AlertOutOfStock  = "out_of_stock"
+	AlertLotExpiring = "lot_expiring"
+)
+
+// Antecedência padrão, em dias, do alerta de lote vencendo
+const defaultAlertExpiryDays = 7
+
+// VendorAlert é um alerta de estoque do vendor
+type VendorAlert struct {
+	ID          int     `json:"id"`
+	VendorsID   int     `json:"vendors_id"`
+	ProductsID  int     `json:"products_id"`
+	ProductName string  `json:"product_name"`
+	LotID       *int    `json:"product_lots_id"`
+	Type        string  `json:"type"`
+	Message     string  `json:"message"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+	ResolvedAt  *string `json:"resolved_at"`
+}
+
+// alertKey identifica uma condição de alerta; cada condição tem no máximo um
+// alerta aberto
+type alertKey struct {
+	alertType string
+	productID int
+	lotID     int
+}
+
+type alertCondition struct {
+	vendorID int
+	message  string
+}
+
+// alertExpiryDays lê a antecedência do alerta de lote vencendo
+// (ALERT_EXPIRY_DAYS), usando o padrão quando ausente ou inválida
+func alertExpiryDays() int {
+	days, err := strconv.Atoi(os.Getenv("ALERT_EXPIRY_DAYS"))
+	if err != nil || days < 0 {
+		return defaultAlertExpiryDays
+	}
+	return days
+}
+
+// reorderThresholdValue converte o limite de reposição para gravação; zero
+// desativa o alerta de estoque baixo
+func reorderThresholdValue(threshold *int) interface{} {
+	if threshold == nil || *threshold == 0 {
+		return nil
+	}
+	return *threshold
+}
+
+// currentAlertConditions levanta as condições de alerta válidas agora
+func currentAlertConditions(db *sql.DB) (map[alertKey]alertCondition, error) {
+	conditions := make(map[alertKey]alertCondition)
+
+	rows, err := db.Query(`
+		SELECT v.id, p.id, p.name, ` + sellableQuantityExpr + ` AS sellable, p.reorder_threshold
+		FROM products p
+		INNER JOIN vendors v ON v.id = p.vendors_id
+		WHERE ` + publicProductCondition + ` AND NOT ` + productHasVariantsCondition + `
+		HAVING sellable <= 0 OR sellable <= p.reorder_threshold`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var vendorID, productID, sellable int
+		var name string
+		var threshold sql.NullInt64
+		if err := rows.Scan(&vendorID, &productID, &name, &sellable, &threshold); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if sellable <= 0 {
+			conditions[alertKey{AlertOutOfStock, productID, 0}] = alertCondition{vendorID,
+				fmt.Sprintf("%s está sem estoque", name)}
+		} else {
+			conditions[alertKey{AlertLowStock, productID, 0}] = alertCondition{vendorID,
+				fmt.Sprintf("%s está com estoque baixo: %d unidade(s), limite de reposição %d", name, sellable, threshold.Int64)}
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`
+		SELECT v.id, p.id, p.name, l.id, l.lot_code, l.quantity_remaining, DATE_FORMAT(l.expires_at, '%Y-%m-%d')
+		FROM product_lots l
+		INNER JOIN products p ON p.id = l.products_id
+		INNER JOIN vendors v ON v.id = p.vendors_id
+		WHERE p.deleted_at IS NULL AND l.quantity_remaining > 0
+			AND l.expires_at >= CURDATE() AND l.expires_at <= CURDATE() + INTERVAL ? DAY`, alertExpiryDays())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var vendorID, productID, lotID, remaining int
+		var name, lotCode, expiresAt string
+		if err := rows.Scan(&vendorID, &productID, &name, &lotID, &lotCode, &remaining, &expiresAt); err != nil {
+			return nil, err
+		}
+		conditions[alertKey{AlertLotExpiring, productID, lotID}] = alertCondition{vendorID,
+			fmt.Sprintf("Lote %s de %s vence em %s com %d unidade(s)", lotCode, name, expiresAt, remaining)}
+	}
+	return conditions, rows.Err()
+}
+
+// EvaluateVendorAlerts abre alertas para as condições novas, atualiza a
+// mensagem dos que continuam valendo e resolve os que deixaram de valer
+func EvaluateVendorAlerts(db *sql.DB) error {
+	conditions, err := currentAlertConditions(db)
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.Query(`
+		SELECT id, type, products_id, COALESCE(product_lots_id, 0), message
+		FROM vendor_alerts WHERE resolved_at IS NULL`)
+	if err != nil {
+		return err
+	}
+	type openAlert struct {
+		id      int
+		message string
+	}
+	open := make(map[alertKey]openAlert)
+	var resolved []int
+	for rows.Next() {
+		var key alertKey
+		var alert openAlert
+		if err := rows.Scan(&alert.id, &key.alertType, &key.productID, &key.lotID, &alert.message); err != nil {
+			rows.Close()
+			return err
+		}
+		if _, seen := open[key]; seen {
+			resolved = append(resolved, alert.id)
+			continue
+		}
+		open[key] = alert
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for key, alert := range open {
+		condition, active := conditions[key]
+		switch {
+		case !active:
+			resolved = append(resolved, alert.id)
+		case condition.message != alert.message:
+			if _, err := db.Exec("UPDATE vendor_alerts SET message = ? WHERE id = ?", condition.message, alert.id); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(resolved) > 0 {
+		if _, err := db.Exec("UPDATE vendor_alerts SET resolved_at = NOW() WHERE id IN ("+placeholders(len(resolved))+")",
+			intArgs(resolved)...); err != nil {
+			return err
+		}
+	}
+
+	for key, condition := range conditions {
+		if _, exists := open[key]; exists {
+			continue
+		}
+		var lotID interface{}
+		if key.lotID != 0 {
+			lotID = key.lotID
+		}
+		if _, err := db.Exec(`
+			INSERT INTO vendor_alerts (vendors_id, products_id, product_lots_id, type, message)
+			VALUES (?, ?, ?, ?, ?)`,
+			condition.vendorID, key.productID, lotID, key.alertType, condition.message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartAlertEvaluator executa EvaluateVendorAlerts periodicamente em segundo plano
+func StartAlertEvaluator(db *sql.DB, interval time.Duration) {
+	go func() {
+		for {
+			if err := EvaluateVendorAlerts(db); err != nil {
+				log.Println("Erro ao avaliar alertas de estoque:", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// @Summary Alertas de estoque do vendor
+// @Description Lista os alertas de estoque baixo, sem estoque e de lotes vencendo (ALERT_EXPIRY_DAYS, padrão 7 dias), mais recentes primeiro
+// @Tags Vendors
+// @Param vendor_id path int true "ID do vendor"
+// @Param status query string false "open, resolved ou all" default(open)
+// @Param type query string false "low_stock, out_of_stock ou lot_expiring"
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros inválidos"
+// @Failure 500 {object} map[string]string "Erro ao buscar alertas"
+// @Router /vendors/{vendor_id}/alerts [get]
+func GetVendorAlerts(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		filter := "a.vendors_id = ?"
+		filterArgs := []interface{}{vendorID}
+
+		switch c.Query("status", "open") {
+		case "open":
+			filter += " AND a.resolved_at IS NULL"
+		case "resolved":
+			filter += " AND a.resolved_at IS NOT NULL"
+		case "all":
+		default:
+			return c.Status(400).JSON(fiber.Map{"error": "Status inválido. Use: open, resolved ou all"})
+		}
+
+		if alertType := c.Query("type"); alertType != "" {
+			if alertType != AlertLowStock && alertType != AlertOutOfStock && alertType != AlertLotExpiring {
+				return c.Status(400).JSON(fiber.Map{"error": "Tipo inválido. Use: low_stock, out_of_stock ou lot_expiring"})
+			}
+			filter += " AND a.type = ?"
+			filterArgs = append(filterArgs, alertType)
+		}
+
+		keyset, keysetArgs := params.Keyset("a.id")
+		args := append(append(append([]interface{}{}, filterArgs...), keysetArgs...), params.FetchLimit())
+		rows, err := db.Query(`
+			SELECT a.id, a.vendors_id, a.products_id, p.name, a.product_lots_id, a.type, a.message,
+				a.created_at, a.updated_at, a.resolved_at
+			FROM vendor_alerts a
+			INNER JOIN products p ON p.id = a.products_id
+			WHERE `+filter+` AND `+keyset+`
+			ORDER BY a.id DESC
+			LIMIT ?`, args...)
+		if err != nil {
+			log.Println("Erro ao buscar alertas do vendor:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar alertas"})
+		}
+		defer rows.Close()
+
+		var alerts []VendorAlert
+		for rows.Next() {
+			var alert VendorAlert
+			if err := rows.Scan(&alert.ID, &alert.VendorsID, &alert.ProductsID, &alert.ProductName, &alert.LotID,
+				&alert.Type, &alert.Message, &alert.CreatedAt, &alert.UpdatedAt, &alert.ResolvedAt); err != nil {
+				log.Println("Erro ao ler alerta:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler alerta"})
+			}
+			alerts = append(alerts, alert)
+		}
+
+		if err := rows.Err(); err != nil {
+			log.Println("Erro com as linhas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar alertas"})
+		}
+
+		page := pagination.NewPage(alerts, params, func(a VendorAlert) int { return a.ID })
+
+		if params.IncludeTotal {
+			var total int
+			if err := db.QueryRow("SELECT COUNT(*) FROM vendor_alerts a WHERE "+filter, filterArgs...).Scan(&total); err != nil {
+				log.Println("Erro ao contar alertas:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar alertas"})
+			}
+			page = page.WithTotal(total)
+		}
+
+		return c.Status(200).JSON(page)
+	}
+}
@@ -0,0 +1,480 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CategoryAttribute define um atributo estruturado do esquema de uma categoria
+type CategoryAttribute struct {
+	ID            int      `json:"id"`
+	CategoryID    int      `json:"categories_products_id"`
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	Unit          *string  `json:"unit,omitempty"`
+	Required      bool     `json:"required"`
+	AllowedValues []string `json:"allowed_values,omitempty"`
+	Inherited     bool     `json:"inherited"`
+}
+
+// attributeValue é o valor normalizado de um atributo pronto para gravação
+type attributeValue struct {
+	Text   string
+	Number *float64
+}
+
+var (
+	attributeNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+	attributeTypes       = map[string]bool{"text": true, "number": true, "integer": true, "boolean": true, "enum": true}
+)
+
+// effectiveCategoryAttributes retorna o esquema de atributos de uma categoria,
+// incluindo os herdados das categorias pai. Um atributo definido mais perto da
+// categoria sobrescreve o de mesmo nome definido em um ancestral.
+func effectiveCategoryAttributes(q sqlQueryer, categoryID int) ([]CategoryAttribute, error) {
+	parents, err := loadCategoryParents(q, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := parents[categoryID]; !exists {
+		return []CategoryAttribute{}, nil
+	}
+
+	// Da raiz até a própria categoria
+	chain := categoryAncestors(parents, categoryID)
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	chain = append(chain, categoryID)
+
+	rows, err := q.Query(`
+		SELECT id, categories_products_id, name, type, unit, required, allowed_values
+		FROM category_attributes
+		WHERE categories_products_id IN (`+placeholders(len(chain))+`)
+		ORDER BY id`, intArgs(chain)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byCategory := make(map[int][]CategoryAttribute)
+	for rows.Next() {
+		attribute, err := scanCategoryAttribute(rows)
+		if err != nil {
+			return nil, err
+		}
+		byCategory[attribute.CategoryID] = append(byCategory[attribute.CategoryID], attribute)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var schema []CategoryAttribute
+	position := make(map[string]int)
+	for _, id := range chain {
+		for _, attribute := range byCategory[id] {
+			attribute.Inherited = id != categoryID
+			if i, exists := position[attribute.Name]; exists {
+				schema[i] = attribute
+				continue
+			}
+			position[attribute.Name] = len(schema)
+			schema = append(schema, attribute)
+		}
+	}
+
+	if schema == nil {
+		schema = []CategoryAttribute{}
+	}
+	return schema, nil
+}
+
+func scanCategoryAttribute(rows *sql.Rows) (CategoryAttribute, error) {
+	var attribute CategoryAttribute
+	var allowedValues sql.NullString
+	err := rows.Scan(&attribute.ID, &attribute.CategoryID, &attribute.Name, &attribute.Type,
+		&attribute.Unit, &attribute.Required, &allowedValues)
+	if err != nil {
+		return attribute, err
+	}
+	if allowedValues.Valid && allowedValues.String != "" {
+		if err := json.Unmarshal([]byte(allowedValues.String), &attribute.AllowedValues); err != nil {
+			return attribute, err
+		}
+	}
+	return attribute, nil
+}
+
+// validateProductAttributes confere os valores enviados contra o esquema da
+// categoria e devolve os valores normalizados. Com requireAll os atributos
+// obrigatórios precisam estar presentes.
+func validateProductAttributes(schema []CategoryAttribute, values map[string]interface{}, requireAll bool) (map[string]attributeValue, error) {
+	byName := make(map[string]CategoryAttribute, len(schema))
+	for _, attribute := range schema {
+		byName[attribute.Name] = attribute
+	}
+
+	normalized := make(map[string]attributeValue, len(values))
+	for name, raw := range values {
+		attribute, exists := byName[name]
+		if !exists {
+			return nil, fmt.Errorf("Atributo desconhecido para esta categoria: %s", name)
+		}
+
+		value, err := normalizeAttributeValue(attribute, raw)
+		if err != nil {
+			return nil, err
+		}
+		normalized[name] = value
+	}
+
+	if requireAll {
+		for _, attribute := range schema {
+			if _, exists := normalized[attribute.Name]; attribute.Required && !exists {
+				return nil, fmt.Errorf("Atributo obrigatório ausente: %s", attribute.Name)
+			}
+		}
+	}
+
+	return normalized, nil
+}
+
+func normalizeAttributeValue(attribute CategoryAttribute, raw interface{}) (attributeValue, error) {
+	invalid := fmt.Errorf("Valor inválido para o atributo %s (tipo %s)", attribute.Name, attribute.Type)
+
+	switch attribute.Type {
+	case "number", "integer":
+		var number float64
+		switch v := raw.(type) {
+		case float64:
+			number = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(v), ",", ".", 1), 64)
+			if err != nil {
+				return attributeValue{}, invalid
+			}
+			number = parsed
+		default:
+			return attributeValue{}, invalid
+		}
+		if attribute.Type == "integer" && number != math.Trunc(number) {
+			return attributeValue{}, invalid
+		}
+		return attributeValue{Text: strconv.FormatFloat(number, 'f', -1, 64), Number: &number}, nil
+
+	case "boolean":
+		switch v := raw.(type) {
+		case bool:
+			return attributeValue{Text: strconv.FormatBool(v)}, nil
+		case string:
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				return attributeValue{}, invalid
+			}
+			return attributeValue{Text: strconv.FormatBool(parsed)}, nil
+		}
+		return attributeValue{}, invalid
+
+	case "enum":
+		text, ok := raw.(string)
+		if !ok {
+			return attributeValue{}, invalid
+		}
+		for _, allowed := range attribute.AllowedValues {
+			if allowed == text {
+				return attributeValue{Text: text}, nil
+			}
+		}
+		return attributeValue{}, fmt.Errorf("Valor não permitido para o atributo %s. Use: %s", attribute.Name, strings.Join(attribute.AllowedValues, ", "))
+
+	default:
+		text, ok := raw.(string)
+		if !ok || strings.TrimSpace(text) == "" || len(text) > 255 {
+			return attributeValue{}, invalid
+		}
+		return attributeValue{Text: strings.TrimSpace(text)}, nil
+	}
+}
+
+// saveProductAttributes grava os valores de atributos do produto, substituindo
+// os existentes de mesmo nome
+func saveProductAttributes(q sqlQueryer, productID interface{}, values map[string]attributeValue) error {
+	for name, value := range values {
+		_, err := q.Exec(`
+			INSERT INTO product_attributes (products_id, name, value, value_number)
+			VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE value = VALUES(value), value_number = VALUES(value_number)`,
+			productID, name, value.Text, value.Number)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadProductAttributeValues carrega os valores gravados de um produto no
+// formato bruto aceito por validateProductAttributes
+func loadProductAttributeValues(q sqlQueryer, productID interface{}) (map[string]interface{}, error) {
+	rows, err := q.Query("SELECT name, value FROM product_attributes WHERE products_id = ?", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[string]interface{})
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+	return values, rows.Err()
+}
+
+// typedAttributeValues converte os valores gravados para os tipos do esquema
+// (números e booleanos deixam de ser texto na resposta)
+func typedAttributeValues(schema []CategoryAttribute, stored map[string]interface{}) map[string]interface{} {
+	byName := make(map[string]CategoryAttribute, len(schema))
+	for _, attribute := range schema {
+		byName[attribute.Name] = attribute
+	}
+
+	typed := make(map[string]interface{}, len(stored))
+	for name, raw := range stored {
+		attribute, exists := byName[name]
+		if !exists {
+			typed[name] = raw
+			continue
+		}
+		if value, err := normalizeAttributeValue(attribute, raw); err == nil && value.Number != nil {
+			typed[name] = *value.Number
+		} else if err == nil && attribute.Type == "boolean" {
+			typed[name] = value.Text == "true"
+		} else {
+			typed[name] = raw
+		}
+	}
+	return typed
+}
+
+// attributeFilterConditions converte os parâmetros attr.<nome>, attr.<nome>.min
+// e attr.<nome>.max da busca em condições SQL sobre product_attributes
+func attributeFilterConditions(queries map[string]string) ([]searchCondition, error) {
+	var keys []string
+	for key := range queries {
+		if strings.HasPrefix(key, "attr.") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var conditions []searchCondition
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, "attr.")
+		operator := "="
+		column := "pa.value"
+		var arg interface{} = queries[key]
+
+		if strings.HasSuffix(name, ".min") || strings.HasSuffix(name, ".max") {
+			if strings.HasSuffix(name, ".min") {
+				operator = ">="
+			} else {
+				operator = "<="
+			}
+			name = name[:len(name)-4]
+			column = "pa.value_number"
+
+			number, err := strconv.ParseFloat(queries[key], 64)
+			if err != nil {
+				return nil, fmt.Errorf("Valor numérico inválido para o filtro %s", key)
+			}
+			arg = number
+		}
+
+		if !attributeNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("Filtro de atributo inválido: %s", key)
+		}
+
+		conditions = append(conditions, searchCondition{
+			dimension: searchDimensionAttrs,
+			clause: `EXISTS (
+				SELECT 1 FROM product_attributes pa
+				WHERE pa.products_id = p.id AND pa.name = ? AND ` + column + ` ` + operator + ` ?)`,
+			args: []interface{}{name, arg},
+		})
+	}
+
+	return conditions, nil
+}
+
+// GetCategoryAttributes retorna o esquema efetivo de atributos de uma categoria
+// @Summary Lista o esquema de atributos de uma categoria (incluindo herdados)
+// @Tags Categories
+// @Produce  json
+// @Param id path int true "ID da categoria"
+// @Success 200 {array} CategoryAttribute
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Categoria não encontrada"
+// @Failure 500 {object} map[string]string "Erro ao buscar atributos"
+// @Router /categories/{id}/attributes [get]
+func GetCategoryAttributes(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		categoryID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID da categoria inválido"})
+		}
+
+		var exists int
+		if err := db.QueryRow("SELECT COUNT(*) FROM categories_products WHERE id = ?", categoryID).Scan(&exists); err != nil {
+			log.Println("Erro ao verificar categoria:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar atributos"})
+		}
+		if exists == 0 {
+			return c.Status(404).JSON(fiber.Map{"error": "Categoria não encontrada"})
+		}
+
+		schema, err := effectiveCategoryAttributes(db, categoryID)
+		if err != nil {
+			log.Println("Erro ao buscar atributos da categoria:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar atributos"})
+		}
+
+		return c.Status(200).JSON(schema)
+	}
+}
+
+// CreateCategoryAttribute adiciona um atributo ao esquema de uma categoria
+// @Summary Cria um atributo no esquema da categoria
+// @Description Restrito a administradores, já que o esquema vale para todos os produtos da categoria
+// @Tags Categories
+// @Accept  json
+// @Produce  json
+// @Param id path int true "ID da categoria"
+// @Param attribute body CategoryAttribute true "Definição do atributo"
+// @Success 201 {object} CategoryAttribute
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 404 {object} map[string]string "Categoria não encontrada"
+// @Failure 409 {object} map[string]string "Atributo já existe na categoria"
+// @Failure 500 {object} map[string]string "Erro ao criar atributo"
+// @Router /admin/categories/{id}/attributes [post]
+func CreateCategoryAttribute(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		categoryID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID da categoria inválido"})
+		}
+
+		var attribute CategoryAttribute
+		if err := c.BodyParser(&attribute); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+		}
+
+		attribute.Name = strings.TrimSpace(attribute.Name)
+		if !attributeNamePattern.MatchString(attribute.Name) {
+			return c.Status(400).JSON(fiber.Map{"error": "Nome do atributo deve conter apenas letras minúsculas, números e _"})
+		}
+		if !attributeTypes[attribute.Type] {
+			return c.Status(400).JSON(fiber.Map{"error": "Tipo inválido. Use: text, number, integer, boolean ou enum"})
+		}
+		if attribute.Type == "enum" && len(attribute.AllowedValues) == 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Atributos do tipo enum precisam de allowed_values"})
+		}
+		if attribute.Type != "enum" {
+			attribute.AllowedValues = nil
+		}
+
+		var exists int
+		if err := db.QueryRow("SELECT COUNT(*) FROM categories_products WHERE id = ?", categoryID).Scan(&exists); err != nil {
+			log.Println("Erro ao verificar categoria:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar atributo"})
+		}
+		if exists == 0 {
+			return c.Status(404).JSON(fiber.Map{"error": "Categoria não encontrada"})
+		}
+
+		var allowedValues interface{}
+		if attribute.AllowedValues != nil {
+			encoded, err := json.Marshal(attribute.AllowedValues)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "allowed_values inválido"})
+			}
+			allowedValues = string(encoded)
+		}
+
+		var duplicated int
+		err = db.QueryRow("SELECT COUNT(*) FROM category_attributes WHERE categories_products_id = ? AND name = ?", categoryID, attribute.Name).Scan(&duplicated)
+		if err != nil {
+			log.Println("Erro ao verificar atributo:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar atributo"})
+		}
+		if duplicated > 0 {
+			return c.Status(409).JSON(fiber.Map{"error": "Atributo já existe nesta categoria"})
+		}
+
+		result, err := db.Exec(`
+			INSERT INTO category_attributes (categories_products_id, name, type, unit, required, allowed_values)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			categoryID, attribute.Name, attribute.Type, attribute.Unit, attribute.Required, allowedValues)
+		if err != nil {
+			log.Println("Erro ao criar atributo:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar atributo"})
+		}
+
+		attributeID, err := result.LastInsertId()
+		if err != nil {
+			log.Println("Erro ao obter ID do atributo:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar atributo"})
+		}
+
+		attribute.ID = int(attributeID)
+		attribute.CategoryID = categoryID
+		attribute.Inherited = false
+
+		return c.Status(201).JSON(attribute)
+	}
+}
+
+// DeleteCategoryAttribute remove um atributo do esquema de uma categoria
+// @Summary Remove um atributo do esquema da categoria
+// @Description Restrito a administradores, já que o esquema vale para todos os produtos da categoria
+// @Tags Categories
+// @Produce  json
+// @Param id path int true "ID da categoria"
+// @Param attribute_id path int true "ID do atributo"
+// @Success 200 {object} map[string]string "Atributo removido com sucesso"
+// @Failure 404 {object} map[string]string "Atributo não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao remover atributo"
+// @Router /admin/categories/{id}/attributes/{attribute_id} [delete]
+func DeleteCategoryAttribute(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		categoryID := c.Params("id")
+		attributeID := c.Params("attribute_id")
+
+		result, err := db.Exec("DELETE FROM category_attributes WHERE id = ? AND categories_products_id = ?", attributeID, categoryID)
+		if err != nil {
+			log.Println("Erro ao remover atributo:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover atributo"})
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Println("Erro ao verificar linhas afetadas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover atributo"})
+		}
+		if rowsAffected == 0 {
+			return c.Status(404).JSON(fiber.Map{"error": "Atributo não encontrado"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{"message": "Atributo removido com sucesso"})
+	}
+}
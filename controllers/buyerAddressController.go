@@ -0,0 +1,461 @@
+package controllers
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Quantidade máxima de endereços no catálogo de um comprador
+const maxBuyerAddresses = 50
+
+// BuyerAddress é um endereço de entrega do catálogo do comprador
+type BuyerAddress struct {
+	ID           int     `json:"id"`
+	BuyersID     int     `json:"buyers_id"`
+	Label        string  `json:"label"`
+	Address      string  `json:"address"`
+	Neighborhood *string `json:"neighborhood"`
+	City         string  `json:"city"`
+	State        string  `json:"state"`
+	Cep          string  `json:"cep"`
+	IsDefault    bool    `json:"is_default"`
+	CreatedAt    string  `json:"created_at"`
+	UpdatedAt    string  `json:"updated_at"`
+}
+
+// BuyerAddressRequest são os dados para cadastrar ou substituir um endereço.
+// Sem is_default o endereço mantém a marcação atual; o primeiro endereço do
+// comprador é sempre o padrão.
+type BuyerAddressRequest struct {
+	Label        string `json:"label"`
+	Address      string `json:"address"`
+	Neighborhood string `json:"neighborhood"`
+	City         string `json:"city"`
+	State        string `json:"state"`
+	Cep          string `json:"cep"`
+	IsDefault    *bool  `json:"is_default,omitempty"`
+}
+
+const buyerAddressColumns = "id, buyers_id, label, address, neighborhood, city, state, cep, is_default, created_at, updated_at"
+
+// scanBuyerAddress lê um endereço selecionado com buyerAddressColumns
+func scanBuyerAddress(row interface{ Scan(...interface{}) error }) (BuyerAddress, error) {
+	var item BuyerAddress
+	err := row.Scan(&item.ID, &item.BuyersID, &item.Label, &item.Address, &item.Neighborhood, &item.City,
+		&item.State, &item.Cep, &item.IsDefault, &item.CreatedAt, &item.UpdatedAt)
+	return item, err
+}
+
+// normalize valida os campos do endereço, normalizando CEP e estado
+func (r *BuyerAddressRequest) normalize() error {
+	r.Label = strings.TrimSpace(r.Label)
+	r.Address = strings.TrimSpace(r.Address)
+	r.Neighborhood = strings.TrimSpace(r.Neighborhood)
+	r.City = strings.TrimSpace(r.City)
+
+	switch {
+	case r.Label == "" || len(r.Label) > 60:
+		return &requestError{400, "Informe o nome do endereço (label) com até 60 caracteres"}
+	case r.Address == "" || len(r.Address) > 255:
+		return &requestError{400, "Informe o endereço com até 255 caracteres"}
+	case len(r.Neighborhood) > 120:
+		return &requestError{400, "O bairro deve ter até 120 caracteres"}
+	case r.City == "" || len(r.City) > 120:
+		return &requestError{400, "Informe a cidade com até 120 caracteres"}
+	case strings.TrimSpace(r.State) == "":
+		return &requestError{400, "Estado é obrigatório"}
+	case strings.TrimSpace(r.Cep) == "":
+		return &requestError{400, "CEP é obrigatório"}
+	}
+
+	cep, state, err := normalizeAddress(r.Cep, r.State)
+	if err != nil {
+		return err
+	}
+	r.Cep, r.State = cep, state
+	return nil
+}
+
+// authorizeBuyerManager garante que quem faz a requisição é owner ou manager
+// do comprador, ou um administrador
+func authorizeBuyerManager(q sqlQueryer, c *fiber.Ctx, buyerID int) error {
+	_, _, err := authorizeMember(q, c, buyerOrganization, buyerID, MemberManager)
+	return err
+}
+
+// loadBuyerAddress busca um endereço do comprador
+func loadBuyerAddress(q sqlQueryer, buyerID, addressID int) (BuyerAddress, error) {
+	item, err := scanBuyerAddress(q.QueryRow("SELECT "+buyerAddressColumns+" FROM buyer_addresses WHERE id = ? AND buyers_id = ?", addressID, buyerID))
+	if err == sql.ErrNoRows {
+		return item, &requestError{404, "Endereço não encontrado"}
+	}
+	return item, err
+}
+
+// ensureUniqueAddressLabel impede dois endereços do comprador com o mesmo nome
+func ensureUniqueAddressLabel(q sqlQueryer, buyerID, exceptID int, label string) error {
+	var count int
+	err := q.QueryRow("SELECT COUNT(*) FROM buyer_addresses WHERE buyers_id = ? AND label = ? AND id <> ?", buyerID, label, exceptID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return &requestError{409, "Já existe um endereço com este nome"}
+	}
+	return nil
+}
+
+// setDefaultBuyerAddress marca o endereço como padrão e desmarca os demais
+func setDefaultBuyerAddress(tx *sql.Tx, buyerID, addressID int) error {
+	_, err := tx.Exec("UPDATE buyer_addresses SET is_default = (id = ?) WHERE buyers_id = ?", addressID, buyerID)
+	return err
+}
+
+// lockBuyer bloqueia o comprador até o fim da transação, serializando as
+// alterações no catálogo de endereços (limite e endereço padrão)
+func lockBuyer(tx *sql.Tx, buyerID int) error {
+	var id int
+	err := tx.QueryRow("SELECT id FROM buyers WHERE id = ? FOR UPDATE", buyerID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return &requestError{404, "Comprador não encontrado"}
+	}
+	return err
+}
+
+// parseBuyerAddressParams lê os IDs do comprador e do endereço da rota
+func parseBuyerAddressParams(c *fiber.Ctx) (int, int, error) {
+	buyerID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return 0, 0, &requestError{400, "ID do comprador inválido"}
+	}
+	addressID, err := strconv.Atoi(c.Params("address_id"))
+	if err != nil {
+		return 0, 0, &requestError{400, "ID do endereço inválido"}
+	}
+	return buyerID, addressID, nil
+}
+
+// applySavedAddress preenche os dados de entrega com uma cópia do endereço
+// do catálogo escolhido (address_id), que precisa ser de um comprador de cuja
+// equipe o usuário do pedido faz parte
+func (r *CheckoutRequest) applySavedAddress(q sqlQueryer, userID string) error {
+	if r.AddressID == nil {
+		return nil
+	}
+
+	var buyerID int
+	var street, city, state, cep string
+	var neighborhood sql.NullString
+	err := q.QueryRow(`
+		SELECT ba.buyers_id, ba.address, ba.neighborhood, ba.city, ba.state, ba.cep
+		FROM buyer_addresses ba
+		INNER JOIN buyer_members m ON m.buyers_id = ba.buyers_id
+		WHERE ba.id = ? AND m.users_id = ?`, *r.AddressID, userID).
+		Scan(&buyerID, &street, &neighborhood, &city, &state, &cep)
+	if err == sql.ErrNoRows {
+		return &requestError{404, "Endereço de entrega não encontrado"}
+	} else if err != nil {
+		return err
+	}
+	if r.BuyersID != nil && *r.BuyersID != buyerID {
+		return &requestError{400, "O endereço informado não pertence ao comprador do pedido"}
+	}
+
+	// O pedido guarda uma única linha de endereço; o bairro vai junto
+	if neighborhood.Valid && neighborhood.String != "" {
+		street += " - " + neighborhood.String
+	}
+	r.ShippingAddress, r.ShippingCity, r.ShippingState, r.ShippingCEP = street, city, state, cep
+	r.BuyersID = &buyerID
+	return nil
+}
+
+// @Summary Listar endereços do comprador
+// @Description Lista o catálogo de endereços de entrega do comprador, com o endereço padrão primeiro. Restrito à equipe do comprador, inclusive operadores, e a administradores
+// @Tags Buyers
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do comprador"
+// @Success 200 {array} BuyerAddress
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o comprador"
+// @Failure 404 {object} map[string]string "Comprador não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar endereços"
+// @Router /buyers/{id}/addresses [get]
+func GetBuyerAddresses(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		buyerID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do comprador inválido"})
+		}
+
+		if _, _, err := authorizeMember(db, c, buyerOrganization, buyerID, MemberOperator); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		rows, err := db.Query("SELECT "+buyerAddressColumns+" FROM buyer_addresses WHERE buyers_id = ? ORDER BY is_default DESC, label", buyerID)
+		if err != nil {
+			log.Println("Erro ao buscar endereços do comprador:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar endereços"})
+		}
+		defer rows.Close()
+
+		addresses := []BuyerAddress{}
+		for rows.Next() {
+			item, err := scanBuyerAddress(rows)
+			if err != nil {
+				log.Println("Erro ao ler endereço do comprador:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar endereços"})
+			}
+			addresses = append(addresses, item)
+		}
+
+		return c.Status(200).JSON(addresses)
+	}
+}
+
+// @Summary Cadastrar endereço do comprador
+// @Description Adiciona um endereço de entrega ao catálogo do comprador, identificado por um nome (ex.: "Fazenda Norte", "Depósito"). O primeiro endereço é o padrão; com is_default o novo endereço passa a ser o padrão. Restrito a owners e managers do comprador e a administradores
+// @Tags Buyers
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do comprador"
+// @Param address body BuyerAddressRequest true "Dados do endereço"
+// @Success 201 {object} BuyerAddress
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o comprador"
+// @Failure 404 {object} map[string]string "Comprador não encontrado"
+// @Failure 409 {object} map[string]string "Nome repetido ou limite de endereços atingido"
+// @Failure 500 {object} map[string]string "Erro ao cadastrar endereço"
+// @Router /buyers/{id}/addresses [post]
+func CreateBuyerAddress(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		buyerID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do comprador inválido"})
+		}
+
+		var request BuyerAddressRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Erro ao analisar o corpo da requisição"})
+		}
+
+		if err := authorizeBuyerManager(db, c, buyerID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+		if err := request.normalize(); err != nil {
+			return respondError(c, err, "Erro ao validar endereço:", "Erro ao cadastrar endereço")
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar endereço"})
+		}
+		defer tx.Rollback()
+
+		if err := lockBuyer(tx, buyerID); err != nil {
+			return respondError(c, err, "Erro ao bloquear comprador:", "Erro ao cadastrar endereço")
+		}
+
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM buyer_addresses WHERE buyers_id = ?", buyerID).Scan(&count); err != nil {
+			log.Println("Erro ao contar endereços do comprador:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar endereço"})
+		}
+		if count >= maxBuyerAddresses {
+			return c.Status(409).JSON(fiber.Map{"error": "Limite de " + strconv.Itoa(maxBuyerAddresses) + " endereços atingido"})
+		}
+		if err := ensureUniqueAddressLabel(tx, buyerID, 0, request.Label); err != nil {
+			return respondError(c, err, "Erro ao verificar nome do endereço:", "Erro ao cadastrar endereço")
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO buyer_addresses (buyers_id, label, address, neighborhood, city, state, cep)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			buyerID, request.Label, request.Address, optionalText(request.Neighborhood), request.City, request.State, request.Cep)
+		if err != nil {
+			log.Println("Erro ao cadastrar endereço:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar endereço"})
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			log.Println("Erro ao obter o ID do endereço:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar endereço"})
+		}
+
+		if count == 0 || (request.IsDefault != nil && *request.IsDefault) {
+			if err := setDefaultBuyerAddress(tx, buyerID, int(id)); err != nil {
+				log.Println("Erro ao definir endereço padrão:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar endereço"})
+			}
+		}
+
+		created, err := loadBuyerAddress(tx, buyerID, int(id))
+		if err != nil {
+			log.Println("Erro ao buscar endereço:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar endereço"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao confirmar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar endereço"})
+		}
+
+		return c.Status(201).JSON(created)
+	}
+}
+
+// @Summary Atualizar endereço do comprador
+// @Description Substitui os dados de um endereço do catálogo. Com is_default true o endereço passa a ser o padrão; o padrão só deixa de ser padrão quando outro endereço é marcado. Pedidos já feitos mantêm a cópia do endereço. Restrito a owners e managers do comprador e a administradores
+// @Tags Buyers
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do comprador"
+// @Param address_id path int true "ID do endereço"
+// @Param address body BuyerAddressRequest true "Dados do endereço"
+// @Success 200 {object} BuyerAddress
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o comprador"
+// @Failure 404 {object} map[string]string "Endereço não encontrado"
+// @Failure 409 {object} map[string]string "Nome repetido"
+// @Failure 500 {object} map[string]string "Erro ao atualizar endereço"
+// @Router /buyers/{id}/addresses/{address_id} [put]
+func UpdateBuyerAddress(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		buyerID, addressID, err := parseBuyerAddressParams(c)
+		if err != nil {
+			return respondError(c, err, "Erro ao ler parâmetros:", "Erro ao atualizar endereço")
+		}
+
+		var request BuyerAddressRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Erro ao analisar o corpo da requisição"})
+		}
+
+		if err := authorizeBuyerManager(db, c, buyerID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+		if err := request.normalize(); err != nil {
+			return respondError(c, err, "Erro ao validar endereço:", "Erro ao atualizar endereço")
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar endereço"})
+		}
+		defer tx.Rollback()
+
+		if err := lockBuyer(tx, buyerID); err != nil {
+			return respondError(c, err, "Erro ao bloquear comprador:", "Erro ao atualizar endereço")
+		}
+		current, err := loadBuyerAddress(tx, buyerID, addressID)
+		if err != nil {
+			return respondError(c, err, "Erro ao buscar endereço:", "Erro ao atualizar endereço")
+		}
+		if current.IsDefault && request.IsDefault != nil && !*request.IsDefault {
+			return c.Status(400).JSON(fiber.Map{"error": "Marque outro endereço como padrão para substituir este"})
+		}
+		if err := ensureUniqueAddressLabel(tx, buyerID, addressID, request.Label); err != nil {
+			return respondError(c, err, "Erro ao verificar nome do endereço:", "Erro ao atualizar endereço")
+		}
+
+		_, err = tx.Exec(`
+			UPDATE buyer_addresses
+			SET label = ?, address = ?, neighborhood = ?, city = ?, state = ?, cep = ?
+			WHERE id = ?`,
+			request.Label, request.Address, optionalText(request.Neighborhood), request.City, request.State, request.Cep, addressID)
+		if err != nil {
+			log.Println("Erro ao atualizar endereço:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar endereço"})
+		}
+
+		if !current.IsDefault && request.IsDefault != nil && *request.IsDefault {
+			if err := setDefaultBuyerAddress(tx, buyerID, addressID); err != nil {
+				log.Println("Erro ao definir endereço padrão:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar endereço"})
+			}
+		}
+
+		updated, err := loadBuyerAddress(tx, buyerID, addressID)
+		if err != nil {
+			log.Println("Erro ao buscar endereço:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar endereço"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao confirmar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar endereço"})
+		}
+
+		return c.Status(200).JSON(updated)
+	}
+}
+
+// @Summary Remover endereço do comprador
+// @Description Remove um endereço do catálogo. Se era o padrão, o endereço mais antigo restante passa a ser o padrão. Pedidos já feitos mantêm a cópia do endereço. Restrito a owners e managers do comprador e a administradores
+// @Tags Buyers
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do comprador"
+// @Param address_id path int true "ID do endereço"
+// @Success 200 {object} map[string]string "Endereço removido"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o comprador"
+// @Failure 404 {object} map[string]string "Endereço não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao remover endereço"
+// @Router /buyers/{id}/addresses/{address_id} [delete]
+func DeleteBuyerAddress(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		buyerID, addressID, err := parseBuyerAddressParams(c)
+		if err != nil {
+			return respondError(c, err, "Erro ao ler parâmetros:", "Erro ao remover endereço")
+		}
+
+		if err := authorizeBuyerManager(db, c, buyerID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover endereço"})
+		}
+		defer tx.Rollback()
+
+		if err := lockBuyer(tx, buyerID); err != nil {
+			return respondError(c, err, "Erro ao bloquear comprador:", "Erro ao remover endereço")
+		}
+		current, err := loadBuyerAddress(tx, buyerID, addressID)
+		if err != nil {
+			return respondError(c, err, "Erro ao buscar endereço:", "Erro ao remover endereço")
+		}
+
+		if _, err := tx.Exec("DELETE FROM buyer_addresses WHERE id = ?", addressID); err != nil {
+			log.Println("Erro ao remover endereço:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover endereço"})
+		}
+		if current.IsDefault {
+			_, err := tx.Exec("UPDATE buyer_addresses SET is_default = TRUE WHERE buyers_id = ? ORDER BY id LIMIT 1", buyerID)
+			if err != nil {
+				log.Println("Erro ao definir endereço padrão:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover endereço"})
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao confirmar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover endereço"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{"message": "Endereço removido"})
+	}
+}
@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"api/document"
+	"api/pagination"
 	"database/sql"
 	"log"
 	"strings"
@@ -67,19 +69,32 @@ func validateBuyerData(db *sql.DB, cnpj, email string, excludeID int) (*BuyerVal
 }
 
 // @Summary Obter todos os compradores
-// @Description Obtém todos os compradores
+// @Description Obtém os compradores com paginação por cursor
 // @Tags Buyers
-// @Success 200 {array} Buyer
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
 // @Failure 500 {object} map[string]string "Erro ao buscar compradores"
 // @Router /buyers [get]
 func GetAllBuyers(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		keyset, keysetArgs := params.Keyset("id")
+
 		buyersQuery := `
             SELECT id, name, description, address, neighborhood, city, state, country, phone, email, users_id, cep, cnpj
             FROM agrofood.buyers
+            WHERE ` + keyset + `
+            ORDER BY id DESC
+            LIMIT ?
         `
 
-		rows, err := db.Query(buyersQuery)
+		rows, err := db.Query(buyersQuery, append(keysetArgs, params.FetchLimit())...)
 		if err != nil {
 			log.Println("Erro ao buscar buyers:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar compradores"})
@@ -93,6 +108,7 @@ func GetAllBuyers(db *sql.DB) fiber.Handler {
 				log.Println("Erro ao escanear buyer:", err)
 				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler comprador"})
 			}
+			buyer.Cnpj = document.FormatCNPJ(buyer.Cnpj)
 			buyers = append(buyers, buyer)
 		}
 
@@ -101,7 +117,18 @@ func GetAllBuyers(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar compradores"})
 		}
 
-		return c.Status(200).JSON(buyers)
+		page := pagination.NewPage(buyers, params, func(b Buyer) int { return b.ID })
+
+		if params.IncludeTotal {
+			var total int
+			if err := db.QueryRow("SELECT COUNT(*) FROM agrofood.buyers").Scan(&total); err != nil {
+				log.Println("Erro ao contar buyers:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao contar compradores"})
+			}
+			page = page.WithTotal(total)
+		}
+
+		return c.Status(200).JSON(page)
 	}
 }
 
@@ -133,6 +160,7 @@ func GetBuyerByID(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar comprador"})
 		}
 
+		buyer.Cnpj = document.FormatCNPJ(buyer.Cnpj)
 		return c.Status(200).JSON(buyer)
 	}
 }
@@ -155,6 +183,18 @@ func CreateBuyer(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "Erro ao analisar o corpo da requisição"})
 		}
 
+		// O CNPJ é gravado só com dígitos e letras, sem pontuação
+		cnpj, err := document.NormalizeCNPJ(buyer.Cnpj)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		buyer.Cnpj = cnpj
+
+		// CEP só com dígitos e estado pela sigla da UF
+		if buyer.Cep, buyer.State, err = normalizeAddress(buyer.Cep, buyer.State); err != nil {
+			return respondError(c, err, "Erro ao validar endereço do comprador:", "Falha na validação")
+		}
+
 		// Validação otimizada em uma única consulta
 		validation, err := validateBuyerData(db, buyer.Cnpj, buyer.Email, 0)
 		if err != nil {
@@ -191,6 +231,10 @@ func CreateBuyer(db *sql.DB) fiber.Handler {
 		}
 
 		buyer.ID = int(id)
+		// Quem cadastra o comprador é o primeiro owner da equipe
+		if err := addOrganizationOwner(db, buyerOrganization, buyer.ID, buyer.UsersId); err != nil {
+			log.Println("Erro ao registrar owner do comprador:", err)
+		}
 		return c.Status(200).JSON(fiber.Map{"message": "Comprador cadastrado com sucesso!"})
 	}
 }
@@ -266,6 +310,24 @@ func UpdateBuyer(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "Erro ao analisar o corpo da requisição"})
 		}
 
+		// O CNPJ alterado é validado, normalizado e não pode pertencer a outro comprador
+		if cnpj, present, err := normalizeDocumentField(updateData, "cnpj", document.NormalizeCNPJ); err != nil {
+			return respondError(c, err, "Erro ao validar CNPJ:", "Falha na validação")
+		} else if present {
+			validation, err := validateBuyerData(db, cnpj, "", existingID)
+			if err != nil {
+				log.Printf("Erro ao validar dados do comprador: %v", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha na validação"})
+			}
+			if validation.CnpjExists {
+				return c.Status(400).JSON(fiber.Map{"error": "CNPJ já está cadastrado"})
+			}
+		}
+
+		if err := normalizeAddressFields(updateData, "cep", "state"); err != nil {
+			return respondError(c, err, "Erro ao validar endereço do comprador:", "Falha na validação")
+		}
+
 		// Construir query dinâmica baseada nos campos fornecidos
 		var setParts []string
 		var args []interface{}
@@ -324,12 +386,13 @@ func UpdateBuyer(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar comprador atualizado"})
 		}
 
+		buyer.Cnpj = document.FormatCNPJ(buyer.Cnpj)
 		return c.Status(200).JSON(buyer)
 	}
 }
 
 // @Summary Obter comprador por User ID
-// @Description Obtém um comprador específico pelo users_id
+// @Description Obtém o comprador de cuja equipe o usuário faz parte, preferindo o de maior papel. GET /users/{id}/memberships lista todas as organizações do usuário
 // @Tags Buyers
 // @Param users_id path int true "ID do Usuário"
 // @Success 200 {object} Buyer
@@ -343,7 +406,12 @@ func GetBuyerByUserID(db *sql.DB) fiber.Handler {
 		buyerQuery := `
             SELECT id, name, description, address, neighborhood, city, state, country, phone, email, users_id, cep, cnpj
             FROM agrofood.buyers
-            WHERE users_id = ?
+            WHERE id = (
+                SELECT buyers_id FROM buyer_members
+                WHERE users_id = ?
+                ORDER BY FIELD(role, 'owner', 'manager', 'operator'), buyers_id
+                LIMIT 1
+            )
         `
 
 		var buyer Buyer
@@ -356,6 +424,7 @@ func GetBuyerByUserID(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar comprador"})
 		}
 
+		buyer.Cnpj = document.FormatCNPJ(buyer.Cnpj)
 		return c.Status(200).JSON(buyer)
 	}
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"api/pagination"
 	"database/sql"
 	"log"
 	"math/rand"
@@ -24,6 +25,11 @@ type CartItem struct {
 	Quantity   int  `json:"quantity"`
 	CartID     *int `json:"cart_id,omitempty"`
 	ProductsID *int `json:"products_id,omitempty"`
+	// Vencimento da reserva de estoque do item
+	ReservedUntil *string `json:"reserved_until,omitempty"`
+	// CEP de entrega conferido com as regiões do vendor ao adicionar o item;
+	// sem ele vale o endereço padrão do comprador
+	ShippingCEP *string `json:"shipping_cep,omitempty"`
 }
 
 // Define um struct para carrinho com itens (para busca completa)
@@ -103,11 +109,22 @@ func CreateCart(db *sql.DB) fiber.Handler {
 // @Tags Cart
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} Cart
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
 // @Router /cart [get]
 func GetCarts(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		rows, err := db.Query("SELECT id, code, created_at, users_id FROM cart")
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		keyset, keysetArgs := params.Keyset("id")
+
+		rows, err := db.Query("SELECT id, code, created_at, users_id FROM cart WHERE "+keyset+" ORDER BY id DESC LIMIT ?",
+			append(keysetArgs, params.FetchLimit())...)
 		if err != nil {
 			log.Println("Erro ao buscar carrinhos:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Falha ao buscar carrinhos"})
@@ -129,7 +146,18 @@ func GetCarts(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar carrinhos"})
 		}
 
-		return c.Status(200).JSON(carts)
+		page := pagination.NewPage(carts, params, func(cart Cart) int { return cart.ID })
+
+		if params.IncludeTotal {
+			var total int
+			if err := db.QueryRow("SELECT COUNT(*) FROM cart").Scan(&total); err != nil {
+				log.Println("Erro ao contar carrinhos:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha ao contar carrinhos"})
+			}
+			page = page.WithTotal(total)
+		}
+
+		return c.Status(200).JSON(page)
 	}
 }
 
@@ -335,6 +363,12 @@ func DeleteCartByID(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "ID do carrinho inválido"})
 		}
 
+		// O estoque reservado pelos itens volta a ficar disponível
+		if err := releaseReservations(db, "r.cart_id = ?", id); err != nil {
+			log.Println("Erro ao liberar reservas do carrinho:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar carrinho"})
+		}
+
 		query := "DELETE FROM cart WHERE id = ?"
 		result, err := db.Exec(query, id)
 		if err != nil {
@@ -360,6 +394,7 @@ func DeleteCartByID(db *sql.DB) fiber.Handler {
 
 // CreateCartItem cria um novo Item do Carrinho
 // @Summary Cria um novo Item do Carrinho
+// @Description Adiciona o produto ao carrinho e reserva o estoque do item por RESERVATION_TTL_MINUTES minutos (padrão 15). Cada atividade no carrinho prorroga as reservas. Produtos de vendors que não entregam no CEP informado (shipping_cep) ou no endereço padrão do comprador, e que não oferecem retirada, são recusados com a lista dos vendors
 // @Tags CartItems
 // @Accept  json
 // @Produce  json
@@ -377,52 +412,64 @@ func CreateCartItem(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
 		}
 
-		// Verificar estoque antes de adicionar
-		var availableStock int
-		stockQuery := "SELECT quantity FROM products WHERE id = ?"
-		err := db.QueryRow(stockQuery, newItem.ProductsID).Scan(&availableStock)
+		if newItem.CartID == nil || newItem.ProductsID == nil || newItem.Quantity <= 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar item do carrinho"})
+		}
+		defer tx.Rollback()
+
+		// Verificar disponibilidade antes de adicionar
+		purchasable, _, err := productPurchasable(tx, newItem.ProductsID)
 		if err != nil {
 			log.Println("Erro ao verificar estoque:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar estoque do produto"})
 		}
 
-		if availableStock < newItem.Quantity {
-			return c.Status(400).JSON(fiber.Map{"error": "Quantidade solicitada excede o estoque disponível"})
+		if !purchasable {
+			return c.Status(400).JSON(fiber.Map{"error": "Produto indisponível para compra"})
+		}
+
+		// O carrinho guarda a variante escolhida, nunca o produto principal
+		hasVariants, err := productHasVariants(tx, newItem.ProductsID)
+		if err != nil {
+			log.Println("Erro ao verificar variantes do produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar produto"})
+		}
+		if hasVariants {
+			return c.Status(400).JSON(fiber.Map{"error": "Produto com variantes; adicione a variante escolhida"})
+		}
+
+		// O vendor precisa entregar no endereço do comprador ou oferecer retirada
+		if err := checkCartItemDelivery(tx, *newItem.CartID, *newItem.ProductsID, newItem.ShippingCEP); err != nil {
+			return respondDeliveryError(c, err, "Erro ao verificar regiões de entrega:", "Erro ao criar item do carrinho")
 		}
 
 		// Verificar se o item já existe no carrinho
-		var existingQuantity int
-		existingQuery := "SELECT quantity FROM cart_items WHERE cart_id = ? AND products_id = ?"
-		err = db.QueryRow(existingQuery, newItem.CartID, newItem.ProductsID).Scan(&existingQuantity)
+		var existingID, existingQuantity int
+		existingQuery := "SELECT id, quantity FROM cart_items WHERE cart_id = ? AND products_id = ? FOR UPDATE"
+		err = tx.QueryRow(existingQuery, newItem.CartID, newItem.ProductsID).Scan(&existingID, &existingQuantity)
 
+		status := 201
 		if err == nil {
 			// Item existe, atualizar quantidade
-			totalQuantity := existingQuantity + newItem.Quantity
-			if totalQuantity > availableStock {
-				return c.Status(400).JSON(fiber.Map{"error": "Quantidade total excede o estoque disponível"})
-			}
+			newItem.ID = existingID
+			newItem.Quantity += existingQuantity
+			status = 200
 
-			updateQuery := "UPDATE cart_items SET quantity = ? WHERE cart_id = ? AND products_id = ?"
-			_, err = db.Exec(updateQuery, totalQuantity, newItem.CartID, newItem.ProductsID)
-			if err != nil {
+			updateQuery := "UPDATE cart_items SET quantity = ? WHERE id = ?"
+			if _, err := tx.Exec(updateQuery, newItem.Quantity, existingID); err != nil {
 				log.Println("Erro ao atualizar item no carrinho:", err)
 				return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar item no carrinho"})
 			}
-
-			// Buscar o item atualizado para retornar
-			query := "SELECT id, quantity, cart_id, products_id FROM cart_items WHERE cart_id = ? AND products_id = ?"
-			err = db.QueryRow(query, newItem.CartID, newItem.ProductsID).Scan(
-				&newItem.ID, &newItem.Quantity, &newItem.CartID, &newItem.ProductsID)
-			if err != nil {
-				log.Println("Erro ao buscar item atualizado:", err)
-				return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar item atualizado"})
-			}
-
-			return c.Status(200).JSON(newItem)
 		} else if err == sql.ErrNoRows {
 			// Item não existe, inserir novo
 			query := "INSERT INTO cart_items (quantity, cart_id, products_id) VALUES (?, ?, ?)"
-			result, err := db.Exec(query, newItem.Quantity, newItem.CartID, newItem.ProductsID)
+			result, err := tx.Exec(query, newItem.Quantity, newItem.CartID, newItem.ProductsID)
 			if err != nil {
 				log.Println("Erro ao criar item do carrinho:", err)
 				return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar item do carrinho"})
@@ -433,13 +480,25 @@ func CreateCartItem(db *sql.DB) fiber.Handler {
 				log.Println("Erro ao obter ID do novo item:", err)
 				return c.Status(500).JSON(fiber.Map{"error": "Erro ao obter ID do novo item"})
 			}
-
 			newItem.ID = int(itemID)
-			return c.Status(201).JSON(newItem)
 		} else {
 			log.Println("Erro ao verificar item existente:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar item existente"})
 		}
+
+		// Reservar o estoque do item pelo prazo da reserva
+		reservedUntil, err := reserveCartItem(tx, newItem.ID, newItem.Quantity)
+		if err != nil {
+			return respondError(c, err, "Erro ao reservar estoque:", "Erro ao reservar estoque do produto")
+		}
+		newItem.ReservedUntil = &reservedUntil
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar item do carrinho"})
+		}
+
+		return c.Status(status).JSON(newItem)
 	}
 }
 
@@ -448,11 +507,22 @@ func CreateCartItem(db *sql.DB) fiber.Handler {
 // @Tags CartItems
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} CartItem
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
 // @Router /cart/cart-items [get]
 func GetCartItems(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		rows, err := db.Query("SELECT id, quantity, cart_id, products_id FROM cart_items")
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		keyset, keysetArgs := params.Keyset("id")
+
+		rows, err := db.Query("SELECT id, quantity, cart_id, products_id FROM cart_items WHERE "+keyset+" ORDER BY id DESC LIMIT ?",
+			append(keysetArgs, params.FetchLimit())...)
 		if err != nil {
 			log.Println("Erro ao buscar itens do carrinho:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Falha ao buscar itens do carrinho"})
@@ -474,7 +544,18 @@ func GetCartItems(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar itens"})
 		}
 
-		return c.Status(200).JSON(items)
+		page := pagination.NewPage(items, params, func(item CartItem) int { return item.ID })
+
+		if params.IncludeTotal {
+			var total int
+			if err := db.QueryRow("SELECT COUNT(*) FROM cart_items").Scan(&total); err != nil {
+				log.Println("Erro ao contar itens do carrinho:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha ao contar itens do carrinho"})
+			}
+			page = page.WithTotal(total)
+		}
+
+		return c.Status(200).JSON(page)
 	}
 }
 
@@ -549,20 +630,9 @@ func UpdateCartItem(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar item"})
 		}
 
-		// Verificar estoque se a quantidade for alterada
-		if itemUpdates.Quantity != 0 && itemUpdates.Quantity != existingItem.Quantity {
-			var availableStock int
-			stockQuery := "SELECT quantity FROM products WHERE id = ?"
-			err := db.QueryRow(stockQuery, existingItem.ProductsID).Scan(&availableStock)
-			if err != nil {
-				log.Println("Erro ao verificar estoque:", err)
-				return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar estoque do produto"})
-			}
-
-			if itemUpdates.Quantity > availableStock {
-				return c.Status(400).JSON(fiber.Map{"error": "Quantidade solicitada excede o estoque disponível"})
-			}
-		}
+		// Trocar de produto ou de carrinho libera a reserva anterior
+		moved := (itemUpdates.CartID != nil && *itemUpdates.CartID != *existingItem.CartID) ||
+			(itemUpdates.ProductsID != nil && *itemUpdates.ProductsID != *existingItem.ProductsID)
 
 		// Atualiza somente os campos que foram enviados no body
 		if itemUpdates.Quantity != 0 {
@@ -575,14 +645,60 @@ func UpdateCartItem(db *sql.DB) fiber.Handler {
 			existingItem.ProductsID = itemUpdates.ProductsID
 		}
 
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar item"})
+		}
+		defer tx.Rollback()
+
+		purchasable, _, err := productPurchasable(tx, existingItem.ProductsID)
+		if err != nil {
+			log.Println("Erro ao verificar estoque:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar estoque do produto"})
+		}
+
+		if !purchasable {
+			return c.Status(400).JSON(fiber.Map{"error": "Produto indisponível para compra"})
+		}
+
+		// O carrinho guarda a variante escolhida, nunca o produto principal
+		hasVariants, err := productHasVariants(tx, existingItem.ProductsID)
+		if err != nil {
+			log.Println("Erro ao verificar variantes do produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar produto"})
+		}
+		if hasVariants {
+			return c.Status(400).JSON(fiber.Map{"error": "Produto com variantes; adicione a variante escolhida"})
+		}
+
+		if moved {
+			if err := releaseReservations(tx, "r.cart_items_id = ?", itemID); err != nil {
+				log.Println("Erro ao liberar reserva do item:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar item"})
+			}
+		}
+
 		// Atualiza os dados no banco de dados
-		_, err = db.Exec("UPDATE cart_items SET quantity = ?, cart_id = ?, products_id = ? WHERE id = ?",
+		_, err = tx.Exec("UPDATE cart_items SET quantity = ?, cart_id = ?, products_id = ? WHERE id = ?",
 			existingItem.Quantity, existingItem.CartID, existingItem.ProductsID, itemID)
 		if err != nil {
 			log.Println("Erro ao atualizar item:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar item"})
 		}
 
+		// Reservar a nova quantidade, prorrogando as reservas do carrinho
+		reservedUntil, err := reserveCartItem(tx, itemID, existingItem.Quantity)
+		if err != nil {
+			return respondError(c, err, "Erro ao reservar estoque:", "Erro ao reservar estoque do produto")
+		}
+		existingItem.ReservedUntil = &reservedUntil
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar item"})
+		}
+
 		return c.Status(200).JSON(existingItem)
 	}
 }
@@ -606,6 +722,12 @@ func DeleteCartItemByID(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "ID do item inválido"})
 		}
 
+		// O estoque reservado pelo item volta a ficar disponível
+		if err := releaseReservations(db, "r.cart_items_id = ?", id); err != nil {
+			log.Println("Erro ao liberar reserva do item:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar item"})
+		}
+
 		query := "DELETE FROM cart_items WHERE id = ?"
 		result, err := db.Exec(query, id)
 		if err != nil {
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"log"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -14,6 +15,130 @@ type Category struct {
 	Name                 string `json:"name"`
 	Description          string `json:"description"`
 	IDCategoriesProducts *int   `json:"id_categories_products,omitempty"`
+	// Produtos desta categoria e das subcategorias passam por moderação
+	RequiresModeration *bool `json:"requires_moderation"`
+}
+
+// CategoryMove representa o destino de uma movimentação de subárvore
+// (parent_id nulo move a categoria para a raiz)
+type CategoryMove struct {
+	ParentID *int `json:"parent_id"`
+}
+
+// CategoryDeleteReport descreve o que foi afetado pela exclusão de uma categoria
+type CategoryDeleteReport struct {
+	Mode               string `json:"mode"`
+	DeletedCategories  []int  `json:"deleted_categories"`
+	ReassignedProducts int64  `json:"reassigned_products"`
+	ReassignedChildren int64  `json:"reassigned_children"`
+	DeletedProducts    int64  `json:"deleted_products"`
+	NewParentID        *int   `json:"new_parent_id,omitempty"`
+}
+
+// sqlQueryer abstrai *sql.DB e *sql.Tx para as funções auxiliares
+type sqlQueryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// placeholders monta a lista "?, ?, ?" para cláusulas IN
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// intArgs converte uma lista de IDs em argumentos para a query
+func intArgs(ids []int) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
+
+// loadCategoryParents carrega o mapa id -> categoria pai de todas as categorias.
+// Com forUpdate as linhas ficam bloqueadas até o fim da transação.
+func loadCategoryParents(q sqlQueryer, forUpdate bool) (map[int]*int, error) {
+	query := "SELECT id, id_categories_products FROM categories_products"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parents := make(map[int]*int)
+	for rows.Next() {
+		var id int
+		var parentID *int
+		if err := rows.Scan(&id, &parentID); err != nil {
+			return nil, err
+		}
+		parents[id] = parentID
+	}
+
+	return parents, rows.Err()
+}
+
+// categoryDescendants retorna todas as subcategorias de id em ordem de largura
+// (pais antes dos filhos)
+func categoryDescendants(parents map[int]*int, id int) []int {
+	children := make(map[int][]int)
+	for childID, parentID := range parents {
+		if parentID != nil {
+			children[*parentID] = append(children[*parentID], childID)
+		}
+	}
+
+	var descendants []int
+	visited := map[int]bool{id: true}
+	queue := []int{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, childID := range children[current] {
+			if visited[childID] {
+				continue
+			}
+			visited[childID] = true
+			descendants = append(descendants, childID)
+			queue = append(queue, childID)
+		}
+	}
+
+	return descendants
+}
+
+// categoryAncestors retorna a cadeia de categorias pai de id, da mais próxima
+// até a raiz
+func categoryAncestors(parents map[int]*int, id int) []int {
+	var ancestors []int
+	visited := map[int]bool{id: true}
+	for parentID := parents[id]; parentID != nil; parentID = parents[*parentID] {
+		if visited[*parentID] {
+			break
+		}
+		visited[*parentID] = true
+		ancestors = append(ancestors, *parentID)
+	}
+	return ancestors
+}
+
+// createsCategoryCycle indica se colocar id abaixo de newParentID criaria um
+// ciclo, isto é, se newParentID é a própria categoria ou uma de suas descendentes
+func createsCategoryCycle(parents map[int]*int, id, newParentID int) bool {
+	if id == newParentID {
+		return true
+	}
+	for _, ancestorID := range categoryAncestors(parents, newParentID) {
+		if ancestorID == id {
+			return true
+		}
+	}
+	return false
 }
 
 // CreateCategory cria uma nova Categoria
@@ -36,8 +161,13 @@ func CreateCategory(db *sql.DB) fiber.Handler {
 		}
 
 		// Insere a nova Categoria no banco de dados
-		query := "INSERT INTO categories_products (name, description, id_categories_products) VALUES (?, ?, ?)"
-		result, err := db.Exec(query, newCategory.Name, newCategory.Description, newCategory.IDCategoriesProducts)
+		if newCategory.RequiresModeration == nil {
+			requiresModeration := false
+			newCategory.RequiresModeration = &requiresModeration
+		}
+
+		query := "INSERT INTO categories_products (name, description, id_categories_products, requires_moderation) VALUES (?, ?, ?, ?)"
+		result, err := db.Exec(query, newCategory.Name, newCategory.Description, newCategory.IDCategoriesProducts, *newCategory.RequiresModeration)
 		if err != nil {
 			log.Println("Erro ao criar categoria:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar categoria"})
@@ -53,6 +183,8 @@ func CreateCategory(db *sql.DB) fiber.Handler {
 		// Atribui o ID à nova Categoria
 		newCategory.ID = int(categoryID)
 
+		refreshSuggestionsLater()
+
 		return c.Status(201).JSON(newCategory)
 	}
 }
@@ -65,7 +197,7 @@ func CreateCategory(db *sql.DB) fiber.Handler {
 // @Param id path int true "ID da Categoria"
 // @Param category body Category true "Dados da Categoria para atualização parcial"
 // @Success 200 {object} Category "Categoria atualizada com sucesso"
-// @Failure 400 {object} map[string]string "ID inválido ou dados de entrada inválidos"
+// @Failure 400 {object} map[string]string "ID inválido, dados de entrada inválidos ou ciclo na árvore"
 // @Failure 404 {object} map[string]string "Categoria não encontrada"
 // @Failure 500 {object} map[string]string "Erro ao atualizar Categoria"
 // @Router /categories/{id} [patch]
@@ -83,13 +215,21 @@ func UpdateCategory(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
 		}
 
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar categoria"})
+		}
+		defer tx.Rollback()
+
 		// Verifica se a categoria existe
 		var existingCategory Category
-		err = db.QueryRow("SELECT id, name, description, id_categories_products FROM categories_products WHERE id = ?", categoryID).Scan(
+		err = tx.QueryRow("SELECT id, name, description, id_categories_products, requires_moderation FROM categories_products WHERE id = ? FOR UPDATE", categoryID).Scan(
 			&existingCategory.ID,
 			&existingCategory.Name,
 			&existingCategory.Description,
-			&existingCategory.IDCategoriesProducts)
+			&existingCategory.IDCategoriesProducts,
+			&existingCategory.RequiresModeration)
 		if err == sql.ErrNoRows {
 			return c.Status(404).JSON(fiber.Map{"error": "Categoria não encontrada"})
 		} else if err != nil {
@@ -106,17 +246,44 @@ func UpdateCategory(db *sql.DB) fiber.Handler {
 		}
 		// Para campos nullable, verificamos se foi enviado no request
 		if categoryUpdates.IDCategoriesProducts != nil {
+			// Bloqueia a árvore, como em MoveCategory, para que alterações
+			// concorrentes do pai não criem ciclos
+			parents, err := loadCategoryParents(tx, true)
+			if err != nil {
+				log.Println("Erro ao carregar árvore de categorias:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar categoria"})
+			}
+
+			newParentID := *categoryUpdates.IDCategoriesProducts
+			if _, exists := parents[newParentID]; !exists {
+				return c.Status(400).JSON(fiber.Map{"error": "Categoria pai não encontrada"})
+			}
+			if createsCategoryCycle(parents, categoryID, newParentID) {
+				return c.Status(400).JSON(fiber.Map{"error": "A categoria pai não pode ser a própria categoria ou uma de suas subcategorias"})
+			}
+
 			existingCategory.IDCategoriesProducts = categoryUpdates.IDCategoriesProducts
 		}
+		if categoryUpdates.RequiresModeration != nil {
+			existingCategory.RequiresModeration = categoryUpdates.RequiresModeration
+		}
 
 		// Atualiza os dados no banco de dados
-		_, err = db.Exec("UPDATE categories_products SET name = ?, description = ?, id_categories_products = ? WHERE id = ?",
-			existingCategory.Name, existingCategory.Description, existingCategory.IDCategoriesProducts, categoryID)
+		_, err = tx.Exec("UPDATE categories_products SET name = ?, description = ?, id_categories_products = ?, requires_moderation = ? WHERE id = ?",
+			existingCategory.Name, existingCategory.Description, existingCategory.IDCategoriesProducts, existingCategory.RequiresModeration, categoryID)
 		if err != nil {
 			log.Println("Erro ao atualizar categoria:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar categoria"})
 		}
 
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar categoria"})
+		}
+
+		// O nome da categoria faz parte dos documentos indexados
+		reindexProductsLogged(db, "p.categories_products_id = ?", categoryID)
+
 		return c.Status(200).JSON(existingCategory)
 	}
 }
@@ -141,8 +308,8 @@ func GetCategoryByID(db *sql.DB) fiber.Handler {
 		}
 
 		var category Category
-		query := "SELECT id, name, description, id_categories_products FROM categories_products WHERE id = ?"
-		err := db.QueryRow(query, id).Scan(&category.ID, &category.Name, &category.Description, &category.IDCategoriesProducts)
+		query := "SELECT id, name, description, id_categories_products, requires_moderation FROM categories_products WHERE id = ?"
+		err := db.QueryRow(query, id).Scan(&category.ID, &category.Name, &category.Description, &category.IDCategoriesProducts, &category.RequiresModeration)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				return c.Status(404).JSON(fiber.Map{"error": "Categoria não encontrada"})
@@ -165,7 +332,7 @@ func GetCategoryByID(db *sql.DB) fiber.Handler {
 // @Router /categories [get]
 func GetCategories(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		rows, err := db.Query("SELECT id, name, description, id_categories_products FROM categories_products")
+		rows, err := db.Query("SELECT id, name, description, id_categories_products, requires_moderation FROM categories_products")
 		if err != nil {
 			log.Println("Erro ao buscar categorias:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Falha ao buscar categorias"})
@@ -175,7 +342,7 @@ func GetCategories(db *sql.DB) fiber.Handler {
 		var categories []Category
 		for rows.Next() {
 			var category Category
-			if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.IDCategoriesProducts); err != nil {
+			if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.IDCategoriesProducts, &category.RequiresModeration); err != nil {
 				log.Println("Erro ao ler dados da categoria:", err)
 				return c.Status(500).JSON(fiber.Map{"error": "Falha ao ler dados da categoria"})
 			}
@@ -191,43 +358,296 @@ func GetCategories(db *sql.DB) fiber.Handler {
 	}
 }
 
+// MoveCategory move uma categoria (com toda a sua subárvore) para outro pai
+// @Summary Move uma categoria e suas subcategorias para outra categoria pai
+// @Tags Categories
+// @Accept  json
+// @Produce  json
+// @Param id path int true "ID da categoria"
+// @Param move body CategoryMove true "Nova categoria pai (null para mover para a raiz)"
+// @Success 200 {object} map[string]interface{} "Categoria movida com sucesso"
+// @Failure 400 {object} map[string]string "Dados inválidos ou ciclo na árvore"
+// @Failure 404 {object} map[string]string "Categoria não encontrada"
+// @Failure 500 {object} map[string]string "Erro ao mover categoria"
+// @Router /categories/{id}/move [post]
+func MoveCategory(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		categoryID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+
+		var move CategoryMove
+		if err := c.BodyParser(&move); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao mover categoria"})
+		}
+		defer tx.Rollback()
+
+		// Bloqueia a árvore para que movimentações concorrentes não criem ciclos
+		parents, err := loadCategoryParents(tx, true)
+		if err != nil {
+			log.Println("Erro ao carregar árvore de categorias:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao mover categoria"})
+		}
+
+		if _, exists := parents[categoryID]; !exists {
+			return c.Status(404).JSON(fiber.Map{"error": "Categoria não encontrada"})
+		}
+
+		if move.ParentID != nil {
+			if _, exists := parents[*move.ParentID]; !exists {
+				return c.Status(400).JSON(fiber.Map{"error": "Categoria pai não encontrada"})
+			}
+			if createsCategoryCycle(parents, categoryID, *move.ParentID) {
+				return c.Status(400).JSON(fiber.Map{"error": "A categoria não pode ser movida para si mesma ou para uma de suas subcategorias"})
+			}
+		}
+
+		_, err = tx.Exec("UPDATE categories_products SET id_categories_products = ? WHERE id = ?", move.ParentID, categoryID)
+		if err != nil {
+			log.Println("Erro ao mover categoria:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao mover categoria"})
+		}
+
+		var category Category
+		err = tx.QueryRow("SELECT id, name, description, id_categories_products, requires_moderation FROM categories_products WHERE id = ?", categoryID).Scan(
+			&category.ID, &category.Name, &category.Description, &category.IDCategoriesProducts, &category.RequiresModeration)
+		if err != nil {
+			log.Println("Erro ao buscar categoria movida:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao mover categoria"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao mover categoria"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{
+			"message":          "Categoria movida com sucesso",
+			"category":         category,
+			"moved_categories": 1 + len(categoryDescendants(parents, categoryID)),
+		})
+	}
+}
+
 // DeleteCategoryByID deleta uma categoria baseado no ID
 // @Summary Deleta uma categoria pelo ID
+// @Description Modos: restrict (padrão) recusa se houver produtos ou subcategorias; reassign move produtos e subcategorias para a categoria pai; cascade remove a subárvore e seus produtos e é restrito a administradores
 // @Tags Categories
 // @Accept  json
 // @Produce  json
 // @Param id path int true "ID da categoria"
-// @Success 200 {object} map[string]string "Category deleted successfully"
-// @Failure 404 {object} map[string]string "Category not found"
-// @Failure 500 {object} map[string]string "Failed to delete category"
+// @Param mode query string false "Modo de exclusão (restrict, reassign, cascade)" default(restrict)
+// @Param X-User-ID header int false "ID do administrador; obrigatório no modo cascade"
+// @Success 200 {object} CategoryDeleteReport "Categoria deletada com sucesso"
+// @Failure 400 {object} map[string]string "ID ou modo inválido"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Modo cascade restrito a administradores"
+// @Failure 404 {object} map[string]string "Categoria não encontrada"
+// @Failure 409 {object} map[string]interface{} "Categoria possui produtos ou subcategorias, ou produtos da subárvore têm variantes em outras categorias"
+// @Failure 500 {object} map[string]string "Falha ao deletar categoria"
 // @Router /categories/{id} [delete]
 func DeleteCategoryByID(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		id := c.Params("id")
-
-		// Verifica se o ID é um número válido
-		if id == "" {
+		categoryID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
 			return c.Status(400).JSON(fiber.Map{"error": "ID da categoria inválido"})
 		}
 
-		query := "DELETE FROM categories_products WHERE id = ?"
-		result, err := db.Exec(query, id)
+		mode := c.Query("mode", "restrict")
+		if mode != "restrict" && mode != "reassign" && mode != "cascade" {
+			return c.Status(400).JSON(fiber.Map{"error": "Modo inválido. Use: restrict, reassign ou cascade"})
+		}
+
+		// O modo cascade remove produtos de qualquer vendor
+		if mode == "cascade" {
+			if _, err := authorizeAdmin(db, c); err != nil {
+				return respondError(c, err, "Erro ao verificar permissões do usuário:", "Falha ao deletar categoria")
+			}
+		}
+
+		tx, err := db.Begin()
 		if err != nil {
-			log.Println("Erro ao deletar categoria:", err)
+			log.Println("Erro ao iniciar transação:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
 		}
+		defer tx.Rollback()
 
-		rowsAffected, err := result.RowsAffected()
+		parents, err := loadCategoryParents(tx, true)
 		if err != nil {
-			log.Println("Erro ao verificar linhas afetadas:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Falha ao verificar linhas afetadas"})
+			log.Println("Erro ao carregar árvore de categorias:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
 		}
 
-		if rowsAffected == 0 {
+		parentID, exists := parents[categoryID]
+		if !exists {
 			return c.Status(404).JSON(fiber.Map{"error": "Categoria não encontrada"})
 		}
 
-		// Resposta com status 200 (com mensagem de sucesso)
-		return c.Status(200).JSON(fiber.Map{"message": "Categoria deletada com sucesso"})
+		var childCount int
+		for _, p := range parents {
+			if p != nil && *p == categoryID {
+				childCount++
+			}
+		}
+
+		var productCount int
+		err = tx.QueryRow("SELECT COUNT(*) FROM products WHERE categories_products_id = ?", categoryID).Scan(&productCount)
+		if err != nil {
+			log.Println("Erro ao contar produtos da categoria:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
+		}
+
+		report := CategoryDeleteReport{Mode: mode}
+		var removedProducts []int
+
+		switch mode {
+		case "restrict":
+			if productCount > 0 || childCount > 0 {
+				return c.Status(409).JSON(fiber.Map{
+					"error":           "Categoria possui produtos ou subcategorias",
+					"products":        productCount,
+					"subcategories":   childCount,
+					"available_modes": []string{"reassign", "cascade"},
+				})
+			}
+
+		case "reassign":
+			if parentID == nil && productCount > 0 {
+				return c.Status(400).JSON(fiber.Map{"error": "Categoria raiz com produtos não possui categoria pai para reatribuição"})
+			}
+
+			result, err := tx.Exec("UPDATE categories_products SET id_categories_products = ? WHERE id_categories_products = ?", parentID, categoryID)
+			if err != nil {
+				log.Println("Erro ao reatribuir subcategorias:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha ao reatribuir subcategorias"})
+			}
+			report.ReassignedChildren, _ = result.RowsAffected()
+
+			if productCount > 0 {
+				result, err = tx.Exec("UPDATE products SET categories_products_id = ? WHERE categories_products_id = ?", *parentID, categoryID)
+				if err != nil {
+					log.Println("Erro ao reatribuir produtos:", err)
+					return c.Status(500).JSON(fiber.Map{"error": "Falha ao reatribuir produtos"})
+				}
+				report.ReassignedProducts, _ = result.RowsAffected()
+			}
+			report.NewParentID = parentID
+
+		case "cascade":
+			subtree := append([]int{categoryID}, categoryDescendants(parents, categoryID)...)
+			inClause := placeholders(len(subtree))
+
+			// Produtos com histórico de pedidos não podem ser removidos
+			var orderedCount int
+			err = tx.QueryRow(`
+				SELECT COUNT(*) FROM order_items oi
+				INNER JOIN products p ON oi.products_id = p.id
+				WHERE p.categories_products_id IN (`+inClause+`)`, intArgs(subtree)...).Scan(&orderedCount)
+			if err != nil {
+				log.Println("Erro ao verificar histórico de pedidos:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
+			}
+			if orderedCount > 0 {
+				return c.Status(409).JSON(fiber.Map{"error": "Existem produtos desta subárvore em pedidos; use o modo reassign"})
+			}
+
+			// Variantes em outras categorias ficariam sem o produto principal
+			var outsideVariants int
+			err = tx.QueryRow(`
+				SELECT COUNT(*) FROM products pv
+				INNER JOIN products p ON pv.parent_id = p.id
+				WHERE p.categories_products_id IN (`+inClause+`) AND pv.categories_products_id NOT IN (`+inClause+`)`,
+				append(intArgs(subtree), intArgs(subtree)...)...).Scan(&outsideVariants)
+			if err != nil {
+				log.Println("Erro ao verificar variantes dos produtos:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
+			}
+			if outsideVariants > 0 {
+				return c.Status(409).JSON(fiber.Map{
+					"error":    "Produtos desta subárvore têm variantes em outras categorias; desvincule-as ou use o modo reassign",
+					"variants": outsideVariants,
+				})
+			}
+
+			productFilter := "SELECT id FROM products WHERE categories_products_id IN (" + inClause + ")"
+			rows, err := tx.Query(productFilter, intArgs(subtree)...)
+			if err != nil {
+				log.Println("Erro ao listar produtos da subárvore:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
+			}
+			for rows.Next() {
+				var productID int
+				if err := rows.Scan(&productID); err != nil {
+					rows.Close()
+					log.Println("Erro ao ler produto da subárvore:", err)
+					return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
+				}
+				removedProducts = append(removedProducts, productID)
+			}
+			rows.Close()
+
+			if _, err := tx.Exec("DELETE FROM images WHERE products_id IN ("+productFilter+")", intArgs(subtree)...); err != nil {
+				log.Println("Erro ao remover imagens dos produtos:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
+			}
+			if _, err := tx.Exec("DELETE FROM cart_items WHERE products_id IN ("+productFilter+")", intArgs(subtree)...); err != nil {
+				log.Println("Erro ao remover itens de carrinho:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
+			}
+
+			// Desvincula as variantes da subárvore antes de remover os principais
+			if _, err := tx.Exec("UPDATE products SET parent_id = NULL WHERE categories_products_id IN ("+inClause+") AND parent_id IS NOT NULL", intArgs(subtree)...); err != nil {
+				log.Println("Erro ao desvincular variantes:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
+			}
+
+			result, err := tx.Exec("DELETE FROM products WHERE categories_products_id IN ("+inClause+")", intArgs(subtree)...)
+			if err != nil {
+				log.Println("Erro ao remover produtos:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
+			}
+			report.DeletedProducts, _ = result.RowsAffected()
+
+			// Remove das folhas para a raiz para respeitar a chave estrangeira
+			for i := len(subtree) - 1; i > 0; i-- {
+				if _, err := tx.Exec("DELETE FROM categories_products WHERE id = ?", subtree[i]); err != nil {
+					log.Println("Erro ao deletar subcategoria:", err)
+					return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
+				}
+				report.DeletedCategories = append(report.DeletedCategories, subtree[i])
+			}
+		}
+
+		if _, err := tx.Exec("DELETE FROM categories_products WHERE id = ?", categoryID); err != nil {
+			log.Println("Erro ao deletar categoria:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
+		}
+		report.DeletedCategories = append(report.DeletedCategories, categoryID)
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Falha ao deletar categoria"})
+		}
+
+		// Mantém o índice de busca coerente com a nova categoria dos produtos
+		if report.ReassignedProducts > 0 {
+			reindexProductsLogged(db, "p.categories_products_id = ?", *parentID)
+		}
+		for _, productID := range removedProducts {
+			productIndex.Remove(productID)
+		}
+		refreshSuggestionsLater()
+
+		return c.Status(200).JSON(fiber.Map{
+			"message": "Categoria deletada com sucesso",
+			"report":  report,
+		})
 	}
 }
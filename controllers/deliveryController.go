@@ -0,0 +1,918 @@
+package controllers
+
+import (
+	"api/address"
+	"api/search"
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Raio máximo de uma região de entrega, em km
+const maxDeliveryRadiusKm = 500
+
+// Quantidade máxima de locais de retirada de um vendor
+const maxPickupPoints = 20
+
+// Raio médio da Terra, em km, para a distância entre coordenadas
+const earthRadiusKm = 6371.0
+
+// Formas de atendimento do pedido
+const (
+	FulfillmentDelivery = "delivery"
+	FulfillmentPickup   = "pickup"
+)
+
+// PickupHours é um intervalo de funcionamento de um local de retirada
+type PickupHours struct {
+	// Dia da semana, de 0 (domingo) a 6 (sábado)
+	Weekday int `json:"weekday"`
+	// Horários no formato HH:MM
+	Opens  string `json:"opens"`
+	Closes string `json:"closes"`
+}
+
+// VendorPickupPoint é um local onde o comprador retira os pedidos do vendor
+type VendorPickupPoint struct {
+	ID           int           `json:"id"`
+	VendorsID    int           `json:"vendors_id"`
+	Name         string        `json:"name"`
+	Address      string        `json:"address"`
+	Neighborhood *string       `json:"neighborhood"`
+	City         string        `json:"city"`
+	State        string        `json:"state"`
+	Cep          string        `json:"cep"`
+	Instructions *string       `json:"instructions"`
+	Active       bool          `json:"active"`
+	Hours        []PickupHours `json:"hours"`
+	CreatedAt    string        `json:"created_at"`
+	UpdatedAt    string        `json:"updated_at"`
+}
+
+// VendorPickupPointRequest são os dados para cadastrar ou substituir um local
+// de retirada, com pelo menos um horário de funcionamento
+type VendorPickupPointRequest struct {
+	Name         string        `json:"name"`
+	Address      string        `json:"address"`
+	Neighborhood string        `json:"neighborhood"`
+	City         string        `json:"city"`
+	State        string        `json:"state"`
+	Cep          string        `json:"cep"`
+	Instructions string        `json:"instructions"`
+	Active       *bool         `json:"active,omitempty"`
+	Hours        []PickupHours `json:"hours"`
+}
+
+// CheckoutPickup escolhe a retirada em um local do vendor no lugar da entrega
+type CheckoutPickup struct {
+	VendorsID     int `json:"vendors_id"`
+	PickupPointID int `json:"pickup_point_id"`
+}
+
+// UndeliverableVendor é um vendor do carrinho que não entrega no endereço
+type UndeliverableVendor struct {
+	VendorsID  int    `json:"vendors_id"`
+	VendorName string `json:"vendor_name"`
+	// Indica se o vendor tem locais de retirada ativos
+	PickupAvailable bool `json:"pickup_available"`
+}
+
+// undeliverableError indica os vendors que não atendem o CEP de entrega
+type undeliverableError struct {
+	Cep     string
+	Vendors []UndeliverableVendor
+}
+
+func (e *undeliverableError) Error() string {
+	names := make([]string, len(e.Vendors))
+	for i, vendor := range e.Vendors {
+		names[i] = vendor.VendorName
+	}
+	if len(names) == 1 {
+		return "O vendor " + names[0] + " não entrega no CEP " + e.Cep
+	}
+	return "Os vendors " + strings.Join(names, ", ") + " não entregam no CEP " + e.Cep
+}
+
+// respondDeliveryError responde os vendors que não entregam no endereço,
+// com a lista por vendor, ou repassa o erro para respondError
+func respondDeliveryError(c *fiber.Ctx, err error, logMessage, message string) error {
+	var undeliverable *undeliverableError
+	if errors.As(err, &undeliverable) {
+		return c.Status(400).JSON(fiber.Map{
+			"error":                 undeliverable.Error(),
+			"undeliverable_vendors": undeliverable.Vendors,
+		})
+	}
+	return respondError(c, err, logMessage, message)
+}
+
+// geoPoint é uma coordenada em graus
+type geoPoint struct {
+	lat, lng float64
+}
+
+// distanceKm calcula a distância em linha reta entre duas coordenadas
+// (fórmula de haversine)
+func distanceKm(a, b geoPoint) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLat := toRadians(b.lat - a.lat)
+	dLng := toRadians(b.lng - a.lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(a.lat))*math.Cos(toRadians(b.lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// sameCity compara nomes de cidade sem diferenciar acentos, caixa e espaços
+func sameCity(a, b string) bool {
+	fold := func(value string) string { return strings.Join(strings.Fields(search.Fold(value)), " ") }
+	return fold(a) == fold(b)
+}
+
+// deliveryDestination é o endereço de entrega conferido com as regiões dos vendors
+type deliveryDestination struct {
+	Cep   string
+	State string
+	City  string
+	// Coordenadas do CEP na base local; nulas quando o CEP não tem coordenadas
+	point *geoPoint
+}
+
+// resolveDeliveryDestination completa o destino (CEP normalizado) com a
+// cidade, o estado e as coordenadas da base de CEPs
+func resolveDeliveryDestination(q sqlQueryer, cep, state, city string) (deliveryDestination, error) {
+	destination := deliveryDestination{Cep: cep, State: state, City: city}
+
+	var baseCity, baseState string
+	var lat, lng sql.NullFloat64
+	err := q.QueryRow("SELECT city, state, latitude, longitude FROM cep_addresses WHERE cep = ?", cep).
+		Scan(&baseCity, &baseState, &lat, &lng)
+	if err != nil && err != sql.ErrNoRows {
+		return destination, err
+	}
+	if err == nil {
+		if destination.City == "" {
+			destination.City = baseCity
+		}
+		if destination.State == "" {
+			destination.State = baseState
+		}
+		if lat.Valid && lng.Valid {
+			destination.point = &geoPoint{lat.Float64, lng.Float64}
+		}
+	}
+	if destination.State == "" {
+		destination.State = address.StateForCEP(cep)
+	}
+	return destination, nil
+}
+
+// covers indica se a região atende o destino; center são as coordenadas do
+// CEP do vendor, usadas nas regiões por raio
+func (z VendorDeliveryZone) covers(destination deliveryDestination, center *geoPoint) bool {
+	switch z.Type {
+	case DeliveryZoneState:
+		return z.State == destination.State
+	case DeliveryZoneCity:
+		return z.State == destination.State && z.City != nil && sameCity(*z.City, destination.City)
+	case DeliveryZoneCEPRange:
+		return z.CepStart != nil && z.CepEnd != nil && destination.Cep >= *z.CepStart && destination.Cep <= *z.CepEnd
+	case DeliveryZoneRadius:
+		return z.RadiusKm != nil && center != nil && destination.point != nil &&
+			distanceKm(*center, *destination.point) <= *z.RadiusKm
+	}
+	return false
+}
+
+// undeliverableVendors lista, entre os vendors informados, os que não
+// atendem o destino. Vendors sem regiões cadastradas entregam em qualquer endereço.
+func undeliverableVendors(q sqlQueryer, vendorIDs []int, destination deliveryDestination) ([]UndeliverableVendor, error) {
+	if len(vendorIDs) == 0 {
+		return nil, nil
+	}
+	list := placeholders(len(vendorIDs))
+	args := intArgs(vendorIDs)
+
+	rows, err := q.Query(`
+		SELECT vendors_id, type, state, city, cep_start, cep_end, radius_km
+		FROM vendor_delivery_zones
+		WHERE vendors_id IN (`+list+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	zones := make(map[int][]VendorDeliveryZone)
+	for rows.Next() {
+		var zone VendorDeliveryZone
+		if err := rows.Scan(&zone.VendorsID, &zone.Type, &zone.State, &zone.City, &zone.CepStart, &zone.CepEnd, &zone.RadiusKm); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		zones[zone.VendorsID] = append(zones[zone.VendorsID], zone)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query(`
+		SELECT v.id, v.name, ca.latitude, ca.longitude,
+			EXISTS (SELECT 1 FROM vendor_pickup_points pp WHERE pp.vendors_id = v.id AND pp.active)
+		FROM vendors v
+		LEFT JOIN cep_addresses ca ON ca.cep = v.cep
+		WHERE v.id IN (`+list+`)
+		ORDER BY v.name`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []UndeliverableVendor
+	for rows.Next() {
+		var vendor UndeliverableVendor
+		var lat, lng sql.NullFloat64
+		if err := rows.Scan(&vendor.VendorsID, &vendor.VendorName, &lat, &lng, &vendor.PickupAvailable); err != nil {
+			return nil, err
+		}
+		vendorZones := zones[vendor.VendorsID]
+		if len(vendorZones) == 0 {
+			continue
+		}
+
+		var center *geoPoint
+		if lat.Valid && lng.Valid {
+			center = &geoPoint{lat.Float64, lng.Float64}
+		}
+		covered := false
+		for _, zone := range vendorZones {
+			if zone.covers(destination, center) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			result = append(result, vendor)
+		}
+	}
+	return result, rows.Err()
+}
+
+// cartDeliveryDestination define o destino usado ao adicionar itens ao
+// carrinho: o CEP informado ou o endereço padrão do comprador do carrinho.
+// Devolve nulo quando não há endereço para conferir.
+func cartDeliveryDestination(q sqlQueryer, cartID int, shippingCEP *string) (*deliveryDestination, error) {
+	if shippingCEP != nil && strings.TrimSpace(*shippingCEP) != "" {
+		cep, err := address.NormalizeCEP(*shippingCEP)
+		if err != nil {
+			return nil, &requestError{400, err.Error()}
+		}
+		destination, err := resolveDeliveryDestination(q, cep, "", "")
+		return &destination, err
+	}
+
+	var cep, state, city string
+	err := q.QueryRow(`
+		SELECT ba.cep, ba.state, ba.city
+		FROM cart c
+		INNER JOIN buyer_members m ON m.users_id = c.users_id
+		INNER JOIN buyer_addresses ba ON ba.buyers_id = m.buyers_id AND ba.is_default
+		WHERE c.id = ?
+		ORDER BY m.buyers_id
+		LIMIT 1`, cartID).Scan(&cep, &state, &city)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	destination, err := resolveDeliveryDestination(q, cep, state, city)
+	return &destination, err
+}
+
+// checkCartItemDelivery impede adicionar ao carrinho produtos de um vendor
+// que não entrega no endereço do comprador nem oferece retirada
+func checkCartItemDelivery(q sqlQueryer, cartID, productID int, shippingCEP *string) error {
+	destination, err := cartDeliveryDestination(q, cartID, shippingCEP)
+	if err != nil || destination == nil {
+		return err
+	}
+
+	vendorIDs, err := scanIDs(q.Query("SELECT vendors_id FROM products WHERE id = ? AND vendors_id IS NOT NULL", productID))
+	if err != nil {
+		return err
+	}
+	vendors, err := undeliverableVendors(q, vendorIDs, *destination)
+	if err != nil {
+		return err
+	}
+
+	var blocked []UndeliverableVendor
+	for _, vendor := range vendors {
+		if !vendor.PickupAvailable {
+			blocked = append(blocked, vendor)
+		}
+	}
+	if len(blocked) > 0 {
+		return &undeliverableError{Cep: destination.Cep, Vendors: blocked}
+	}
+	return nil
+}
+
+// checkDelivery confere se todos os vendors informados entregam no endereço
+// de entrega do checkout
+func (r *CheckoutRequest) checkDelivery(q sqlQueryer, vendorIDs []int) error {
+	destination, err := resolveDeliveryDestination(q, r.ShippingCEP, r.ShippingState, r.ShippingCity)
+	if err != nil {
+		return err
+	}
+	vendors, err := undeliverableVendors(q, vendorIDs, destination)
+	if err != nil {
+		return err
+	}
+	if len(vendors) > 0 {
+		return &undeliverableError{Cep: destination.Cep, Vendors: vendors}
+	}
+	return nil
+}
+
+// requireShippingAddress exige os campos do endereço de entrega
+func (r *CheckoutRequest) requireShippingAddress() error {
+	switch {
+	case r.ShippingAddress == "":
+		return &requestError{400, "Endereço de entrega é obrigatório"}
+	case r.ShippingCity == "":
+		return &requestError{400, "Cidade é obrigatória"}
+	case r.ShippingState == "":
+		return &requestError{400, "Estado é obrigatório"}
+	case r.ShippingCEP == "":
+		return &requestError{400, "CEP é obrigatório"}
+	}
+	return nil
+}
+
+// pickupPoints valida as retiradas escolhidas no checkout, uma por vendor do
+// carrinho, e devolve o local de retirada de cada vendor
+func (r *CheckoutRequest) pickupPoints(q sqlQueryer, cartVendorIDs []int) (map[int]VendorPickupPoint, error) {
+	inCart := make(map[int]bool, len(cartVendorIDs))
+	for _, id := range cartVendorIDs {
+		inCart[id] = true
+	}
+
+	points := make(map[int]VendorPickupPoint, len(r.Pickups))
+	for _, pickup := range r.Pickups {
+		if !inCart[pickup.VendorsID] {
+			return nil, &requestError{400, "O vendor " + strconv.Itoa(pickup.VendorsID) + " não tem itens no carrinho"}
+		}
+		if _, repeated := points[pickup.VendorsID]; repeated {
+			return nil, &requestError{400, "Escolha um único local de retirada por vendor"}
+		}
+
+		point, err := loadPickupPoint(q, pickup.VendorsID, pickup.PickupPointID)
+		if err != nil {
+			return nil, err
+		}
+		if !point.Active {
+			return nil, &requestError{400, "O local de retirada " + point.Name + " não está disponível"}
+		}
+		points[pickup.VendorsID] = point
+	}
+	return points, nil
+}
+
+// scanIDs lê uma lista de IDs do resultado da consulta
+func scanIDs(rows *sql.Rows, err error) ([]int, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// buildDeliveryZone valida a região de entrega pedida, normalizando estado e CEPs
+func buildDeliveryZone(q sqlQueryer, vendorID int, request VendorDeliveryZoneRequest) (VendorDeliveryZone, error) {
+	zone := VendorDeliveryZone{VendorsID: vendorID, Type: request.Type}
+	city := strings.TrimSpace(request.City)
+
+	switch {
+	case request.Type != DeliveryZoneCity && city != "":
+		return zone, &requestError{400, "Apenas regiões do tipo city têm cidade"}
+	case request.Type != DeliveryZoneCEPRange && (request.CepStart != "" || request.CepEnd != ""):
+		return zone, &requestError{400, "Apenas regiões do tipo cep_range têm faixa de CEP"}
+	case request.Type != DeliveryZoneRadius && request.RadiusKm != 0:
+		return zone, &requestError{400, "Apenas regiões do tipo radius têm raio"}
+	}
+
+	switch request.Type {
+	case DeliveryZoneState, DeliveryZoneCity:
+		state, err := address.NormalizeState(request.State)
+		if err != nil {
+			return zone, &requestError{400, err.Error()}
+		}
+		zone.State = state
+		if request.Type == DeliveryZoneCity {
+			if city == "" || len(city) > 100 {
+				return zone, &requestError{400, "Informe a cidade com até 100 caracteres"}
+			}
+			zone.City = &city
+		}
+
+	case DeliveryZoneCEPRange:
+		start, err := address.NormalizeCEP(request.CepStart)
+		if err != nil {
+			return zone, &requestError{400, "cep_start: " + err.Error()}
+		}
+		end, err := address.NormalizeCEP(request.CepEnd)
+		if err != nil {
+			return zone, &requestError{400, "cep_end: " + err.Error()}
+		}
+		if start > end {
+			return zone, &requestError{400, "cep_start deve ser menor ou igual a cep_end"}
+		}
+		zone.State = address.StateForCEP(start)
+		if address.StateForCEP(end) != zone.State {
+			return zone, &requestError{400, "A faixa de CEP deve ficar em um único estado"}
+		}
+		if strings.TrimSpace(request.State) != "" {
+			if state, err := address.NormalizeState(request.State); err != nil || state != zone.State {
+				return zone, &requestError{400, "A faixa de CEP não pertence ao estado informado"}
+			}
+		}
+		zone.CepStart, zone.CepEnd = &start, &end
+
+	case DeliveryZoneRadius:
+		if request.RadiusKm <= 0 || request.RadiusKm > maxDeliveryRadiusKm {
+			return zone, &requestError{400, "Informe o raio (radius_km) entre 0 e " + strconv.Itoa(maxDeliveryRadiusKm) + " km"}
+		}
+
+		// O raio é medido a partir do CEP do vendor, que precisa ter coordenadas
+		var cep string
+		var lat sql.NullFloat64
+		err := q.QueryRow(`
+			SELECT v.cep, ca.latitude
+			FROM vendors v
+			LEFT JOIN cep_addresses ca ON ca.cep = v.cep
+			WHERE v.id = ?`, vendorID).Scan(&cep, &lat)
+		if err == sql.ErrNoRows {
+			return zone, &requestError{404, "Vendor não encontrado"}
+		} else if err != nil {
+			return zone, err
+		}
+		if !lat.Valid {
+			return zone, &requestError{400, "O CEP do vendor não tem coordenadas na base de CEPs; use outro tipo de região"}
+		}
+		zone.State = address.StateForCEP(cep)
+		radius := math.Round(request.RadiusKm*10) / 10
+		zone.RadiusKm = &radius
+
+	default:
+		return zone, &requestError{400, "Tipo inválido. Use: state, city, cep_range ou radius"}
+	}
+	return zone, nil
+}
+
+// normalize valida os dados do local de retirada e os horários de funcionamento
+func (r *VendorPickupPointRequest) normalize() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Address = strings.TrimSpace(r.Address)
+	r.Neighborhood = strings.TrimSpace(r.Neighborhood)
+	r.City = strings.TrimSpace(r.City)
+	r.Instructions = strings.TrimSpace(r.Instructions)
+
+	switch {
+	case r.Name == "" || len(r.Name) > 100:
+		return &requestError{400, "Informe o nome do local com até 100 caracteres"}
+	case r.Address == "" || len(r.Address) > 255:
+		return &requestError{400, "Informe o endereço com até 255 caracteres"}
+	case len(r.Neighborhood) > 120:
+		return &requestError{400, "O bairro deve ter até 120 caracteres"}
+	case r.City == "" || len(r.City) > 120:
+		return &requestError{400, "Informe a cidade com até 120 caracteres"}
+	case strings.TrimSpace(r.State) == "":
+		return &requestError{400, "Estado é obrigatório"}
+	case strings.TrimSpace(r.Cep) == "":
+		return &requestError{400, "CEP é obrigatório"}
+	case len(r.Instructions) > 500:
+		return &requestError{400, "As instruções devem ter até 500 caracteres"}
+	case len(r.Hours) == 0:
+		return &requestError{400, "Informe ao menos um horário de funcionamento"}
+	}
+
+	cep, state, err := normalizeAddress(r.Cep, r.State)
+	if err != nil {
+		return err
+	}
+	r.Cep, r.State = cep, state
+
+	for i := range r.Hours {
+		hours := &r.Hours[i]
+		if hours.Weekday < 0 || hours.Weekday > 6 {
+			return &requestError{400, "weekday deve ir de 0 (domingo) a 6 (sábado)"}
+		}
+		opens, errOpens := time.Parse("15:04", strings.TrimSpace(hours.Opens))
+		closes, errCloses := time.Parse("15:04", strings.TrimSpace(hours.Closes))
+		if errOpens != nil || errCloses != nil {
+			return &requestError{400, "Use o formato HH:MM nos horários de funcionamento"}
+		}
+		if !opens.Before(closes) {
+			return &requestError{400, "O horário de abertura deve ser anterior ao de fechamento"}
+		}
+		hours.Opens, hours.Closes = opens.Format("15:04"), closes.Format("15:04")
+	}
+
+	// Intervalos do mesmo dia não podem se sobrepor
+	sort.Slice(r.Hours, func(i, j int) bool {
+		if r.Hours[i].Weekday != r.Hours[j].Weekday {
+			return r.Hours[i].Weekday < r.Hours[j].Weekday
+		}
+		return r.Hours[i].Opens < r.Hours[j].Opens
+	})
+	for i := 1; i < len(r.Hours); i++ {
+		if r.Hours[i].Weekday == r.Hours[i-1].Weekday && r.Hours[i].Opens < r.Hours[i-1].Closes {
+			return &requestError{400, "Os horários de funcionamento de um mesmo dia não podem se sobrepor"}
+		}
+	}
+	return nil
+}
+
+// optionalText devolve o texto para gravação, nulo quando vazio
+func optionalText(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+const pickupPointColumns = "id, vendors_id, name, address, neighborhood, city, state, cep, instructions, active, created_at, updated_at"
+
+// scanPickupPoint lê um local selecionado com pickupPointColumns
+func scanPickupPoint(row interface{ Scan(...interface{}) error }) (VendorPickupPoint, error) {
+	var point VendorPickupPoint
+	err := row.Scan(&point.ID, &point.VendorsID, &point.Name, &point.Address, &point.Neighborhood, &point.City,
+		&point.State, &point.Cep, &point.Instructions, &point.Active, &point.CreatedAt, &point.UpdatedAt)
+	point.Hours = []PickupHours{}
+	return point, err
+}
+
+// loadPickupHours preenche os horários de funcionamento dos locais
+func loadPickupHours(q sqlQueryer, points []VendorPickupPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+	ids := make([]int, len(points))
+	index := make(map[int]int, len(points))
+	for i, point := range points {
+		ids[i] = point.ID
+		index[point.ID] = i
+	}
+
+	rows, err := q.Query(`
+		SELECT pickup_points_id, weekday, TIME_FORMAT(opens_at, '%H:%i'), TIME_FORMAT(closes_at, '%H:%i')
+		FROM vendor_pickup_hours
+		WHERE pickup_points_id IN (`+placeholders(len(ids))+`)
+		ORDER BY weekday, opens_at`, intArgs(ids)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pointID int
+		var hours PickupHours
+		if err := rows.Scan(&pointID, &hours.Weekday, &hours.Opens, &hours.Closes); err != nil {
+			return err
+		}
+		i := index[pointID]
+		points[i].Hours = append(points[i].Hours, hours)
+	}
+	return rows.Err()
+}
+
+// loadPickupPoints lista os locais de retirada do vendor com os horários
+func loadPickupPoints(q sqlQueryer, vendorID int, activeOnly bool) ([]VendorPickupPoint, error) {
+	query := "SELECT " + pickupPointColumns + " FROM vendor_pickup_points WHERE vendors_id = ?"
+	if activeOnly {
+		query += " AND active"
+	}
+	rows, err := q.Query(query+" ORDER BY name", vendorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []VendorPickupPoint{}
+	for rows.Next() {
+		point, err := scanPickupPoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return points, loadPickupHours(q, points)
+}
+
+// loadPickupPoint busca um local de retirada do vendor com os horários
+func loadPickupPoint(q sqlQueryer, vendorID, pointID int) (VendorPickupPoint, error) {
+	point, err := scanPickupPoint(q.QueryRow("SELECT "+pickupPointColumns+" FROM vendor_pickup_points WHERE id = ? AND vendors_id = ?", pointID, vendorID))
+	if err == sql.ErrNoRows {
+		return point, &requestError{404, "Local de retirada não encontrado"}
+	} else if err != nil {
+		return point, err
+	}
+	points := []VendorPickupPoint{point}
+	if err := loadPickupHours(q, points); err != nil {
+		return point, err
+	}
+	return points[0], nil
+}
+
+// savePickupHours substitui os horários de funcionamento do local
+func savePickupHours(tx *sql.Tx, pointID int, hours []PickupHours) error {
+	if _, err := tx.Exec("DELETE FROM vendor_pickup_hours WHERE pickup_points_id = ?", pointID); err != nil {
+		return err
+	}
+	for _, item := range hours {
+		_, err := tx.Exec("INSERT INTO vendor_pickup_hours (pickup_points_id, weekday, opens_at, closes_at) VALUES (?, ?, ?, ?)",
+			pointID, item.Weekday, item.Opens, item.Closes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parsePickupPointParams lê os IDs do vendor e do local de retirada da rota
+func parsePickupPointParams(c *fiber.Ctx) (int, int, error) {
+	vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+	if err != nil {
+		return 0, 0, &requestError{400, "ID do vendor inválido"}
+	}
+	pointID, err := strconv.Atoi(c.Params("point_id"))
+	if err != nil {
+		return 0, 0, &requestError{400, "ID do local de retirada inválido"}
+	}
+	return vendorID, pointID, nil
+}
+
+// @Summary Locais de retirada do vendor
+// @Description Lista os locais de retirada do vendor com os horários de funcionamento. Os inativos aparecem apenas para a equipe do vendor e administradores
+// @Tags Vendors
+// @Produce json
+// @Param X-User-ID header int false "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Success 200 {array} VendorPickupPoint
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar locais de retirada"
+// @Router /vendors/{vendor_id}/pickup-points [get]
+func GetVendorPickupPoints(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+
+		if err := ensureOrganization(db, vendorOrganization, vendorID); err != nil {
+			return respondError(c, err, "Erro ao buscar vendor:", "Erro ao buscar locais de retirada")
+		}
+		manager, err := canSeeVendorPrivate(db, c, vendorID)
+		if err != nil {
+			log.Println("Erro ao verificar permissões do usuário:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar permissões"})
+		}
+
+		points, err := loadPickupPoints(db, vendorID, !manager)
+		if err != nil {
+			log.Println("Erro ao buscar locais de retirada:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar locais de retirada"})
+		}
+
+		return c.Status(200).JSON(points)
+	}
+}
+
+// @Summary Cadastrar local de retirada
+// @Description Cadastra um local onde o comprador pode retirar os pedidos do vendor, com os horários de funcionamento. Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param point body VendorPickupPointRequest true "Dados do local de retirada"
+// @Success 201 {object} VendorPickupPoint
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 409 {object} map[string]string "Limite de locais atingido"
+// @Failure 500 {object} map[string]string "Erro ao cadastrar local de retirada"
+// @Router /vendors/{vendor_id}/pickup-points [post]
+func CreateVendorPickupPoint(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+
+		var request VendorPickupPointRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Erro ao analisar o corpo da requisição"})
+		}
+
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+		if err := request.normalize(); err != nil {
+			return respondError(c, err, "Erro ao validar local de retirada:", "Erro ao cadastrar local de retirada")
+		}
+
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM vendor_pickup_points WHERE vendors_id = ?", vendorID).Scan(&count); err != nil {
+			log.Println("Erro ao contar locais de retirada:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar local de retirada"})
+		}
+		if count >= maxPickupPoints {
+			return c.Status(409).JSON(fiber.Map{"error": "Limite de " + strconv.Itoa(maxPickupPoints) + " locais de retirada atingido"})
+		}
+
+		active := request.Active == nil || *request.Active
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar local de retirada"})
+		}
+		defer tx.Rollback()
+
+		result, err := tx.Exec(`
+			INSERT INTO vendor_pickup_points (vendors_id, name, address, neighborhood, city, state, cep, instructions, active)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			vendorID, request.Name, request.Address, optionalText(request.Neighborhood), request.City, request.State,
+			request.Cep, optionalText(request.Instructions), active)
+		if err != nil {
+			log.Println("Erro ao cadastrar local de retirada:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar local de retirada"})
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			log.Println("Erro ao obter o ID do local de retirada:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar local de retirada"})
+		}
+
+		if err := savePickupHours(tx, int(id), request.Hours); err != nil {
+			log.Println("Erro ao gravar horários do local de retirada:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar local de retirada"})
+		}
+
+		point, err := loadPickupPoint(tx, vendorID, int(id))
+		if err != nil {
+			log.Println("Erro ao buscar local de retirada:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar local de retirada"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao confirmar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar local de retirada"})
+		}
+
+		return c.Status(201).JSON(point)
+	}
+}
+
+// @Summary Atualizar local de retirada
+// @Description Substitui os dados e os horários de funcionamento do local de retirada. Com active false o local deixa de ser oferecido no checkout. Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param point_id path int true "ID do local de retirada"
+// @Param point body VendorPickupPointRequest true "Dados do local de retirada"
+// @Success 200 {object} VendorPickupPoint
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Local de retirada não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao atualizar local de retirada"
+// @Router /vendors/{vendor_id}/pickup-points/{point_id} [put]
+func UpdateVendorPickupPoint(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, pointID, err := parsePickupPointParams(c)
+		if err != nil {
+			return respondError(c, err, "Erro ao ler parâmetros:", "Erro ao atualizar local de retirada")
+		}
+
+		var request VendorPickupPointRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Erro ao analisar o corpo da requisição"})
+		}
+
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+		if err := request.normalize(); err != nil {
+			return respondError(c, err, "Erro ao validar local de retirada:", "Erro ao atualizar local de retirada")
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar local de retirada"})
+		}
+		defer tx.Rollback()
+
+		current, err := loadPickupPoint(tx, vendorID, pointID)
+		if err != nil {
+			return respondError(c, err, "Erro ao buscar local de retirada:", "Erro ao atualizar local de retirada")
+		}
+		active := current.Active
+		if request.Active != nil {
+			active = *request.Active
+		}
+
+		_, err = tx.Exec(`
+			UPDATE vendor_pickup_points
+			SET name = ?, address = ?, neighborhood = ?, city = ?, state = ?, cep = ?, instructions = ?, active = ?
+			WHERE id = ?`,
+			request.Name, request.Address, optionalText(request.Neighborhood), request.City, request.State,
+			request.Cep, optionalText(request.Instructions), active, pointID)
+		if err != nil {
+			log.Println("Erro ao atualizar local de retirada:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar local de retirada"})
+		}
+		if err := savePickupHours(tx, pointID, request.Hours); err != nil {
+			log.Println("Erro ao gravar horários do local de retirada:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar local de retirada"})
+		}
+
+		point, err := loadPickupPoint(tx, vendorID, pointID)
+		if err != nil {
+			log.Println("Erro ao buscar local de retirada:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar local de retirada"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao confirmar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar local de retirada"})
+		}
+
+		return c.Status(200).JSON(point)
+	}
+}
+
+// @Summary Remover local de retirada
+// @Description Remove o local de retirada. Pedidos já feitos mantêm o endereço do local. Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param point_id path int true "ID do local de retirada"
+// @Success 200 {object} map[string]string "Local de retirada removido"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Local de retirada não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao remover local de retirada"
+// @Router /vendors/{vendor_id}/pickup-points/{point_id} [delete]
+func DeleteVendorPickupPoint(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, pointID, err := parsePickupPointParams(c)
+		if err != nil {
+			return respondError(c, err, "Erro ao ler parâmetros:", "Erro ao remover local de retirada")
+		}
+
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		result, err := db.Exec("DELETE FROM vendor_pickup_points WHERE id = ? AND vendors_id = ?", pointID, vendorID)
+		if err != nil {
+			log.Println("Erro ao remover local de retirada:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover local de retirada"})
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Println("Erro ao obter o número de linhas afetadas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover local de retirada"})
+		}
+		if rowsAffected == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Local de retirada não encontrado"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{"message": "Local de retirada removido com sucesso"})
+	}
+}
@@ -0,0 +1,630 @@
+package controllers
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Situações da janela de entrega
+const (
+	DeliverySlotOpen   = "open"
+	DeliverySlotClosed = "closed"
+)
+
+const (
+	// Quantidade máxima de janelas publicadas por requisição
+	maxDeliverySlotsPerRequest = 200
+	// Capacidade máxima de pedidos de uma janela
+	maxDeliverySlotCapacity = 1000
+	// Período padrão e máximo, em dias, da listagem de janelas
+	defaultDeliverySlotDays = 14
+	maxDeliverySlotDays     = 62
+)
+
+const slotDateLayout = "2006-01-02"
+
+// DeliverySlot é uma janela de entrega do vendor com capacidade em pedidos
+type DeliverySlot struct {
+	ID        int    `json:"id"`
+	VendorsID int    `json:"vendors_id"`
+	Date      string `json:"date"`
+	StartsAt  string `json:"starts_at"`
+	EndsAt    string `json:"ends_at"`
+	Capacity  int    `json:"capacity"`
+	Booked    int    `json:"booked"`
+	Available int    `json:"available"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+}
+
+// DeliverySlotInput é uma janela a publicar, com data (AAAA-MM-DD) e
+// horários (HH:MM)
+type DeliverySlotInput struct {
+	Date     string `json:"date"`
+	StartsAt string `json:"starts_at"`
+	EndsAt   string `json:"ends_at"`
+	Capacity int    `json:"capacity"`
+}
+
+// DeliverySlotsRequest publica várias janelas de uma vez
+type DeliverySlotsRequest struct {
+	Slots []DeliverySlotInput `json:"slots"`
+}
+
+// DeliverySlotUpdate altera a capacidade ou a situação (open, closed) da janela
+type DeliverySlotUpdate struct {
+	Capacity *int    `json:"capacity,omitempty"`
+	Status   *string `json:"status,omitempty"`
+}
+
+// CheckoutSlot escolhe a janela de entrega do pedido de um vendor
+type CheckoutSlot struct {
+	VendorsID      int `json:"vendors_id"`
+	DeliverySlotID int `json:"delivery_slot_id"`
+}
+
+// ManifestOrderItem é um item de pedido no manifesto de entregas
+type ManifestOrderItem struct {
+	ProductName  string  `json:"product_name"`
+	VariantLabel *string `json:"variant_label,omitempty"`
+	Quantity     int     `json:"quantity"`
+}
+
+// ManifestOrder é um pedido de uma janela no manifesto de entregas
+type ManifestOrder struct {
+	ID              int                 `json:"id"`
+	OrderNumber     string              `json:"order_number"`
+	Status          string              `json:"status"`
+	Fulfillment     string              `json:"fulfillment"`
+	Total           float64             `json:"total"`
+	BuyerName       string              `json:"buyer_name"`
+	BuyerPhone      string              `json:"buyer_phone"`
+	ShippingAddress string              `json:"shipping_address"`
+	ShippingCity    string              `json:"shipping_city"`
+	ShippingState   string              `json:"shipping_state"`
+	ShippingCEP     string              `json:"shipping_cep"`
+	Items           []ManifestOrderItem `json:"items"`
+}
+
+// ManifestSlot é uma janela do dia com os pedidos agendados
+type ManifestSlot struct {
+	DeliverySlot
+	Orders []ManifestOrder `json:"orders"`
+}
+
+const deliverySlotColumns = `id, vendors_id, DATE_FORMAT(slot_date, '%Y-%m-%d'), TIME_FORMAT(starts_at, '%H:%i'),
+	TIME_FORMAT(ends_at, '%H:%i'), capacity, booked, status, created_at`
+
+// scanDeliverySlot lê uma janela selecionada com deliverySlotColumns
+func scanDeliverySlot(row interface{ Scan(...interface{}) error }) (DeliverySlot, error) {
+	var slot DeliverySlot
+	err := row.Scan(&slot.ID, &slot.VendorsID, &slot.Date, &slot.StartsAt, &slot.EndsAt,
+		&slot.Capacity, &slot.Booked, &slot.Status, &slot.CreatedAt)
+	slot.Available = slot.Capacity - slot.Booked
+	return slot, err
+}
+
+// loadDeliverySlot busca uma janela do vendor
+func loadDeliverySlot(q sqlQueryer, vendorID, slotID int) (DeliverySlot, error) {
+	slot, err := scanDeliverySlot(q.QueryRow("SELECT "+deliverySlotColumns+" FROM delivery_slots WHERE id = ? AND vendors_id = ?", slotID, vendorID))
+	if err == sql.ErrNoRows {
+		return slot, &requestError{404, "Janela de entrega não encontrada"}
+	}
+	return slot, err
+}
+
+// normalize valida a janela a publicar, que precisa começar no futuro
+func (s *DeliverySlotInput) normalize(now time.Time) error {
+	date, err := time.ParseInLocation(slotDateLayout, strings.TrimSpace(s.Date), time.Local)
+	if err != nil {
+		return &requestError{400, "Use o formato AAAA-MM-DD na data da janela"}
+	}
+	starts, errStarts := time.Parse("15:04", strings.TrimSpace(s.StartsAt))
+	ends, errEnds := time.Parse("15:04", strings.TrimSpace(s.EndsAt))
+	if errStarts != nil || errEnds != nil {
+		return &requestError{400, "Use o formato HH:MM nos horários da janela"}
+	}
+	if !starts.Before(ends) {
+		return &requestError{400, "O início da janela deve ser anterior ao fim"}
+	}
+	if s.Capacity < 1 || s.Capacity > maxDeliverySlotCapacity {
+		return &requestError{400, "A capacidade da janela deve ir de 1 a " + strconv.Itoa(maxDeliverySlotCapacity) + " pedidos"}
+	}
+
+	s.Date, s.StartsAt, s.EndsAt = date.Format(slotDateLayout), starts.Format("15:04"), ends.Format("15:04")
+	if !date.Add(time.Duration(starts.Hour())*time.Hour + time.Duration(starts.Minute())*time.Minute).After(now) {
+		return &requestError{400, "A janela de " + s.Date + " " + s.StartsAt + " já começou"}
+	}
+	return nil
+}
+
+// slotsByVendor valida as janelas escolhidas no checkout, uma por vendor do
+// carrinho, e devolve a janela de cada vendor
+func (r *CheckoutRequest) slotsByVendor(cartVendorIDs []int) (map[int]int, error) {
+	inCart := make(map[int]bool, len(cartVendorIDs))
+	for _, id := range cartVendorIDs {
+		inCart[id] = true
+	}
+
+	slots := make(map[int]int, len(r.Slots))
+	for _, choice := range r.Slots {
+		if !inCart[choice.VendorsID] {
+			return nil, &requestError{400, "O vendor " + strconv.Itoa(choice.VendorsID) + " não tem itens no carrinho"}
+		}
+		if _, repeated := slots[choice.VendorsID]; repeated {
+			return nil, &requestError{400, "Escolha uma única janela de entrega por vendor"}
+		}
+		slots[choice.VendorsID] = choice.DeliverySlotID
+	}
+	return slots, nil
+}
+
+// bookDeliverySlot ocupa uma vaga da janela do vendor. A condição na própria
+// atualização garante que pedidos simultâneos não ultrapassem a capacidade.
+func bookDeliverySlot(tx *sql.Tx, vendorID, slotID int) (DeliverySlot, error) {
+	result, err := tx.Exec(`
+		UPDATE delivery_slots SET booked = booked + 1
+		WHERE id = ? AND vendors_id = ? AND status = 'open' AND booked < capacity
+			AND TIMESTAMP(slot_date, starts_at) > NOW()`, slotID, vendorID)
+	if err != nil {
+		return DeliverySlot{}, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return DeliverySlot{}, err
+	}
+
+	slot, err := loadDeliverySlot(tx, vendorID, slotID)
+	if err != nil {
+		return slot, err
+	}
+	if affected == 0 {
+		return slot, &requestError{409, "A janela de entrega de " + slot.Date + " " + slot.StartsAt + "-" + slot.EndsAt + " está esgotada ou indisponível"}
+	}
+	return slot, nil
+}
+
+// releaseDeliverySlot devolve a vaga ocupada pelo pedido cancelado
+func releaseDeliverySlot(tx *sql.Tx, orderID int64) error {
+	_, err := tx.Exec(`
+		UPDATE delivery_slots s
+		INNER JOIN orders o ON o.delivery_slots_id = s.id
+		SET s.booked = s.booked - 1
+		WHERE o.id = ? AND s.booked > 0`, orderID)
+	return err
+}
+
+// parseDeliverySlotParams lê os IDs do vendor e da janela da rota
+func parseDeliverySlotParams(c *fiber.Ctx) (int, int, error) {
+	vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+	if err != nil {
+		return 0, 0, &requestError{400, "ID do vendor inválido"}
+	}
+	slotID, err := strconv.Atoi(c.Params("slot_id"))
+	if err != nil {
+		return 0, 0, &requestError{400, "ID da janela de entrega inválido"}
+	}
+	return vendorID, slotID, nil
+}
+
+// @Summary Janelas de entrega do vendor
+// @Description Lista as janelas de entrega do período (padrão: os próximos 14 dias; até 62 dias) com a capacidade e as vagas disponíveis. O público vê apenas as janelas abertas que ainda não começaram; a equipe do vendor e administradores veem todas
+// @Tags Vendors
+// @Produce json
+// @Param X-User-ID header int false "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param from query string false "Data inicial (AAAA-MM-DD)"
+// @Param to query string false "Data final (AAAA-MM-DD)"
+// @Success 200 {array} DeliverySlot
+// @Failure 400 {object} map[string]string "Período inválido"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar janelas de entrega"
+// @Router /vendors/{vendor_id}/delivery-slots [get]
+func GetVendorDeliverySlots(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+
+		today := time.Now().Format(slotDateLayout)
+		from, err := time.Parse(slotDateLayout, c.Query("from", today))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Use o formato AAAA-MM-DD em from"})
+		}
+		to := from.AddDate(0, 0, defaultDeliverySlotDays)
+		if value := c.Query("to"); value != "" {
+			if to, err = time.Parse(slotDateLayout, value); err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "Use o formato AAAA-MM-DD em to"})
+			}
+		}
+		if to.Before(from) || to.Sub(from) > maxDeliverySlotDays*24*time.Hour {
+			return c.Status(400).JSON(fiber.Map{"error": "O período deve ter de 0 a " + strconv.Itoa(maxDeliverySlotDays) + " dias"})
+		}
+
+		if err := ensureOrganization(db, vendorOrganization, vendorID); err != nil {
+			return respondError(c, err, "Erro ao buscar vendor:", "Erro ao buscar janelas de entrega")
+		}
+		manager, err := canSeeVendorPrivate(db, c, vendorID)
+		if err != nil {
+			log.Println("Erro ao verificar permissões do usuário:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar permissões"})
+		}
+
+		query := "SELECT " + deliverySlotColumns + " FROM delivery_slots WHERE vendors_id = ? AND slot_date BETWEEN ? AND ?"
+		if !manager {
+			query += " AND status = 'open' AND TIMESTAMP(slot_date, starts_at) > NOW()"
+		}
+		rows, err := db.Query(query+" ORDER BY slot_date, starts_at", vendorID, from.Format(slotDateLayout), to.Format(slotDateLayout))
+		if err != nil {
+			log.Println("Erro ao buscar janelas de entrega:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar janelas de entrega"})
+		}
+		defer rows.Close()
+
+		slots := []DeliverySlot{}
+		for rows.Next() {
+			slot, err := scanDeliverySlot(rows)
+			if err != nil {
+				log.Println("Erro ao ler janela de entrega:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar janelas de entrega"})
+			}
+			slots = append(slots, slot)
+		}
+
+		return c.Status(200).JSON(slots)
+	}
+}
+
+// @Summary Publicar janelas de entrega
+// @Description Publica janelas de entrega com data, horário e capacidade em pedidos (até 200 por requisição). Janelas já publicadas com a mesma data e início são ignoradas e devolvidas em skipped. Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param slots body DeliverySlotsRequest true "Janelas de entrega"
+// @Success 201 {object} map[string]interface{} "Janelas criadas e ignoradas"
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao publicar janelas de entrega"
+// @Router /vendors/{vendor_id}/delivery-slots [post]
+func CreateVendorDeliverySlots(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+
+		var request DeliverySlotsRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Erro ao analisar o corpo da requisição"})
+		}
+		if len(request.Slots) == 0 || len(request.Slots) > maxDeliverySlotsPerRequest {
+			return c.Status(400).JSON(fiber.Map{"error": "Informe de 1 a " + strconv.Itoa(maxDeliverySlotsPerRequest) + " janelas em slots"})
+		}
+
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		now := time.Now()
+		for i := range request.Slots {
+			if err := request.Slots[i].normalize(now); err != nil {
+				return respondError(c, err, "Erro ao validar janela de entrega:", "Erro ao publicar janelas de entrega")
+			}
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao publicar janelas de entrega"})
+		}
+		defer tx.Rollback()
+
+		created := []DeliverySlot{}
+		skipped := []DeliverySlotInput{}
+		for _, input := range request.Slots {
+			// INSERT IGNORE deixa de fora as janelas já publicadas (mesma data e início)
+			result, err := tx.Exec(`
+				INSERT IGNORE INTO delivery_slots (vendors_id, slot_date, starts_at, ends_at, capacity)
+				VALUES (?, ?, ?, ?, ?)`, vendorID, input.Date, input.StartsAt, input.EndsAt, input.Capacity)
+			if err != nil {
+				log.Println("Erro ao publicar janela de entrega:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao publicar janelas de entrega"})
+			}
+			affected, err := result.RowsAffected()
+			if err != nil {
+				log.Println("Erro ao obter o número de linhas afetadas:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao publicar janelas de entrega"})
+			}
+			if affected == 0 {
+				skipped = append(skipped, input)
+				continue
+			}
+
+			id, err := result.LastInsertId()
+			if err != nil {
+				log.Println("Erro ao obter o ID da janela de entrega:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao publicar janelas de entrega"})
+			}
+			slot, err := loadDeliverySlot(tx, vendorID, int(id))
+			if err != nil {
+				log.Println("Erro ao buscar janela de entrega:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao publicar janelas de entrega"})
+			}
+			created = append(created, slot)
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao confirmar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao publicar janelas de entrega"})
+		}
+
+		return c.Status(201).JSON(fiber.Map{"created": created, "skipped": skipped})
+	}
+}
+
+// @Summary Atualizar janela de entrega
+// @Description Altera a capacidade (não menor que as vagas ocupadas) ou a situação da janela; janelas fechadas (closed) deixam de aceitar pedidos, mas mantêm os já agendados. Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param slot_id path int true "ID da janela de entrega"
+// @Param slot body DeliverySlotUpdate true "Capacidade e situação"
+// @Success 200 {object} DeliverySlot
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Janela de entrega não encontrada"
+// @Failure 409 {object} map[string]string "Capacidade menor que as vagas ocupadas"
+// @Failure 500 {object} map[string]string "Erro ao atualizar janela de entrega"
+// @Router /vendors/{vendor_id}/delivery-slots/{slot_id} [patch]
+func UpdateVendorDeliverySlot(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, slotID, err := parseDeliverySlotParams(c)
+		if err != nil {
+			return respondError(c, err, "Erro ao ler parâmetros:", "Erro ao atualizar janela de entrega")
+		}
+
+		var update DeliverySlotUpdate
+		if err := c.BodyParser(&update); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Erro ao analisar o corpo da requisição"})
+		}
+		if update.Capacity == nil && update.Status == nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Informe capacity ou status"})
+		}
+		if update.Capacity != nil && (*update.Capacity < 1 || *update.Capacity > maxDeliverySlotCapacity) {
+			return c.Status(400).JSON(fiber.Map{"error": "A capacidade da janela deve ir de 1 a " + strconv.Itoa(maxDeliverySlotCapacity) + " pedidos"})
+		}
+		if update.Status != nil && *update.Status != DeliverySlotOpen && *update.Status != DeliverySlotClosed {
+			return c.Status(400).JSON(fiber.Map{"error": "Status inválido. Use: open ou closed"})
+		}
+
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar janela de entrega"})
+		}
+		defer tx.Rollback()
+
+		// Bloqueia a janela para que nenhum pedido ocupe vagas durante a alteração
+		slot, err := scanDeliverySlot(tx.QueryRow("SELECT "+deliverySlotColumns+" FROM delivery_slots WHERE id = ? AND vendors_id = ? FOR UPDATE", slotID, vendorID))
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Janela de entrega não encontrada"})
+		} else if err != nil {
+			log.Println("Erro ao buscar janela de entrega:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar janela de entrega"})
+		}
+
+		if update.Capacity != nil {
+			if *update.Capacity < slot.Booked {
+				return c.Status(409).JSON(fiber.Map{"error": "A janela já tem " + strconv.Itoa(slot.Booked) + " pedidos agendados"})
+			}
+			slot.Capacity = *update.Capacity
+		}
+		if update.Status != nil {
+			slot.Status = *update.Status
+		}
+
+		if _, err := tx.Exec("UPDATE delivery_slots SET capacity = ?, status = ? WHERE id = ?", slot.Capacity, slot.Status, slotID); err != nil {
+			log.Println("Erro ao atualizar janela de entrega:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar janela de entrega"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao confirmar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar janela de entrega"})
+		}
+
+		slot.Available = slot.Capacity - slot.Booked
+		return c.Status(200).JSON(slot)
+	}
+}
+
+// @Summary Remover janela de entrega
+// @Description Remove uma janela sem pedidos agendados; janelas com pedidos podem ser fechadas. Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param slot_id path int true "ID da janela de entrega"
+// @Success 200 {object} map[string]string "Janela de entrega removida"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Janela de entrega não encontrada"
+// @Failure 409 {object} map[string]string "Janela com pedidos agendados"
+// @Failure 500 {object} map[string]string "Erro ao remover janela de entrega"
+// @Router /vendors/{vendor_id}/delivery-slots/{slot_id} [delete]
+func DeleteVendorDeliverySlot(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, slotID, err := parseDeliverySlotParams(c)
+		if err != nil {
+			return respondError(c, err, "Erro ao ler parâmetros:", "Erro ao remover janela de entrega")
+		}
+
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		result, err := db.Exec("DELETE FROM delivery_slots WHERE id = ? AND vendors_id = ? AND booked = 0", slotID, vendorID)
+		if err != nil {
+			log.Println("Erro ao remover janela de entrega:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover janela de entrega"})
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Println("Erro ao obter o número de linhas afetadas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover janela de entrega"})
+		}
+		if rowsAffected == 0 {
+			// Sem remoção: a janela não existe ou já tem pedidos
+			if _, err := loadDeliverySlot(db, vendorID, slotID); err != nil {
+				return respondError(c, err, "Erro ao buscar janela de entrega:", "Erro ao remover janela de entrega")
+			}
+			return c.Status(409).JSON(fiber.Map{"error": "A janela tem pedidos agendados; feche-a em vez de remover"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{"message": "Janela de entrega removida com sucesso"})
+	}
+}
+
+// @Summary Manifesto de entregas do dia
+// @Description Lista as janelas de entrega do dia com os pedidos agendados em cada uma (comprador, endereço e itens), para a separação e a rota de entrega. Pedidos cancelados ficam de fora. Restrito à equipe do vendor, inclusive operadores, e a administradores
+// @Tags Vendors
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param date query string false "Dia (AAAA-MM-DD); padrão hoje"
+// @Success 200 {object} map[string]interface{} "Dia e janelas com os pedidos"
+// @Failure 400 {object} map[string]string "Data inválida"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao gerar manifesto"
+// @Router /vendors/{vendor_id}/delivery-manifest [get]
+func GetVendorDeliveryManifest(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+		date, err := time.Parse(slotDateLayout, c.Query("date", time.Now().Format(slotDateLayout)))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Use o formato AAAA-MM-DD em date"})
+		}
+		day := date.Format(slotDateLayout)
+
+		if _, _, err := authorizeMember(db, c, vendorOrganization, vendorID, MemberOperator); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		rows, err := db.Query("SELECT "+deliverySlotColumns+" FROM delivery_slots WHERE vendors_id = ? AND slot_date = ? ORDER BY starts_at", vendorID, day)
+		if err != nil {
+			log.Println("Erro ao buscar janelas do manifesto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao gerar manifesto"})
+		}
+		slots := []ManifestSlot{}
+		slotIndex := make(map[int]int)
+		for rows.Next() {
+			slot, err := scanDeliverySlot(rows)
+			if err != nil {
+				rows.Close()
+				log.Println("Erro ao ler janela do manifesto:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao gerar manifesto"})
+			}
+			slotIndex[slot.ID] = len(slots)
+			slots = append(slots, ManifestSlot{DeliverySlot: slot, Orders: []ManifestOrder{}})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			log.Println("Erro ao ler janelas do manifesto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao gerar manifesto"})
+		}
+
+		rows, err = db.Query(`
+			SELECT o.delivery_slots_id, o.id, o.order_number, o.status, o.fulfillment, o.total,
+				u.name, COALESCE(b.phone, ''), o.shipping_address, o.shipping_city, o.shipping_state, o.shipping_cep
+			FROM orders o
+			INNER JOIN delivery_slots s ON s.id = o.delivery_slots_id
+			INNER JOIN users u ON u.id = o.users_id
+			LEFT JOIN buyers b ON b.id = o.buyers_id
+			WHERE s.vendors_id = ? AND s.slot_date = ? AND o.status <> 'cancelled'
+			ORDER BY s.starts_at, o.id`, vendorID, day)
+		if err != nil {
+			log.Println("Erro ao buscar pedidos do manifesto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao gerar manifesto"})
+		}
+		type orderRef struct{ slot, order int }
+		orderRefs := make(map[int]orderRef)
+		var orderIDs []int
+		for rows.Next() {
+			var slotID int
+			var order ManifestOrder
+			if err := rows.Scan(&slotID, &order.ID, &order.OrderNumber, &order.Status, &order.Fulfillment, &order.Total,
+				&order.BuyerName, &order.BuyerPhone, &order.ShippingAddress, &order.ShippingCity, &order.ShippingState, &order.ShippingCEP); err != nil {
+				rows.Close()
+				log.Println("Erro ao ler pedido do manifesto:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao gerar manifesto"})
+			}
+			order.Items = []ManifestOrderItem{}
+			i := slotIndex[slotID]
+			orderRefs[order.ID] = orderRef{i, len(slots[i].Orders)}
+			orderIDs = append(orderIDs, order.ID)
+			slots[i].Orders = append(slots[i].Orders, order)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			log.Println("Erro ao ler pedidos do manifesto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao gerar manifesto"})
+		}
+
+		if len(orderIDs) > 0 {
+			rows, err = db.Query(`
+				SELECT oi.orders_id, p.name, oi.variant_label, oi.quantity
+				FROM order_items oi
+				INNER JOIN products p ON p.id = oi.products_id
+				WHERE oi.orders_id IN (`+placeholders(len(orderIDs))+`)
+				ORDER BY oi.id`, intArgs(orderIDs)...)
+			if err != nil {
+				log.Println("Erro ao buscar itens do manifesto:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao gerar manifesto"})
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var orderID int
+				var item ManifestOrderItem
+				if err := rows.Scan(&orderID, &item.ProductName, &item.VariantLabel, &item.Quantity); err != nil {
+					log.Println("Erro ao ler item do manifesto:", err)
+					return c.Status(500).JSON(fiber.Map{"error": "Erro ao gerar manifesto"})
+				}
+				ref := orderRefs[orderID]
+				order := &slots[ref.slot].Orders[ref.order]
+				order.Items = append(order.Items, item)
+			}
+			if err := rows.Err(); err != nil {
+				log.Println("Erro ao ler itens do manifesto:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao gerar manifesto"})
+			}
+		}
+
+		return c.Status(200).JSON(fiber.Map{
+			"vendors_id": vendorID,
+			"date":       day,
+			"slots":      slots,
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package controllers
+
+// normalizeDocumentField valida o CPF ou CNPJ enviado em uma atualização
+// parcial e substitui no mapa o valor normalizado. present indica se o campo
+// foi enviado.
+func normalizeDocumentField(updateData map[string]interface{}, field string, normalize func(string) (string, error)) (value string, present bool, err error) {
+	raw, present := updateData[field]
+	if !present {
+		return "", false, nil
+	}
+
+	text, _ := raw.(string)
+	value, err = normalize(text)
+	if err != nil {
+		return "", true, &requestError{400, err.Error()}
+	}
+	updateData[field] = value
+	return value, true, nil
+}
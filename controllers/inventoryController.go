@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"api/pagination"
+	"database/sql"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Tipos de movimento de estoque
+const (
+	MovementReceipt            = "receipt"
+	MovementSale               = "sale"
+	MovementCancellationReturn = "cancellation_return"
+	MovementAdjustment         = "adjustment"
+	MovementLoss               = "loss"
+)
+
+// InventoryMovement é um lançamento do livro-razão de estoque
+type InventoryMovement struct {
+	ID           int     `json:"id"`
+	ProductID    int     `json:"products_id"`
+	Type         string  `json:"type"`
+	Delta        int     `json:"delta"`
+	BalanceAfter int     `json:"balance_after"`
+	UsersID      *int    `json:"users_id"`
+	OrdersID     *int    `json:"orders_id"`
+	Reason       *string `json:"reason"`
+	CreatedAt    string  `json:"created_at"`
+}
+
+// InventoryMovementRequest é o lançamento manual de estoque. Recebimentos e
+// perdas usam quantidades positivas; ajustes aceitam valores negativos.
+// Perdas e ajustes de produtos com lotes informam o lote em LotID.
+type InventoryMovementRequest struct {
+	Type     string `json:"type"`
+	Quantity int    `json:"quantity"`
+	Reason   string `json:"reason"`
+	LotID    int    `json:"lot_id"`
+}
+
+// nullableID converte IDs opcionais (zero) em NULL
+func nullableID(id int64) interface{} {
+	if id <= 0 {
+		return nil
+	}
+	return id
+}
+
+// logInventoryMovement grava um movimento cujo efeito no estoque já foi aplicado
+func logInventoryMovement(q sqlQueryer, productID interface{}, movementType string, delta, balanceAfter int, userID, orderID int64, reason string) error {
+	var reasonValue interface{}
+	if reason != "" {
+		reasonValue = reason
+	}
+	_, err := q.Exec(`
+		INSERT INTO inventory_movements (products_id, type, delta, balance_after, users_id, orders_id, reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		productID, movementType, delta, balanceAfter, nullableID(userID), nullableID(orderID), reasonValue)
+	return err
+}
+
+// adjustStock soma delta ao estoque do produto e registra o movimento,
+// devolvendo o novo saldo. Saldos negativos são recusados com *requestError,
+// assim como saídas que deixariam o estoque abaixo do saldo dos lotes: a
+// baixa de um lote deve ser aplicada ao lote antes de chamar adjustStock.
+func adjustStock(q sqlQueryer, productID interface{}, movementType string, delta int, userID, orderID int64, reason string) (int, error) {
+	var balance int
+	if err := q.QueryRow("SELECT quantity FROM products WHERE id = ? FOR UPDATE", productID).Scan(&balance); err != nil {
+		return 0, err
+	}
+
+	balance += delta
+	if balance < 0 {
+		return 0, &requestError{409, "Estoque insuficiente para o movimento"}
+	}
+	if delta < 0 {
+		lotted, err := lottedQuantity(q, productID)
+		if err != nil {
+			return 0, err
+		}
+		if balance < lotted {
+			return 0, &requestError{409, "Estoque sem lote insuficiente para o movimento; informe o lote"}
+		}
+	}
+
+	if _, err := q.Exec("UPDATE products SET quantity = ? WHERE id = ?", balance, productID); err != nil {
+		return 0, err
+	}
+	if err := logInventoryMovement(q, productID, movementType, delta, balance, userID, orderID, reason); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// setStockLevel leva o estoque ao valor informado registrando um ajuste
+func setStockLevel(q sqlQueryer, productID interface{}, quantity int, userID int64, reason string) error {
+	var current int
+	if err := q.QueryRow("SELECT quantity FROM products WHERE id = ? FOR UPDATE", productID).Scan(&current); err != nil {
+		return err
+	}
+	if current == quantity {
+		return nil
+	}
+	_, err := adjustStock(q, productID, MovementAdjustment, quantity-current, userID, 0, reason)
+	return err
+}
+
+// restockCancelledOrder devolve ao estoque os itens de um pedido cancelado
+func restockCancelledOrder(tx *sql.Tx, orderID int64, userID int64) error {
+	rows, err := tx.Query("SELECT id, products_id, quantity FROM order_items WHERE orders_id = ?", orderID)
+	if err != nil {
+		return err
+	}
+
+	type orderLine struct{ id, productID, quantity int }
+	var lines []orderLine
+	for rows.Next() {
+		var line orderLine
+		if err := rows.Scan(&line.id, &line.productID, &line.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		lines = append(lines, line)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		if err := returnOrderItemLots(tx, line.id); err != nil {
+			return err
+		}
+		if _, err := adjustStock(tx, line.productID, MovementCancellationReturn, line.quantity, userID, orderID, "Cancelamento do pedido"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// @Summary Lançar movimento de estoque
+// @Description Registra um recebimento, ajuste ou perda de estoque do produto. Vendas e devoluções por cancelamento são lançadas automaticamente pelos pedidos. Perdas e ajustes podem indicar o lote afetado em lot_id; recebimentos de lotes usam /products/id/{id}/lots
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do produto"
+// @Param X-User-ID header int true "ID do usuário"
+// @Param movement body InventoryMovementRequest true "Movimento (type: receipt, adjustment ou loss)"
+// @Success 201 {object} map[string]interface{} "Movimento registrado"
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Produto pertence a outro vendor"
+// @Failure 404 {object} map[string]string "Produto não encontrado"
+// @Failure 409 {object} map[string]string "Estoque insuficiente"
+// @Failure 500 {object} map[string]string "Erro ao registrar movimento"
+// @Router /products/id/{id}/inventory [post]
+func CreateInventoryMovement(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		productID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+
+		actor, err := authorizeProductManager(db, c, productID)
+		if err != nil {
+			return respondError(c, err, "Erro ao verificar permissões:", "Erro ao registrar movimento")
+		}
+
+		var request InventoryMovementRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+		}
+		request.Reason = strings.TrimSpace(request.Reason)
+
+		delta := request.Quantity
+		switch request.Type {
+		case MovementReceipt:
+			if request.Quantity <= 0 {
+				return c.Status(400).JSON(fiber.Map{"error": "A quantidade recebida deve ser positiva"})
+			}
+		case MovementLoss:
+			if request.Quantity <= 0 {
+				return c.Status(400).JSON(fiber.Map{"error": "A quantidade perdida deve ser positiva"})
+			}
+			delta = -request.Quantity
+		case MovementAdjustment:
+			if request.Quantity == 0 {
+				return c.Status(400).JSON(fiber.Map{"error": "O ajuste não pode ser zero"})
+			}
+		default:
+			return c.Status(400).JSON(fiber.Map{"error": "Tipo inválido. Use: receipt, adjustment ou loss"})
+		}
+
+		if (request.Type == MovementAdjustment || request.Type == MovementLoss) && request.Reason == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Informe o motivo do ajuste ou da perda"})
+		}
+		if request.Type == MovementReceipt && request.LotID != 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Recebimentos de lote devem ser cadastrados em /products/id/:id/lots"})
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao registrar movimento"})
+		}
+		defer tx.Rollback()
+
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM products WHERE id = ? AND deleted_at IS NULL", productID).Scan(&exists); err != nil {
+			log.Println("Erro ao verificar produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar produto"})
+		}
+		if exists == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Produto não encontrado"})
+		}
+
+		if request.LotID != 0 {
+			if delta < 0 {
+				err = consumeLot(tx, productID, request.LotID, -delta)
+			} else {
+				err = restoreLot(tx, productID, request.LotID, delta)
+			}
+			if err != nil {
+				return respondError(c, err, "Erro ao movimentar lote:", "Erro ao registrar movimento")
+			}
+		}
+
+		balance, err := adjustStock(tx, productID, request.Type, delta, int64(actor), 0, request.Reason)
+		if err != nil {
+			return respondError(c, err, "Erro ao registrar movimento de estoque:", "Erro ao registrar movimento")
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao registrar movimento"})
+		}
+
+		return c.Status(201).JSON(fiber.Map{
+			"message":     "Movimento registrado com sucesso",
+			"products_id": productID,
+			"type":        request.Type,
+			"delta":       delta,
+			"quantity":    balance,
+		})
+	}
+}
+
+// @Summary Histórico de estoque do produto
+// @Description Lista os movimentos de estoque do produto (mais recentes primeiro) e confere o estoque atual com a soma do livro-razão
+// @Tags Inventory
+// @Param id path int true "ID do produto"
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Saldo, conciliação e movimentos paginados"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
+// @Failure 404 {object} map[string]string "Produto não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar movimentos"
+// @Router /products/id/{id}/inventory [get]
+func GetInventoryMovements(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		productID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		var quantity, ledgerBalance, movementCount int
+		err = db.QueryRow(`
+			SELECT p.quantity, COALESCE(SUM(m.delta), 0), COUNT(m.id)
+			FROM products p
+			LEFT JOIN inventory_movements m ON m.products_id = p.id
+			WHERE p.id = ?
+			GROUP BY p.id, p.quantity`, productID).Scan(&quantity, &ledgerBalance, &movementCount)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Produto não encontrado"})
+		} else if err != nil {
+			log.Println("Erro ao conciliar estoque:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar movimentos"})
+		}
+
+		keyset, keysetArgs := params.Keyset("id")
+		args := append(append([]interface{}{productID}, keysetArgs...), params.FetchLimit())
+		rows, err := db.Query(`
+			SELECT id, products_id, type, delta, balance_after, users_id, orders_id, reason, created_at
+			FROM inventory_movements
+			WHERE products_id = ? AND `+keyset+`
+			ORDER BY id DESC
+			LIMIT ?`, args...)
+		if err != nil {
+			log.Println("Erro ao buscar movimentos de estoque:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar movimentos"})
+		}
+		defer rows.Close()
+
+		var movements []InventoryMovement
+		for rows.Next() {
+			var movement InventoryMovement
+			if err := rows.Scan(&movement.ID, &movement.ProductID, &movement.Type, &movement.Delta, &movement.BalanceAfter,
+				&movement.UsersID, &movement.OrdersID, &movement.Reason, &movement.CreatedAt); err != nil {
+				log.Println("Erro ao ler movimento:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler movimento"})
+			}
+			movements = append(movements, movement)
+		}
+
+		if err := rows.Err(); err != nil {
+			log.Println("Erro com as linhas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar movimentos"})
+		}
+
+		page := pagination.NewPage(movements, params, func(m InventoryMovement) int { return m.ID })
+
+		response := fiber.Map{
+			"products_id":    productID,
+			"quantity":       quantity,
+			"ledger_balance": ledgerBalance,
+			"reconciled":     quantity == ledgerBalance,
+			"discrepancy":    quantity - ledgerBalance,
+			"data":           page.Data,
+			"next_cursor":    page.NextCursor,
+		}
+		if params.IncludeTotal {
+			response["total"] = movementCount
+		}
+
+		return c.Status(200).JSON(response)
+	}
+}
@@ -0,0 +1,467 @@
+package controllers
+
+import (
+	"api/pagination"
+	"database/sql"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Expressão SQL do estoque vendável (alias p): o estoque físico menos o saldo
+// dos lotes vencidos, que deixam de ser vendidos assim que a validade passa,
+// mesmo antes da baixa feita por ExpireProductLots, e menos as reservas
+// ativas dos carrinhos
+const sellableQuantityExpr = `(p.quantity - COALESCE((
+	SELECT SUM(l.quantity_remaining) FROM product_lots l
+	WHERE l.products_id = p.id AND l.expires_at < CURDATE()), 0) - COALESCE((
+	SELECT SUM(r.quantity) FROM stock_reservations r
+	WHERE r.products_id = p.id AND ` + activeReservationCondition + `), 0))`
+
+// Ordem FEFO: primeiro o lote que vence antes; lotes sem validade por último
+const lotFEFOOrder = "expires_at IS NULL, expires_at, id"
+
+// ProductLot é um lote de estoque do produto
+type ProductLot struct {
+	ID                int     `json:"id"`
+	ProductID         int     `json:"products_id"`
+	LotCode           string  `json:"lot_code"`
+	ProducedAt        *string `json:"produced_at"`
+	ExpiresAt         *string `json:"expires_at"`
+	QuantityReceived  int     `json:"quantity_received"`
+	QuantityRemaining int     `json:"quantity_remaining"`
+	Expired           bool    `json:"expired"`
+	CreatedAt         string  `json:"created_at"`
+}
+
+// ProductLotRequest é o recebimento de um novo lote. As datas usam o formato
+// AAAA-MM-DD; produced_at é a data de produção ou colheita.
+type ProductLotRequest struct {
+	LotCode    string `json:"lot_code"`
+	ProducedAt string `json:"produced_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Quantity   int    `json:"quantity"`
+}
+
+// parseLotDate valida uma data opcional de lote
+func parseLotDate(value string) (interface{}, *time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil, nil
+	}
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return value, &parsed, nil
+}
+
+// lottedQuantity devolve o saldo físico somado de todos os lotes do produto,
+// vencidos ou não
+func lottedQuantity(q sqlQueryer, productID interface{}) (int, error) {
+	var total int
+	err := q.QueryRow("SELECT COALESCE(SUM(quantity_remaining), 0) FROM product_lots WHERE products_id = ?", productID).Scan(&total)
+	return total, err
+}
+
+// consumeLot retira quantity do saldo de um lote, travando a linha. O estoque
+// do produto deve ser ajustado em seguida com adjustStock.
+func consumeLot(q sqlQueryer, productID interface{}, lotID int, quantity int) error {
+	var remaining int
+	err := q.QueryRow("SELECT quantity_remaining FROM product_lots WHERE id = ? AND products_id = ? FOR UPDATE", lotID, productID).
+		Scan(&remaining)
+	if err == sql.ErrNoRows {
+		return &requestError{404, "Lote não encontrado"}
+	}
+	if err != nil {
+		return err
+	}
+	if remaining < quantity {
+		return &requestError{409, "Saldo insuficiente no lote"}
+	}
+	_, err = q.Exec("UPDATE product_lots SET quantity_remaining = quantity_remaining - ? WHERE id = ?", quantity, lotID)
+	return err
+}
+
+// restoreLot devolve quantity ao saldo de um lote (ajustes positivos)
+func restoreLot(q sqlQueryer, productID interface{}, lotID int, quantity int) error {
+	result, err := q.Exec(`
+		UPDATE product_lots SET quantity_remaining = quantity_remaining + ?
+		WHERE id = ? AND products_id = ? AND quantity_remaining + ? <= quantity_received`,
+		quantity, lotID, productID, quantity)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &requestError{409, "Lote não encontrado ou ajuste acima da quantidade recebida"}
+	}
+	return nil
+}
+
+// allocateLotsFEFO reserva a quantidade vendida de um item de pedido nos lotes
+// válidos do produto, do que vence primeiro ao que vence por último, e
+// registra em order_item_lots de quais lotes ela saiu. O que exceder o saldo
+// dos lotes sai do estoque sem lote do produto.
+func allocateLotsFEFO(tx *sql.Tx, productID interface{}, orderItemID int64, quantity int) error {
+	rows, err := tx.Query(`
+		SELECT id, quantity_remaining FROM product_lots
+		WHERE products_id = ? AND quantity_remaining > 0
+			AND (expires_at IS NULL OR expires_at >= CURDATE())
+		ORDER BY `+lotFEFOOrder+`
+		FOR UPDATE`, productID)
+	if err != nil {
+		return err
+	}
+
+	type lotBalance struct{ id, remaining int }
+	var lots []lotBalance
+	for rows.Next() {
+		var lot lotBalance
+		if err := rows.Scan(&lot.id, &lot.remaining); err != nil {
+			rows.Close()
+			return err
+		}
+		lots = append(lots, lot)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	pending := quantity
+	for _, lot := range lots {
+		if pending == 0 {
+			break
+		}
+		taken := lot.remaining
+		if taken > pending {
+			taken = pending
+		}
+		if _, err := tx.Exec("UPDATE product_lots SET quantity_remaining = quantity_remaining - ? WHERE id = ?", taken, lot.id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("INSERT INTO order_item_lots (order_items_id, product_lots_id, quantity) VALUES (?, ?, ?)",
+			orderItemID, lot.id, taken); err != nil {
+			return err
+		}
+		pending -= taken
+	}
+
+	// O restante precisa caber no estoque sem lote; adjustStock recusa a venda
+	// caso contrário
+	return nil
+}
+
+// returnOrderItemLots devolve aos lotes de origem as quantidades de um item
+// de pedido cancelado
+func returnOrderItemLots(tx *sql.Tx, orderItemID int) error {
+	_, err := tx.Exec(`
+		UPDATE product_lots l
+		INNER JOIN order_item_lots oil ON oil.product_lots_id = l.id
+		SET l.quantity_remaining = l.quantity_remaining + oil.quantity
+		WHERE oil.order_items_id = ?`, orderItemID)
+	return err
+}
+
+// ExpireProductLots baixa como perda o saldo dos lotes vencidos, mantendo o
+// livro-razão de estoque conciliado. Devolve a quantidade de lotes baixados.
+func ExpireProductLots(db *sql.DB) (int, error) {
+	rows, err := db.Query(`
+		SELECT id FROM product_lots
+		WHERE expires_at < CURDATE() AND quantity_remaining > 0`)
+	if err != nil {
+		return 0, err
+	}
+	var lotIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		lotIDs = append(lotIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, lotID := range lotIDs {
+		done, err := expireProductLot(db, lotID)
+		if err != nil {
+			return expired, err
+		}
+		if done {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func expireProductLot(db *sql.DB, lotID int) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var productID, remaining int
+	var lotCode string
+	err = tx.QueryRow(`
+		SELECT products_id, lot_code, quantity_remaining FROM product_lots
+		WHERE id = ? AND expires_at < CURDATE() FOR UPDATE`, lotID).Scan(&productID, &lotCode, &remaining)
+	if err == sql.ErrNoRows || (err == nil && remaining == 0) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec("UPDATE product_lots SET quantity_remaining = 0 WHERE id = ?", lotID); err != nil {
+		return false, err
+	}
+	if _, err := adjustStock(tx, productID, MovementLoss, -remaining, 0, 0, "Lote "+lotCode+" vencido"); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// StartLotExpiryJob executa ExpireProductLots periodicamente em segundo plano
+func StartLotExpiryJob(db *sql.DB, interval time.Duration) {
+	go func() {
+		for {
+			if expired, err := ExpireProductLots(db); err != nil {
+				log.Println("Erro ao baixar lotes vencidos:", err)
+			} else if expired > 0 {
+				log.Printf("%d lote(s) vencido(s) baixado(s) do estoque", expired)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// @Summary Receber lote de estoque
+// @Description Cadastra um lote do produto com datas de produção/colheita e validade, lançando a quantidade como recebimento no estoque
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do produto"
+// @Param X-User-ID header int true "ID do usuário"
+// @Param lot body ProductLotRequest true "Dados do lote"
+// @Success 201 {object} ProductLot "Lote cadastrado"
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Produto pertence a outro vendor"
+// @Failure 404 {object} map[string]string "Produto não encontrado"
+// @Failure 409 {object} map[string]string "Código de lote já cadastrado"
+// @Failure 500 {object} map[string]string "Erro ao cadastrar lote"
+// @Router /products/id/{id}/lots [post]
+func CreateProductLot(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		productID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+
+		actor, err := authorizeProductManager(db, c, productID)
+		if err != nil {
+			return respondError(c, err, "Erro ao verificar permissões:", "Erro ao cadastrar lote")
+		}
+
+		var request ProductLotRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+		}
+		request.LotCode = strings.TrimSpace(request.LotCode)
+		if request.LotCode == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Informe o código do lote"})
+		}
+		if request.Quantity <= 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "A quantidade do lote deve ser positiva"})
+		}
+
+		producedAt, producedDate, err := parseLotDate(request.ProducedAt)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Data de produção inválida. Use AAAA-MM-DD"})
+		}
+		expiresAt, expiresDate, err := parseLotDate(request.ExpiresAt)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Data de validade inválida. Use AAAA-MM-DD"})
+		}
+		if producedDate != nil && expiresDate != nil && expiresDate.Before(*producedDate) {
+			return c.Status(400).JSON(fiber.Map{"error": "A validade não pode ser anterior à produção"})
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar lote"})
+		}
+		defer tx.Rollback()
+
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM products WHERE id = ? AND deleted_at IS NULL", productID).Scan(&exists); err != nil {
+			log.Println("Erro ao verificar produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar produto"})
+		}
+		if exists == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Produto não encontrado"})
+		}
+
+		var duplicated int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM product_lots WHERE products_id = ? AND lot_code = ?", productID, request.LotCode).
+			Scan(&duplicated); err != nil {
+			log.Println("Erro ao verificar lote:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar lote"})
+		}
+		if duplicated > 0 {
+			return c.Status(409).JSON(fiber.Map{"error": "Já existe um lote com este código para o produto"})
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO product_lots (products_id, lot_code, produced_at, expires_at, quantity_received, quantity_remaining)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			productID, request.LotCode, producedAt, expiresAt, request.Quantity, request.Quantity)
+		if err != nil {
+			log.Println("Erro ao cadastrar lote:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar lote"})
+		}
+		lotID, err := result.LastInsertId()
+		if err != nil {
+			log.Println("Erro ao obter ID do lote:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar lote"})
+		}
+
+		if _, err := adjustStock(tx, productID, MovementReceipt, request.Quantity, int64(actor), 0, "Lote "+request.LotCode); err != nil {
+			return respondError(c, err, "Erro ao lançar recebimento do lote:", "Erro ao cadastrar lote")
+		}
+
+		var lot ProductLot
+		err = tx.QueryRow(`
+			SELECT id, products_id, lot_code, produced_at, expires_at, quantity_received, quantity_remaining,
+				COALESCE(expires_at < CURDATE(), FALSE), created_at
+			FROM product_lots WHERE id = ?`, lotID).
+			Scan(&lot.ID, &lot.ProductID, &lot.LotCode, &lot.ProducedAt, &lot.ExpiresAt, &lot.QuantityReceived,
+				&lot.QuantityRemaining, &lot.Expired, &lot.CreatedAt)
+		if err != nil {
+			log.Println("Erro ao buscar lote:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar lote"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar lote"})
+		}
+
+		return c.Status(201).JSON(lot)
+	}
+}
+
+// @Summary Lotes do produto
+// @Description Lista os lotes do produto em ordem FEFO (primeiro o que vence antes) com o estoque total, vendável e sem lote
+// @Tags Inventory
+// @Param id path int true "ID do produto"
+// @Param include_empty query bool false "Inclui lotes sem saldo"
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Success 200 {object} map[string]interface{} "Saldos e lotes paginados"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
+// @Failure 404 {object} map[string]string "Produto não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar lotes"
+// @Router /products/id/{id}/lots [get]
+func GetProductLots(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		productID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		var quantity, sellable, lotted int
+		err = db.QueryRow(`
+			SELECT p.quantity, `+sellableQuantityExpr+`,
+				COALESCE((SELECT SUM(quantity_remaining) FROM product_lots WHERE products_id = p.id), 0)
+			FROM products p
+			WHERE p.id = ?`, productID).Scan(&quantity, &sellable, &lotted)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Produto não encontrado"})
+		} else if err != nil {
+			log.Println("Erro ao buscar estoque do produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar lotes"})
+		}
+
+		// Os lotes são paginados pelo ID; a ordem FEFO vale dentro da página
+		conditions := []string{"products_id = ?"}
+		args := []interface{}{productID}
+		if !c.QueryBool("include_empty", false) {
+			conditions = append(conditions, "quantity_remaining > 0")
+		}
+		keyset, keysetArgs := params.Keyset("id")
+		conditions = append(conditions, keyset)
+		args = append(args, keysetArgs...)
+
+		rows, err := db.Query(`
+			SELECT id, products_id, lot_code, produced_at, expires_at, quantity_received, quantity_remaining,
+				COALESCE(expires_at < CURDATE(), FALSE), created_at
+			FROM product_lots
+			WHERE `+strings.Join(conditions, " AND ")+`
+			ORDER BY id DESC
+			LIMIT ?`, append(args, params.FetchLimit())...)
+		if err != nil {
+			log.Println("Erro ao buscar lotes:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar lotes"})
+		}
+		defer rows.Close()
+
+		var lots []ProductLot
+		for rows.Next() {
+			var lot ProductLot
+			if err := rows.Scan(&lot.ID, &lot.ProductID, &lot.LotCode, &lot.ProducedAt, &lot.ExpiresAt, &lot.QuantityReceived,
+				&lot.QuantityRemaining, &lot.Expired, &lot.CreatedAt); err != nil {
+				log.Println("Erro ao ler lote:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler lote"})
+			}
+			lots = append(lots, lot)
+		}
+
+		if err := rows.Err(); err != nil {
+			log.Println("Erro com as linhas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar lotes"})
+		}
+
+		page := pagination.NewPage(lots, params, func(l ProductLot) int { return l.ID })
+		sortLotsFEFO(page.Data)
+
+		return c.Status(200).JSON(fiber.Map{
+			"products_id":       productID,
+			"quantity":          quantity,
+			"sellable_quantity": sellable,
+			"unlotted_quantity": quantity - lotted,
+			"data":              page.Data,
+			"next_cursor":       page.NextCursor,
+		})
+	}
+}
+
+// sortLotsFEFO ordena os lotes pela validade, deixando os sem validade por último
+func sortLotsFEFO(lots []ProductLot) {
+	sort.SliceStable(lots, func(i, j int) bool {
+		a, b := lots[i].ExpiresAt, lots[j].ExpiresAt
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		if *a != *b {
+			return *a < *b
+		}
+		return lots[i].ID < lots[j].ID
+	})
+}
@@ -0,0 +1,1030 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Papéis dos membros de vendors e compradores, do maior para o menor acesso:
+// owner administra a equipe, manager cuida do cadastro e do catálogo e
+// operator opera os pedidos do dia a dia
+const (
+	MemberOwner    = "owner"
+	MemberManager  = "manager"
+	MemberOperator = "operator"
+)
+
+// Situações do convite
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+)
+
+const (
+	// Validade do convite enviado por e-mail
+	invitationTTL = 7 * 24 * time.Hour
+	// Quantidade máxima de convites pendentes por organização
+	maxPendingInvitations = 50
+)
+
+// Nível de acesso de cada papel
+var memberRoleRanks = map[string]int{MemberOperator: 1, MemberManager: 2, MemberOwner: 3}
+
+// organization descreve as tabelas de membros e convites de um tipo de
+// organização (vendor ou comprador)
+type organization struct {
+	kind             string
+	table            string
+	column           string
+	param            string
+	membersTable     string
+	invitationsTable string
+	notFound         string
+	forbidden        string
+}
+
+var vendorOrganization = organization{
+	kind:             "vendor",
+	table:            "vendors",
+	column:           "vendors_id",
+	param:            "vendor_id",
+	membersTable:     "vendor_members",
+	invitationsTable: "vendor_invitations",
+	notFound:         "Vendor não encontrado",
+	forbidden:        "Apenas a equipe do vendor ou administradores podem alterar estes dados",
+}
+
+var buyerOrganization = organization{
+	kind:             "buyer",
+	table:            "buyers",
+	column:           "buyers_id",
+	param:            "id",
+	membersTable:     "buyer_members",
+	invitationsTable: "buyer_invitations",
+	notFound:         "Comprador não encontrado",
+	forbidden:        "Apenas a equipe do comprador ou administradores podem acessar estes dados",
+}
+
+// OrganizationMember é um usuário da equipe do vendor ou do comprador
+type OrganizationMember struct {
+	UsersID   int    `json:"users_id"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+}
+
+// MemberRoleRequest altera o papel de um membro
+type MemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// InvitationRequest convida um e-mail para a equipe com o papel informado
+type InvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// OrganizationInvitation é um convite para a equipe. O token só é devolvido
+// na criação, para ser enviado ao convidado por e-mail.
+type OrganizationInvitation struct {
+	ID         int     `json:"id"`
+	Email      string  `json:"email"`
+	Role       string  `json:"role"`
+	Status     string  `json:"status"`
+	InvitedBy  *int    `json:"invited_by"`
+	AcceptedBy *int    `json:"accepted_by"`
+	ExpiresAt  string  `json:"expires_at"`
+	AcceptedAt *string `json:"accepted_at"`
+	CreatedAt  string  `json:"created_at"`
+	Token      string  `json:"token,omitempty"`
+}
+
+// AcceptInvitationRequest aceita o convite com o token recebido por e-mail
+type AcceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+// UserMembership é uma organização de que o usuário participa
+type UserMembership struct {
+	Type      string `json:"type"`
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+}
+
+// normalizeEmail valida o e-mail e o devolve em minúsculas, forma em que é
+// gravado nos convites
+func normalizeEmail(value string) (string, bool) {
+	parsed, err := mail.ParseAddress(strings.TrimSpace(value))
+	if err != nil {
+		return "", false
+	}
+	return strings.ToLower(parsed.Address), true
+}
+
+// validMemberRole indica se o papel informado é um papel de membro
+func validMemberRole(role string) bool {
+	_, ok := memberRoleRanks[role]
+	return ok
+}
+
+// memberRole devolve o papel do usuário na organização, ou vazio quando ele
+// não é membro
+func memberRole(q sqlQueryer, org organization, orgID, userID int) (string, error) {
+	var role string
+	err := q.QueryRow("SELECT role FROM "+org.membersTable+" WHERE "+org.column+" = ? AND users_id = ?", orgID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// ensureOrganization confere se a organização existe
+func ensureOrganization(q sqlQueryer, org organization, orgID int) error {
+	var exists int
+	if err := q.QueryRow("SELECT COUNT(*) FROM "+org.table+" WHERE id = ?", orgID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return &requestError{404, org.notFound}
+	}
+	return nil
+}
+
+// authorizeMember garante que quem faz a requisição é membro da organização
+// com ao menos o papel informado, ou administrador (que age como owner), e
+// devolve o usuário e o papel dele
+func authorizeMember(q sqlQueryer, c *fiber.Ctx, org organization, orgID int, minRole string) (int, string, error) {
+	userID, ok := callerUserID(c)
+	if !ok {
+		return 0, "", &requestError{401, "Usuário não identificado"}
+	}
+	if err := ensureOrganization(q, org, orgID); err != nil {
+		return 0, "", err
+	}
+
+	role, err := memberRole(q, org, orgID, userID)
+	if err != nil {
+		return 0, "", err
+	}
+	if memberRoleRanks[role] >= memberRoleRanks[minRole] {
+		return userID, role, nil
+	}
+
+	admin, err := isAdminUser(q, userID)
+	if err != nil {
+		return 0, "", err
+	}
+	if !admin {
+		return 0, "", &requestError{403, org.forbidden}
+	}
+	return userID, MemberOwner, nil
+}
+
+// addOrganizationOwner registra o usuário que criou a organização como owner
+func addOrganizationOwner(q sqlQueryer, org organization, orgID, userID int) error {
+	_, err := q.Exec("INSERT IGNORE INTO "+org.membersTable+" ("+org.column+", users_id, role) VALUES (?, ?, ?)",
+		orgID, userID, MemberOwner)
+	return err
+}
+
+// ensureAnotherOwner impede que a organização fique sem owner
+func ensureAnotherOwner(tx *sql.Tx, org organization, orgID, userID int) error {
+	var owners int
+	err := tx.QueryRow("SELECT COUNT(*) FROM "+org.membersTable+" WHERE "+org.column+" = ? AND role = ? AND users_id <> ? FOR UPDATE",
+		orgID, MemberOwner, userID).Scan(&owners)
+	if err != nil {
+		return err
+	}
+	if owners == 0 {
+		return &requestError{409, "A organização precisa de ao menos um owner"}
+	}
+	return nil
+}
+
+// canManageProduct indica se o usuário pode alterar um produto: membros com
+// papel manager ou owner do vendor dono do produto, ou quem cadastrou um
+// produto sem vendor
+func canManageProduct(q sqlQueryer, vendorID *int, createdBy, userID int) (bool, error) {
+	if vendorID == nil {
+		return createdBy == userID, nil
+	}
+	role, err := memberRole(q, vendorOrganization, *vendorID, userID)
+	if err != nil {
+		return false, err
+	}
+	return memberRoleRanks[role] >= memberRoleRanks[MemberManager], nil
+}
+
+// authorizeProductManager garante que quem faz a requisição pode alterar o
+// produto (canManageProduct) ou é administrador, e devolve o usuário
+func authorizeProductManager(q sqlQueryer, c *fiber.Ctx, productID interface{}) (int, error) {
+	userID, ok := callerUserID(c)
+	if !ok {
+		return 0, &requestError{401, "Usuário não identificado"}
+	}
+
+	var createdBy int
+	var vendorID *int
+	err := q.QueryRow("SELECT users_id, vendors_id FROM products WHERE id = ?", productID).Scan(&createdBy, &vendorID)
+	if err == sql.ErrNoRows {
+		return 0, &requestError{404, "Produto não encontrado"}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	allowed, err := canManageProduct(q, vendorID, createdBy, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !allowed {
+		admin, err := isAdminUser(q, userID)
+		if err != nil {
+			return 0, err
+		}
+		if !admin {
+			return 0, &requestError{403, "Produto pertence a outro vendor"}
+		}
+	}
+	return userID, nil
+}
+
+// authorizeBuyer confere se o usuário do pedido é membro do comprador
+// informado em buyers_id. Compras em nome do comprador só são feitas pelo
+// próprio usuário do pedido (X-User-ID) ou por administradores.
+func (r *CheckoutRequest) authorizeBuyer(q sqlQueryer, c *fiber.Ctx, userID string) error {
+	if r.BuyersID == nil {
+		return nil
+	}
+	id, err := strconv.Atoi(userID)
+	if err != nil {
+		return &requestError{400, "ID do usuário inválido"}
+	}
+	caller, ok := callerUserID(c)
+	if !ok {
+		return &requestError{401, "Usuário não identificado"}
+	}
+	if caller != id {
+		admin, err := isAdminUser(q, caller)
+		if err != nil {
+			return err
+		}
+		if !admin {
+			return &requestError{403, "Só o próprio usuário pode comprar em nome do comprador"}
+		}
+	}
+	role, err := memberRole(q, buyerOrganization, *r.BuyersID, id)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return &requestError{403, "O usuário não faz parte da equipe do comprador informado"}
+	}
+	return nil
+}
+
+// ensureCartVendors impede o checkout de carrinhos com produtos sem vendor,
+// que não teriam pedido de vendor onde entrar
+func ensureCartVendors(q sqlQueryer, cartID int) error {
+	var missing bool
+	err := q.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM cart_items ci
+			INNER JOIN products p ON p.id = ci.products_id
+			LEFT JOIN vendors v ON v.id = p.vendors_id
+			WHERE ci.cart_id = ? AND v.id IS NULL
+		)`, cartID).Scan(&missing)
+	if err != nil {
+		return err
+	}
+	if missing {
+		return &requestError{409, "O carrinho tem produtos sem vendor; remova-os para finalizar o pedido"}
+	}
+	return nil
+}
+
+// newInvitationToken gera o token do convite e o hash gravado no banco
+func newInvitationToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(raw)
+	return token, invitationTokenHash(token), nil
+}
+
+// invitationTokenHash devolve o hash SHA-256 do token do convite
+func invitationTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseMemberParams lê os IDs da organização e do usuário da rota
+func parseMemberParams(c *fiber.Ctx, org organization) (int, int, error) {
+	orgID, err := strconv.Atoi(c.Params(org.param))
+	if err != nil {
+		return 0, 0, &requestError{400, "ID da organização inválido"}
+	}
+	userID, err := strconv.Atoi(c.Params("user_id"))
+	if err != nil {
+		return 0, 0, &requestError{400, "ID do usuário inválido"}
+	}
+	return orgID, userID, nil
+}
+
+const invitationColumns = `id, email, role, status, invited_by, accepted_by, expires_at, accepted_at, created_at`
+
+// scanInvitation lê um convite selecionado com invitationColumns
+func scanInvitation(row interface{ Scan(...interface{}) error }) (OrganizationInvitation, error) {
+	var invitation OrganizationInvitation
+	err := row.Scan(&invitation.ID, &invitation.Email, &invitation.Role, &invitation.Status, &invitation.InvitedBy,
+		&invitation.AcceptedBy, &invitation.ExpiresAt, &invitation.AcceptedAt, &invitation.CreatedAt)
+	return invitation, err
+}
+
+// listMembers lista a equipe da organização, do maior para o menor papel
+func listMembers(db *sql.DB, org organization) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		orgID, err := strconv.Atoi(c.Params(org.param))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID da organização inválido"})
+		}
+		if _, _, err := authorizeMember(db, c, org, orgID, MemberOperator); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		rows, err := db.Query(`
+			SELECT m.users_id, u.username, u.name, m.role, m.created_at
+			FROM `+org.membersTable+` m
+			INNER JOIN users u ON u.id = m.users_id
+			WHERE m.`+org.column+` = ?
+			ORDER BY FIELD(m.role, 'owner', 'manager', 'operator'), u.name`, orgID)
+		if err != nil {
+			log.Println("Erro ao buscar membros:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar membros"})
+		}
+		defer rows.Close()
+
+		members := []OrganizationMember{}
+		for rows.Next() {
+			var member OrganizationMember
+			if err := rows.Scan(&member.UsersID, &member.Username, &member.Name, &member.Role, &member.CreatedAt); err != nil {
+				log.Println("Erro ao ler membro:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar membros"})
+			}
+			members = append(members, member)
+		}
+
+		return c.Status(200).JSON(members)
+	}
+}
+
+// updateMemberRole altera o papel de um membro. Só é possível alterar membros
+// e conceder papéis até o nível de quem faz a requisição.
+func updateMemberRole(db *sql.DB, org organization) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		orgID, userID, err := parseMemberParams(c, org)
+		if err != nil {
+			return respondError(c, err, "Erro ao ler parâmetros:", "Erro ao atualizar membro")
+		}
+
+		var request MemberRoleRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Erro ao analisar o corpo da requisição"})
+		}
+		if !validMemberRole(request.Role) {
+			return c.Status(400).JSON(fiber.Map{"error": "Papel inválido. Use: owner, manager ou operator"})
+		}
+
+		_, callerRole, err := authorizeMember(db, c, org, orgID, MemberManager)
+		if err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar membro"})
+		}
+		defer tx.Rollback()
+
+		var current string
+		err = tx.QueryRow("SELECT role FROM "+org.membersTable+" WHERE "+org.column+" = ? AND users_id = ? FOR UPDATE", orgID, userID).Scan(&current)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Membro não encontrado"})
+		} else if err != nil {
+			log.Println("Erro ao buscar membro:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar membro"})
+		}
+
+		rank := memberRoleRanks[callerRole]
+		if memberRoleRanks[current] > rank || memberRoleRanks[request.Role] > rank {
+			return c.Status(403).JSON(fiber.Map{"error": "Só é possível alterar membros e conceder papéis até o seu próprio papel"})
+		}
+		if current == MemberOwner && request.Role != MemberOwner {
+			if err := ensureAnotherOwner(tx, org, orgID, userID); err != nil {
+				return respondError(c, err, "Erro ao verificar owners:", "Erro ao atualizar membro")
+			}
+		}
+
+		if _, err := tx.Exec("UPDATE "+org.membersTable+" SET role = ? WHERE "+org.column+" = ? AND users_id = ?", request.Role, orgID, userID); err != nil {
+			log.Println("Erro ao atualizar membro:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar membro"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao confirmar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar membro"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{"message": "Papel atualizado com sucesso", "users_id": userID, "role": request.Role})
+	}
+}
+
+// removeMember tira um usuário da equipe. Qualquer membro pode sair; para
+// remover outro membro é preciso ter papel igual ou maior que o dele.
+func removeMember(db *sql.DB, org organization) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		orgID, userID, err := parseMemberParams(c, org)
+		if err != nil {
+			return respondError(c, err, "Erro ao ler parâmetros:", "Erro ao remover membro")
+		}
+
+		minRole := MemberManager
+		if caller, ok := callerUserID(c); ok && caller == userID {
+			minRole = MemberOperator
+		}
+		callerID, callerRole, err := authorizeMember(db, c, org, orgID, minRole)
+		if err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover membro"})
+		}
+		defer tx.Rollback()
+
+		var current string
+		err = tx.QueryRow("SELECT role FROM "+org.membersTable+" WHERE "+org.column+" = ? AND users_id = ? FOR UPDATE", orgID, userID).Scan(&current)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Membro não encontrado"})
+		} else if err != nil {
+			log.Println("Erro ao buscar membro:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover membro"})
+		}
+
+		if callerID != userID && memberRoleRanks[current] > memberRoleRanks[callerRole] {
+			return c.Status(403).JSON(fiber.Map{"error": "Só é possível remover membros até o seu próprio papel"})
+		}
+		if current == MemberOwner {
+			if err := ensureAnotherOwner(tx, org, orgID, userID); err != nil {
+				return respondError(c, err, "Erro ao verificar owners:", "Erro ao remover membro")
+			}
+		}
+
+		if _, err := tx.Exec("DELETE FROM "+org.membersTable+" WHERE "+org.column+" = ? AND users_id = ?", orgID, userID); err != nil {
+			log.Println("Erro ao remover membro:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover membro"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao confirmar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover membro"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{"message": "Membro removido com sucesso"})
+	}
+}
+
+// listInvitations lista os convites da organização, com os pendentes primeiro
+func listInvitations(db *sql.DB, org organization) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		orgID, err := strconv.Atoi(c.Params(org.param))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID da organização inválido"})
+		}
+		if _, _, err := authorizeMember(db, c, org, orgID, MemberManager); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		rows, err := db.Query("SELECT "+invitationColumns+" FROM "+org.invitationsTable+" WHERE "+org.column+" = ? ORDER BY status = 'pending' DESC, id DESC", orgID)
+		if err != nil {
+			log.Println("Erro ao buscar convites:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar convites"})
+		}
+		defer rows.Close()
+
+		invitations := []OrganizationInvitation{}
+		for rows.Next() {
+			invitation, err := scanInvitation(rows)
+			if err != nil {
+				log.Println("Erro ao ler convite:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar convites"})
+			}
+			invitations = append(invitations, invitation)
+		}
+
+		return c.Status(200).JSON(invitations)
+	}
+}
+
+// createInvitation convida um e-mail para a equipe. Um novo convite para o
+// mesmo e-mail substitui o pendente.
+func createInvitation(db *sql.DB, org organization) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		orgID, err := strconv.Atoi(c.Params(org.param))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID da organização inválido"})
+		}
+
+		var request InvitationRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Erro ao analisar o corpo da requisição"})
+		}
+		email, ok := normalizeEmail(request.Email)
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{"error": "E-mail inválido"})
+		}
+		if !validMemberRole(request.Role) {
+			return c.Status(400).JSON(fiber.Map{"error": "Papel inválido. Use: owner, manager ou operator"})
+		}
+
+		callerID, callerRole, err := authorizeMember(db, c, org, orgID, MemberManager)
+		if err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+		if memberRoleRanks[request.Role] > memberRoleRanks[callerRole] {
+			return c.Status(403).JSON(fiber.Map{"error": "Só é possível convidar com papéis até o seu próprio papel"})
+		}
+
+		token, tokenHash, err := newInvitationToken()
+		if err != nil {
+			log.Println("Erro ao gerar token do convite:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar convite"})
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar convite"})
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec("UPDATE "+org.invitationsTable+" SET status = ? WHERE "+org.column+" = ? AND email = ? AND status = ?",
+			InvitationRevoked, orgID, email, InvitationPending); err != nil {
+			log.Println("Erro ao substituir convite pendente:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar convite"})
+		}
+
+		var pending int
+		err = tx.QueryRow("SELECT COUNT(*) FROM "+org.invitationsTable+" WHERE "+org.column+" = ? AND status = ? AND expires_at > NOW()",
+			orgID, InvitationPending).Scan(&pending)
+		if err != nil {
+			log.Println("Erro ao contar convites:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar convite"})
+		}
+		if pending >= maxPendingInvitations {
+			return c.Status(409).JSON(fiber.Map{"error": "Limite de " + strconv.Itoa(maxPendingInvitations) + " convites pendentes atingido"})
+		}
+
+		expiresAt := time.Now().Add(invitationTTL).Format("2006-01-02 15:04:05")
+		result, err := tx.Exec("INSERT INTO "+org.invitationsTable+" ("+org.column+", email, role, token_hash, invited_by, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+			orgID, email, request.Role, tokenHash, callerID, expiresAt)
+		if err != nil {
+			log.Println("Erro ao criar convite:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar convite"})
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			log.Println("Erro ao obter o ID do convite:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar convite"})
+		}
+
+		invitation, err := scanInvitation(tx.QueryRow("SELECT "+invitationColumns+" FROM "+org.invitationsTable+" WHERE id = ?", id))
+		if err != nil {
+			log.Println("Erro ao buscar convite:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar convite"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao confirmar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar convite"})
+		}
+
+		invitation.Token = token
+		return c.Status(201).JSON(invitation)
+	}
+}
+
+// revokeInvitation cancela um convite pendente
+func revokeInvitation(db *sql.DB, org organization) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		orgID, err := strconv.Atoi(c.Params(org.param))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID da organização inválido"})
+		}
+		invitationID, err := strconv.Atoi(c.Params("invitation_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do convite inválido"})
+		}
+
+		if _, _, err := authorizeMember(db, c, org, orgID, MemberManager); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		result, err := db.Exec("UPDATE "+org.invitationsTable+" SET status = ? WHERE id = ? AND "+org.column+" = ? AND status = ?",
+			InvitationRevoked, invitationID, orgID, InvitationPending)
+		if err != nil {
+			log.Println("Erro ao revogar convite:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao revogar convite"})
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Println("Erro ao obter o número de linhas afetadas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao revogar convite"})
+		}
+		if rowsAffected == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Convite pendente não encontrado"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{"message": "Convite revogado com sucesso"})
+	}
+}
+
+// @Summary Equipe do vendor
+// @Description Lista os usuários da equipe do vendor com o papel de cada um (owner, manager ou operator). Restrito à equipe e a administradores
+// @Tags Vendors
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Success 200 {array} OrganizationMember
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar membros"
+// @Router /vendors/{vendor_id}/members [get]
+func GetVendorMembers(db *sql.DB) fiber.Handler {
+	return listMembers(db, vendorOrganization)
+}
+
+// @Summary Alterar papel na equipe do vendor
+// @Description Altera o papel de um membro. Managers e owners alteram membros e concedem papéis até o próprio papel; o vendor precisa manter ao menos um owner
+// @Tags Vendors
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param user_id path int true "ID do usuário membro"
+// @Param member body MemberRoleRequest true "Novo papel"
+// @Success 200 {object} map[string]interface{} "Papel atualizado"
+// @Failure 400 {object} map[string]string "Papel inválido"
+// @Failure 403 {object} map[string]string "Sem permissão"
+// @Failure 404 {object} map[string]string "Membro não encontrado"
+// @Failure 409 {object} map[string]string "Último owner do vendor"
+// @Failure 500 {object} map[string]string "Erro ao atualizar membro"
+// @Router /vendors/{vendor_id}/members/{user_id} [patch]
+func UpdateVendorMember(db *sql.DB) fiber.Handler {
+	return updateMemberRole(db, vendorOrganization)
+}
+
+// @Summary Remover da equipe do vendor
+// @Description Remove um membro da equipe; qualquer membro pode sair por conta própria. O vendor precisa manter ao menos um owner
+// @Tags Vendors
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param user_id path int true "ID do usuário membro"
+// @Success 200 {object} map[string]string "Membro removido"
+// @Failure 403 {object} map[string]string "Sem permissão"
+// @Failure 404 {object} map[string]string "Membro não encontrado"
+// @Failure 409 {object} map[string]string "Último owner do vendor"
+// @Failure 500 {object} map[string]string "Erro ao remover membro"
+// @Router /vendors/{vendor_id}/members/{user_id} [delete]
+func DeleteVendorMember(db *sql.DB) fiber.Handler {
+	return removeMember(db, vendorOrganization)
+}
+
+// @Summary Convites da equipe do vendor
+// @Description Lista os convites enviados pela equipe do vendor. Restrito a managers, owners e administradores
+// @Tags Vendors
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Success 200 {array} OrganizationInvitation
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar convites"
+// @Router /vendors/{vendor_id}/invitations [get]
+func GetVendorInvitations(db *sql.DB) fiber.Handler {
+	return listInvitations(db, vendorOrganization)
+}
+
+// @Summary Convidar para a equipe do vendor
+// @Description Cria um convite válido por 7 dias para o e-mail informado, com o papel até o de quem convida. O token devolvido só aparece nesta resposta e deve ser enviado ao convidado por e-mail; um novo convite para o mesmo e-mail substitui o pendente
+// @Tags Vendors
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param invitation body InvitationRequest true "E-mail e papel"
+// @Success 201 {object} OrganizationInvitation
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 403 {object} map[string]string "Sem permissão"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 409 {object} map[string]string "Limite de convites pendentes"
+// @Failure 500 {object} map[string]string "Erro ao criar convite"
+// @Router /vendors/{vendor_id}/invitations [post]
+func CreateVendorInvitation(db *sql.DB) fiber.Handler {
+	return createInvitation(db, vendorOrganization)
+}
+
+// @Summary Revogar convite do vendor
+// @Description Cancela um convite pendente da equipe do vendor
+// @Tags Vendors
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param invitation_id path int true "ID do convite"
+// @Success 200 {object} map[string]string "Convite revogado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Convite pendente não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao revogar convite"
+// @Router /vendors/{vendor_id}/invitations/{invitation_id} [delete]
+func DeleteVendorInvitation(db *sql.DB) fiber.Handler {
+	return revokeInvitation(db, vendorOrganization)
+}
+
+// @Summary Equipe do comprador
+// @Description Lista os usuários da equipe do comprador com o papel de cada um (owner, manager ou operator). Restrito à equipe e a administradores
+// @Tags Buyers
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do comprador"
+// @Success 200 {array} OrganizationMember
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o comprador"
+// @Failure 404 {object} map[string]string "Comprador não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar membros"
+// @Router /buyers/{id}/members [get]
+func GetBuyerMembers(db *sql.DB) fiber.Handler {
+	return listMembers(db, buyerOrganization)
+}
+
+// @Summary Alterar papel na equipe do comprador
+// @Description Altera o papel de um membro. Managers e owners alteram membros e concedem papéis até o próprio papel; o comprador precisa manter ao menos um owner
+// @Tags Buyers
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do comprador"
+// @Param user_id path int true "ID do usuário membro"
+// @Param member body MemberRoleRequest true "Novo papel"
+// @Success 200 {object} map[string]interface{} "Papel atualizado"
+// @Failure 400 {object} map[string]string "Papel inválido"
+// @Failure 403 {object} map[string]string "Sem permissão"
+// @Failure 404 {object} map[string]string "Membro não encontrado"
+// @Failure 409 {object} map[string]string "Último owner do comprador"
+// @Failure 500 {object} map[string]string "Erro ao atualizar membro"
+// @Router /buyers/{id}/members/{user_id} [patch]
+func UpdateBuyerMember(db *sql.DB) fiber.Handler {
+	return updateMemberRole(db, buyerOrganization)
+}
+
+// @Summary Remover da equipe do comprador
+// @Description Remove um membro da equipe; qualquer membro pode sair por conta própria. O comprador precisa manter ao menos um owner
+// @Tags Buyers
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do comprador"
+// @Param user_id path int true "ID do usuário membro"
+// @Success 200 {object} map[string]string "Membro removido"
+// @Failure 403 {object} map[string]string "Sem permissão"
+// @Failure 404 {object} map[string]string "Membro não encontrado"
+// @Failure 409 {object} map[string]string "Último owner do comprador"
+// @Failure 500 {object} map[string]string "Erro ao remover membro"
+// @Router /buyers/{id}/members/{user_id} [delete]
+func DeleteBuyerMember(db *sql.DB) fiber.Handler {
+	return removeMember(db, buyerOrganization)
+}
+
+// @Summary Convites da equipe do comprador
+// @Description Lista os convites enviados pela equipe do comprador. Restrito a managers, owners e administradores
+// @Tags Buyers
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do comprador"
+// @Success 200 {array} OrganizationInvitation
+// @Failure 403 {object} map[string]string "Sem permissão sobre o comprador"
+// @Failure 404 {object} map[string]string "Comprador não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar convites"
+// @Router /buyers/{id}/invitations [get]
+func GetBuyerInvitations(db *sql.DB) fiber.Handler {
+	return listInvitations(db, buyerOrganization)
+}
+
+// @Summary Convidar para a equipe do comprador
+// @Description Cria um convite válido por 7 dias para o e-mail informado, com o papel até o de quem convida. O token devolvido só aparece nesta resposta e deve ser enviado ao convidado por e-mail; um novo convite para o mesmo e-mail substitui o pendente
+// @Tags Buyers
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do comprador"
+// @Param invitation body InvitationRequest true "E-mail e papel"
+// @Success 201 {object} OrganizationInvitation
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 403 {object} map[string]string "Sem permissão"
+// @Failure 404 {object} map[string]string "Comprador não encontrado"
+// @Failure 409 {object} map[string]string "Limite de convites pendentes"
+// @Failure 500 {object} map[string]string "Erro ao criar convite"
+// @Router /buyers/{id}/invitations [post]
+func CreateBuyerInvitation(db *sql.DB) fiber.Handler {
+	return createInvitation(db, buyerOrganization)
+}
+
+// @Summary Revogar convite do comprador
+// @Description Cancela um convite pendente da equipe do comprador
+// @Tags Buyers
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do comprador"
+// @Param invitation_id path int true "ID do convite"
+// @Success 200 {object} map[string]string "Convite revogado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o comprador"
+// @Failure 404 {object} map[string]string "Convite pendente não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao revogar convite"
+// @Router /buyers/{id}/invitations/{invitation_id} [delete]
+func DeleteBuyerInvitation(db *sql.DB) fiber.Handler {
+	return revokeInvitation(db, buyerOrganization)
+}
+
+// @Summary Aceitar convite
+// @Description Adiciona o usuário (X-User-ID) à equipe do vendor ou do comprador com o papel do convite, a partir do token recebido por e-mail. O token é a única credencial do convite: quem o apresenta entra na equipe, qualquer que seja o e-mail convidado
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param invitation body AcceptInvitationRequest true "Token do convite"
+// @Success 200 {object} UserMembership
+// @Failure 400 {object} map[string]string "Token não informado"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 404 {object} map[string]string "Convite não encontrado"
+// @Failure 409 {object} map[string]string "Convite já usado, revogado ou usuário já é membro"
+// @Failure 410 {object} map[string]string "Convite expirado"
+// @Failure 500 {object} map[string]string "Erro ao aceitar convite"
+// @Router /invitations/accept [post]
+func AcceptInvitation(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := callerUserID(c)
+		if !ok {
+			return c.Status(401).JSON(fiber.Map{"error": "Usuário não identificado"})
+		}
+
+		var request AcceptInvitationRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Erro ao analisar o corpo da requisição"})
+		}
+		if strings.TrimSpace(request.Token) == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Token do convite é obrigatório"})
+		}
+		tokenHash := invitationTokenHash(request.Token)
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao aceitar convite"})
+		}
+		defer tx.Rollback()
+
+		// O token é procurado nos convites de vendors e de compradores
+		for _, org := range []organization{vendorOrganization, buyerOrganization} {
+			var invitationID, orgID int
+			var role, status string
+			var expired bool
+			err := tx.QueryRow("SELECT id, "+org.column+", role, status, expires_at <= NOW() FROM "+org.invitationsTable+" WHERE token_hash = ? FOR UPDATE", tokenHash).
+				Scan(&invitationID, &orgID, &role, &status, &expired)
+			if err == sql.ErrNoRows {
+				continue
+			} else if err != nil {
+				log.Println("Erro ao buscar convite:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao aceitar convite"})
+			}
+
+			switch {
+			case status != InvitationPending:
+				return c.Status(409).JSON(fiber.Map{"error": "Convite já utilizado ou revogado"})
+			case expired:
+				return c.Status(410).JSON(fiber.Map{"error": "Convite expirado; peça um novo convite"})
+			}
+
+			current, err := memberRole(tx, org, orgID, userID)
+			if err != nil {
+				log.Println("Erro ao buscar membro:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao aceitar convite"})
+			}
+			if current != "" {
+				return c.Status(409).JSON(fiber.Map{"error": "O usuário já faz parte desta equipe"})
+			}
+
+			if _, err := tx.Exec("INSERT INTO "+org.membersTable+" ("+org.column+", users_id, role) VALUES (?, ?, ?)", orgID, userID, role); err != nil {
+				log.Println("Erro ao adicionar membro:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao aceitar convite"})
+			}
+			if _, err := tx.Exec("UPDATE "+org.invitationsTable+" SET status = ?, accepted_by = ?, accepted_at = NOW() WHERE id = ?",
+				InvitationAccepted, userID, invitationID); err != nil {
+				log.Println("Erro ao atualizar convite:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao aceitar convite"})
+			}
+
+			membership := UserMembership{Type: org.kind, ID: orgID, Role: role}
+			err = tx.QueryRow("SELECT o.name, m.created_at FROM "+org.table+" o INNER JOIN "+org.membersTable+" m ON m."+org.column+" = o.id WHERE o.id = ? AND m.users_id = ?", orgID, userID).
+				Scan(&membership.Name, &membership.CreatedAt)
+			if err != nil {
+				log.Println("Erro ao buscar organização:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao aceitar convite"})
+			}
+
+			if err := tx.Commit(); err != nil {
+				log.Println("Erro ao confirmar transação:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao aceitar convite"})
+			}
+			return c.Status(200).JSON(membership)
+		}
+
+		return c.Status(404).JSON(fiber.Map{"message": "Convite não encontrado"})
+	}
+}
+
+// @Summary Organizações do usuário
+// @Description Lista os vendors e compradores de que o usuário faz parte, com o papel em cada um. Restrito ao próprio usuário e a administradores
+// @Tags Users
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do usuário"
+// @Success 200 {array} UserMembership
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão"
+// @Failure 500 {object} map[string]string "Erro ao buscar organizações"
+// @Router /users/{id}/memberships [get]
+func GetUserMemberships(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do usuário inválido"})
+		}
+
+		callerID, ok := callerUserID(c)
+		if !ok {
+			return c.Status(401).JSON(fiber.Map{"error": "Usuário não identificado"})
+		}
+		if callerID != userID {
+			admin, err := isAdminUser(db, callerID)
+			if err != nil {
+				log.Println("Erro ao verificar permissões do usuário:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar permissões"})
+			}
+			if !admin {
+				return c.Status(403).JSON(fiber.Map{"error": "Apenas o próprio usuário ou administradores podem ver estas organizações"})
+			}
+		}
+
+		rows, err := db.Query(`
+			SELECT 'vendor', v.id, v.name, m.role, m.created_at
+			FROM vendor_members m
+			INNER JOIN vendors v ON v.id = m.vendors_id
+			WHERE m.users_id = ?
+			UNION ALL
+			SELECT 'buyer', b.id, b.name, m.role, m.created_at
+			FROM buyer_members m
+			INNER JOIN buyers b ON b.id = m.buyers_id
+			WHERE m.users_id = ?
+			ORDER BY 1 DESC, 3`, userID, userID)
+		if err != nil {
+			log.Println("Erro ao buscar organizações do usuário:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar organizações"})
+		}
+		defer rows.Close()
+
+		memberships := []UserMembership{}
+		for rows.Next() {
+			var membership UserMembership
+			if err := rows.Scan(&membership.Type, &membership.ID, &membership.Name, &membership.Role, &membership.CreatedAt); err != nil {
+				log.Println("Erro ao ler organização do usuário:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar organizações"})
+			}
+			memberships = append(memberships, membership)
+		}
+
+		return c.Status(200).JSON(memberships)
+	}
+}
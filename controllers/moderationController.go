@@ -0,0 +1,404 @@
+package controllers
+
+import (
+	"api/pagination"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Tipos e status das revisões de moderação
+const (
+	ModerationActionCreate = "create"
+	ModerationActionUpdate = "update"
+
+	ModerationStatusPending  = "pending"
+	ModerationStatusApproved = "approved"
+	ModerationStatusRejected = "rejected"
+)
+
+// ProductModeration é uma revisão de produto na fila de moderação
+type ProductModeration struct {
+	ID         int             `json:"id"`
+	ProductID  int             `json:"products_id"`
+	UsersID    *int            `json:"users_id"`
+	Action     string          `json:"action"`
+	Payload    json.RawMessage `json:"payload"`
+	Status     string          `json:"status"`
+	Reason     *string         `json:"reason"`
+	ReviewedBy *int            `json:"reviewed_by"`
+	ReviewedAt *string         `json:"reviewed_at"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+// ModerationDecision é o corpo das rotas de aprovação e rejeição
+type ModerationDecision struct {
+	Reason string `json:"reason"`
+}
+
+// moderationCreatePayload guarda o status pedido pelo vendor no cadastro,
+// aplicado quando o produto é aprovado
+type moderationCreatePayload struct {
+	Status string `json:"status"`
+}
+
+// moderationModeAll indica se todos os produtos passam por moderação
+// (MODERATION_MODE=all). Caso contrário, apenas as categorias marcadas com
+// requires_moderation e suas subcategorias.
+func moderationModeAll() bool {
+	return strings.EqualFold(os.Getenv("MODERATION_MODE"), "all")
+}
+
+// productRequiresModeration indica se produtos nas categorias informadas
+// precisam de revisão, considerando a marcação herdada das categorias pai
+func productRequiresModeration(q sqlQueryer, categoryIDs ...int) (bool, error) {
+	if moderationModeAll() {
+		return true, nil
+	}
+
+	parents, err := loadCategoryParents(q, false)
+	if err != nil {
+		return false, err
+	}
+
+	var lineage []int
+	for _, categoryID := range categoryIDs {
+		lineage = append(lineage, categoryID)
+		lineage = append(lineage, categoryAncestors(parents, categoryID)...)
+	}
+
+	var count int
+	err = q.QueryRow("SELECT COUNT(*) FROM categories_products WHERE requires_moderation = 1 AND id IN ("+placeholders(len(lineage))+")",
+		intArgs(lineage)...).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// submitProductModeration coloca uma revisão na fila e devolve seu ID
+func submitProductModeration(q sqlQueryer, productID interface{}, userID int, action string, payload interface{}) (int64, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	var submitter interface{}
+	if userID > 0 {
+		submitter = userID
+	}
+
+	result, err := q.Exec("INSERT INTO product_moderation (products_id, users_id, action, payload) VALUES (?, ?, ?, ?)",
+		productID, submitter, action, string(encoded))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+const productModerationColumns = `
+	id, products_id, users_id, action, COALESCE(payload, 'null'), status, reason,
+	reviewed_by, reviewed_at, created_at`
+
+func scanProductModeration(scanner interface{ Scan(...interface{}) error }) (ProductModeration, error) {
+	var moderation ProductModeration
+	var payload string
+	err := scanner.Scan(&moderation.ID, &moderation.ProductID, &moderation.UsersID, &moderation.Action, &payload,
+		&moderation.Status, &moderation.Reason, &moderation.ReviewedBy, &moderation.ReviewedAt, &moderation.CreatedAt)
+	moderation.Payload = json.RawMessage(payload)
+	return moderation, err
+}
+
+// loadPendingModeration busca e trava uma revisão pendente
+func loadPendingModeration(tx *sql.Tx, moderationID int) (*ProductModeration, error) {
+	row := tx.QueryRow("SELECT "+productModerationColumns+" FROM product_moderation WHERE id = ? FOR UPDATE", moderationID)
+	moderation, err := scanProductModeration(row)
+	if err == sql.ErrNoRows {
+		return nil, &requestError{404, "Revisão não encontrada"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if moderation.Status != ModerationStatusPending {
+		return nil, &requestError{409, "Revisão já foi decidida"}
+	}
+	return &moderation, nil
+}
+
+// @Summary Fila de moderação
+// @Description Lista as revisões de produtos, por padrão as pendentes
+// @Tags Moderation
+// @Param status query string false "Status das revisões (pending, approved, rejected)" default(pending)
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros inválidos"
+// @Failure 500 {object} map[string]string "Erro ao buscar revisões"
+// @Router /admin/moderation [get]
+func GetModerationQueue(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		status := c.Query("status", ModerationStatusPending)
+		if status != ModerationStatusPending && status != ModerationStatusApproved && status != ModerationStatusRejected {
+			return c.Status(400).JSON(fiber.Map{"error": "Status inválido. Use: pending, approved ou rejected"})
+		}
+
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		keyset, keysetArgs := params.Keyset("id")
+
+		args := append(append([]interface{}{status}, keysetArgs...), params.FetchLimit())
+		rows, err := db.Query("SELECT "+productModerationColumns+" FROM product_moderation WHERE status = ? AND "+keyset+" ORDER BY id DESC LIMIT ?", args...)
+		if err != nil {
+			log.Println("Erro ao buscar fila de moderação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar revisões"})
+		}
+		defer rows.Close()
+
+		var queue []ProductModeration
+		for rows.Next() {
+			moderation, err := scanProductModeration(rows)
+			if err != nil {
+				log.Println("Erro ao ler revisão:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler revisão"})
+			}
+			queue = append(queue, moderation)
+		}
+
+		if err := rows.Err(); err != nil {
+			log.Println("Erro com as linhas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar revisões"})
+		}
+
+		page := pagination.NewPage(queue, params, func(m ProductModeration) int { return m.ID })
+
+		if params.IncludeTotal {
+			var total int
+			if err := db.QueryRow("SELECT COUNT(*) FROM product_moderation WHERE status = ?", status).Scan(&total); err != nil {
+				log.Println("Erro ao contar revisões:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao contar revisões"})
+			}
+			page = page.WithTotal(total)
+		}
+
+		return c.Status(200).JSON(page)
+	}
+}
+
+// @Summary Aprovar revisão
+// @Description Aprova uma revisão pendente. No cadastro, o produto recebe o status pedido pelo vendor; na edição, as alterações são aplicadas
+// @Tags Moderation
+// @Param id path int true "ID da revisão"
+// @Param decision body ModerationDecision false "Observação opcional"
+// @Success 200 {object} map[string]interface{} "Revisão aprovada"
+// @Failure 404 {object} map[string]string "Revisão não encontrada"
+// @Failure 409 {object} map[string]string "Revisão já decidida ou alterações não são mais válidas"
+// @Failure 500 {object} map[string]string "Erro ao aprovar revisão"
+// @Router /admin/moderation/{id}/approve [post]
+func ApproveModeration(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		moderationID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+
+		var decision ModerationDecision
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&decision); err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+			}
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao aprovar revisão"})
+		}
+		defer tx.Rollback()
+
+		moderation, err := loadPendingModeration(tx, moderationID)
+		if err != nil {
+			return respondError(c, err, "Erro ao buscar revisão:", "Erro ao aprovar revisão")
+		}
+
+		switch moderation.Action {
+		case ModerationActionCreate:
+			var payload moderationCreatePayload
+			if err := json.Unmarshal(moderation.Payload, &payload); err != nil || !validProductStatus(payload.Status) {
+				payload.Status = ProductStatusPublished
+			}
+			_, err = tx.Exec("UPDATE products SET status = ? WHERE id = ? AND status = ?",
+				payload.Status, moderation.ProductID, ProductStatusPendingReview)
+
+		case ModerationActionUpdate:
+			var update ProductUpdate
+			if err := json.Unmarshal(moderation.Payload, &update); err != nil {
+				log.Println("Erro ao ler alterações da revisão:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao aprovar revisão"})
+			}
+
+			var currentCategoryID int
+			err = tx.QueryRow("SELECT categories_products_id FROM products WHERE id = ? AND deleted_at IS NULL FOR UPDATE",
+				moderation.ProductID).Scan(&currentCategoryID)
+			if err == sql.ErrNoRows {
+				return c.Status(409).JSON(fiber.Map{"error": "Produto foi excluído; rejeite a revisão"})
+			}
+			if err != nil {
+				log.Println("Erro ao buscar produto da revisão:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao aprovar revisão"})
+			}
+
+			// As alterações são revalidadas, pois o produto pode ter mudado desde o envio
+			plan, planErr := planProductUpdate(tx, moderation.ProductID, currentCategoryID, update)
+			if reqErr, ok := planErr.(*requestError); ok {
+				return c.Status(409).JSON(fiber.Map{"error": "Alterações não são mais válidas: " + reqErr.message})
+			}
+			if planErr != nil {
+				log.Println("Erro ao validar alterações da revisão:", planErr)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao aprovar revisão"})
+			}
+			err = plan.apply(tx, moderation.ProductID, 0)
+		}
+		if err != nil {
+			log.Println("Erro ao aplicar revisão:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao aprovar revisão"})
+		}
+
+		var reason interface{}
+		if decision.Reason != "" {
+			reason = decision.Reason
+		}
+		_, err = tx.Exec("UPDATE product_moderation SET status = ?, reason = ?, reviewed_by = ?, reviewed_at = NOW() WHERE id = ?",
+			ModerationStatusApproved, reason, c.Locals("admin_user_id"), moderationID)
+		if err != nil {
+			log.Println("Erro ao registrar decisão:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao aprovar revisão"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao aprovar revisão"})
+		}
+
+		reindexProduct(db, moderation.ProductID)
+
+		return c.Status(200).JSON(fiber.Map{
+			"message":     "Revisão aprovada",
+			"id":          moderationID,
+			"products_id": moderation.ProductID,
+		})
+	}
+}
+
+// @Summary Rejeitar revisão
+// @Description Rejeita uma revisão pendente com um motivo. No cadastro, o produto fica com status rejected até ser reenviado pelo vendor
+// @Tags Moderation
+// @Param id path int true "ID da revisão"
+// @Param decision body ModerationDecision true "Motivo da rejeição"
+// @Success 200 {object} map[string]interface{} "Revisão rejeitada"
+// @Failure 400 {object} map[string]string "Motivo obrigatório"
+// @Failure 404 {object} map[string]string "Revisão não encontrada"
+// @Failure 409 {object} map[string]string "Revisão já decidida"
+// @Failure 500 {object} map[string]string "Erro ao rejeitar revisão"
+// @Router /admin/moderation/{id}/reject [post]
+func RejectModeration(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		moderationID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+
+		var decision ModerationDecision
+		if err := c.BodyParser(&decision); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+		}
+		decision.Reason = strings.TrimSpace(decision.Reason)
+		if decision.Reason == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "O motivo da rejeição é obrigatório"})
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao rejeitar revisão"})
+		}
+		defer tx.Rollback()
+
+		moderation, err := loadPendingModeration(tx, moderationID)
+		if err != nil {
+			return respondError(c, err, "Erro ao buscar revisão:", "Erro ao rejeitar revisão")
+		}
+
+		if moderation.Action == ModerationActionCreate {
+			_, err = tx.Exec("UPDATE products SET status = ? WHERE id = ? AND status = ?",
+				ProductStatusRejected, moderation.ProductID, ProductStatusPendingReview)
+			if err != nil {
+				log.Println("Erro ao atualizar status do produto:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao rejeitar revisão"})
+			}
+		}
+
+		_, err = tx.Exec("UPDATE product_moderation SET status = ?, reason = ?, reviewed_by = ?, reviewed_at = NOW() WHERE id = ?",
+			ModerationStatusRejected, decision.Reason, c.Locals("admin_user_id"), moderationID)
+		if err != nil {
+			log.Println("Erro ao registrar decisão:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao rejeitar revisão"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao rejeitar revisão"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{
+			"message":     "Revisão rejeitada",
+			"id":          moderationID,
+			"products_id": moderation.ProductID,
+			"reason":      decision.Reason,
+		})
+	}
+}
+
+// @Summary Histórico de moderação do produto
+// @Description Lista as revisões de um produto com as decisões e motivos, da mais recente para a mais antiga
+// @Tags Moderation
+// @Param id path int true "ID do produto"
+// @Success 200 {array} ProductModeration
+// @Failure 500 {object} map[string]string "Erro ao buscar histórico"
+// @Router /products/id/{id}/moderation [get]
+func GetProductModerationHistory(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		productID := c.Params("id")
+
+		rows, err := db.Query("SELECT "+productModerationColumns+" FROM product_moderation WHERE products_id = ? ORDER BY id DESC", productID)
+		if err != nil {
+			log.Println("Erro ao buscar histórico de moderação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar histórico"})
+		}
+		defer rows.Close()
+
+		history := []ProductModeration{}
+		for rows.Next() {
+			moderation, err := scanProductModeration(rows)
+			if err != nil {
+				log.Println("Erro ao ler revisão:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler revisão"})
+			}
+			history = append(history, moderation)
+		}
+
+		if err := rows.Err(); err != nil {
+			log.Println("Erro com as linhas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar histórico"})
+		}
+
+		return c.Status(200).JSON(history)
+	}
+}
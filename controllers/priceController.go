@@ -0,0 +1,541 @@
+package controllers
+
+import (
+	"api/pagination"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Status de um preço programado
+const (
+	PriceScheduleScheduled = "scheduled"
+	PriceScheduleActive    = "active"
+	PriceScheduleCompleted = "completed"
+	PriceScheduleCancelled = "cancelled"
+)
+
+// Formatos aceitos para as datas dos preços programados
+var priceScheduleTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+// PriceChange é um registro do histórico de preços
+type PriceChange struct {
+	ID         int      `json:"id"`
+	ProductID  int      `json:"products_id"`
+	OldPrice   *float64 `json:"old_price"`
+	NewPrice   float64  `json:"new_price"`
+	UsersID    *int     `json:"users_id"`
+	ScheduleID *int     `json:"product_price_schedules_id"`
+	Reason     *string  `json:"reason"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// PriceSchedule é um preço programado para um período
+type PriceSchedule struct {
+	ID            int      `json:"id"`
+	ProductID     int      `json:"products_id"`
+	Price         float64  `json:"price"`
+	StartsAt      string   `json:"starts_at"`
+	EndsAt        *string  `json:"ends_at"`
+	PreviousPrice *float64 `json:"previous_price"`
+	Status        string   `json:"status"`
+	UsersID       *int     `json:"users_id"`
+	CreatedAt     string   `json:"created_at"`
+}
+
+// PriceScheduleRequest programa um preço. Sem ends_at o preço permanece após
+// ser aplicado; com ends_at o preço anterior é restaurado ao final.
+type PriceScheduleRequest struct {
+	Price    string `json:"price"`
+	StartsAt string `json:"starts_at"`
+	EndsAt   string `json:"ends_at"`
+}
+
+const priceScheduleColumns = "id, products_id, price, starts_at, ends_at, previous_price, status, users_id, created_at"
+
+func scanPriceSchedule(row interface{ Scan(...interface{}) error }) (PriceSchedule, error) {
+	var schedule PriceSchedule
+	err := row.Scan(&schedule.ID, &schedule.ProductID, &schedule.Price, &schedule.StartsAt, &schedule.EndsAt,
+		&schedule.PreviousPrice, &schedule.Status, &schedule.UsersID, &schedule.CreatedAt)
+	return schedule, err
+}
+
+// parseScheduleTime interpreta uma data de preço programado no fuso do servidor
+func parseScheduleTime(value string) (time.Time, error) {
+	for _, layout := range priceScheduleTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, errors.New("data inválida")
+}
+
+// logPriceChange grava no histórico uma alteração de preço já aplicada
+func logPriceChange(q sqlQueryer, productID interface{}, oldPrice interface{}, newPrice interface{}, userID, scheduleID int64, reason string) error {
+	var reasonValue interface{}
+	if reason != "" {
+		reasonValue = reason
+	}
+	_, err := q.Exec(`
+		INSERT INTO product_price_history (products_id, old_price, new_price, users_id, product_price_schedules_id, reason)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		productID, oldPrice, newPrice, nullableID(userID), nullableID(scheduleID), reasonValue)
+	return err
+}
+
+// setProductPrice altera o preço do produto e registra a mudança no
+// histórico; preços iguais ao atual não geram registro
+func setProductPrice(q sqlQueryer, productID interface{}, price string, userID, scheduleID int64, reason string) error {
+	var current string
+	var unchanged bool
+	err := q.QueryRow("SELECT price, price = ? FROM products WHERE id = ? FOR UPDATE", price, productID).Scan(&current, &unchanged)
+	if err != nil {
+		return err
+	}
+	if unchanged {
+		return nil
+	}
+
+	if _, err := q.Exec("UPDATE products SET price = ? WHERE id = ?", price, productID); err != nil {
+		return err
+	}
+	return logPriceChange(q, productID, current, price, userID, scheduleID, reason)
+}
+
+// ApplyPriceSchedules aplica os preços programados cujo início chegou e
+// restaura o preço anterior dos que terminaram. Um preço alterado
+// manualmente durante a vigência não é revertido.
+func ApplyPriceSchedules(db *sql.DB) (int, error) {
+	rows, err := db.Query(`
+		SELECT id FROM product_price_schedules
+		WHERE (status = 'scheduled' AND starts_at <= NOW())
+			OR (status = 'active' AND ends_at <= NOW())
+		ORDER BY starts_at, id`)
+	if err != nil {
+		return 0, err
+	}
+	var scheduleIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		scheduleIDs = append(scheduleIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, id := range scheduleIDs {
+		if err := applyPriceSchedule(db, id); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+func applyPriceSchedule(db *sql.DB, scheduleID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	schedule, err := scanPriceSchedule(tx.QueryRow("SELECT "+priceScheduleColumns+" FROM product_price_schedules WHERE id = ? FOR UPDATE", scheduleID))
+	if err != nil {
+		return err
+	}
+
+	var started, ended bool
+	if err := tx.QueryRow("SELECT starts_at <= NOW(), COALESCE(ends_at <= NOW(), FALSE) FROM product_price_schedules WHERE id = ?", scheduleID).
+		Scan(&started, &ended); err != nil {
+		return err
+	}
+
+	reason := fmt.Sprintf("Preço programado #%d", schedule.ID)
+	price := strconv.FormatFloat(schedule.Price, 'f', 2, 64)
+	switch {
+	case schedule.Status == PriceScheduleScheduled && started && ended:
+		// O período inteiro passou sem o agendador rodar; nada a aplicar
+		_, err = tx.Exec("UPDATE product_price_schedules SET status = 'completed' WHERE id = ?", scheduleID)
+	case schedule.Status == PriceScheduleScheduled && started:
+		var current string
+		if err = tx.QueryRow("SELECT price FROM products WHERE id = ? FOR UPDATE", schedule.ProductID).Scan(&current); err != nil {
+			return err
+		}
+		if err = setProductPrice(tx, schedule.ProductID, price, 0, int64(schedule.ID), reason); err != nil {
+			return err
+		}
+		status := PriceScheduleActive
+		if schedule.EndsAt == nil {
+			status = PriceScheduleCompleted
+		}
+		_, err = tx.Exec("UPDATE product_price_schedules SET status = ?, previous_price = ? WHERE id = ?", status, current, scheduleID)
+	case schedule.Status == PriceScheduleActive && ended:
+		err = revertPriceSchedule(tx, schedule, PriceScheduleCompleted, 0, "Fim do "+reason)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// revertPriceSchedule encerra um preço programado vigente, restaurando o
+// preço anterior se o produto ainda estiver com o preço programado
+func revertPriceSchedule(tx *sql.Tx, schedule PriceSchedule, status string, userID int64, reason string) error {
+	var stillScheduled bool
+	if err := tx.QueryRow("SELECT price = ? FROM products WHERE id = ? FOR UPDATE", schedule.Price, schedule.ProductID).
+		Scan(&stillScheduled); err != nil {
+		return err
+	}
+	if stillScheduled && schedule.PreviousPrice != nil {
+		previous := strconv.FormatFloat(*schedule.PreviousPrice, 'f', 2, 64)
+		if err := setProductPrice(tx, schedule.ProductID, previous, userID, int64(schedule.ID), reason); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec("UPDATE product_price_schedules SET status = ? WHERE id = ?", status, schedule.ID)
+	return err
+}
+
+// StartPriceScheduler executa ApplyPriceSchedules periodicamente em segundo plano
+func StartPriceScheduler(db *sql.DB, interval time.Duration) {
+	go func() {
+		for {
+			if applied, err := ApplyPriceSchedules(db); err != nil {
+				log.Println("Erro ao aplicar preços programados:", err)
+			} else if applied > 0 {
+				log.Printf("%d preço(s) programado(s) processado(s)", applied)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// @Summary Histórico de preços do produto
+// @Description Lista as alterações de preço do produto (mais recentes primeiro), com o preço anterior, o novo e quem alterou
+// @Tags Products
+// @Param id path int true "ID do produto"
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
+// @Failure 404 {object} map[string]string "Produto não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar histórico"
+// @Router /products/id/{id}/price-history [get]
+func GetProductPriceHistory(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		productID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		var exists int
+		if err := db.QueryRow("SELECT COUNT(*) FROM products WHERE id = ?", productID).Scan(&exists); err != nil {
+			log.Println("Erro ao verificar produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar histórico"})
+		}
+		if exists == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Produto não encontrado"})
+		}
+
+		keyset, keysetArgs := params.Keyset("id")
+		args := append(append([]interface{}{productID}, keysetArgs...), params.FetchLimit())
+		rows, err := db.Query(`
+			SELECT id, products_id, old_price, new_price, users_id, product_price_schedules_id, reason, created_at
+			FROM product_price_history
+			WHERE products_id = ? AND `+keyset+`
+			ORDER BY id DESC
+			LIMIT ?`, args...)
+		if err != nil {
+			log.Println("Erro ao buscar histórico de preços:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar histórico"})
+		}
+		defer rows.Close()
+
+		var changes []PriceChange
+		for rows.Next() {
+			var change PriceChange
+			if err := rows.Scan(&change.ID, &change.ProductID, &change.OldPrice, &change.NewPrice, &change.UsersID,
+				&change.ScheduleID, &change.Reason, &change.CreatedAt); err != nil {
+				log.Println("Erro ao ler alteração de preço:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler histórico"})
+			}
+			changes = append(changes, change)
+		}
+
+		if err := rows.Err(); err != nil {
+			log.Println("Erro com as linhas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar histórico"})
+		}
+
+		page := pagination.NewPage(changes, params, func(p PriceChange) int { return p.ID })
+
+		if params.IncludeTotal {
+			var total int
+			if err := db.QueryRow("SELECT COUNT(*) FROM product_price_history WHERE products_id = ?", productID).Scan(&total); err != nil {
+				log.Println("Erro ao contar histórico de preços:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar histórico"})
+			}
+			page = page.WithTotal(total)
+		}
+
+		return c.Status(200).JSON(page)
+	}
+}
+
+// @Summary Programar preço
+// @Description Programa um preço para o produto a partir de starts_at. Com ends_at (ex.: promoção de sexta a domingo) o preço anterior é restaurado ao final, salvo se tiver sido alterado manualmente durante a vigência. Períodos não podem se sobrepor a outros preços programados do produto. Restrito a owners e managers do vendor do produto e a administradores.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do produto"
+// @Param schedule body PriceScheduleRequest true "Preço e período"
+// @Success 201 {object} PriceSchedule "Preço programado"
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Produto pertence a outro vendor"
+// @Failure 404 {object} map[string]string "Produto não encontrado"
+// @Failure 409 {object} map[string]string "Período sobreposto"
+// @Failure 500 {object} map[string]string "Erro ao programar preço"
+// @Router /products/id/{id}/price-schedules [post]
+func CreatePriceSchedule(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		productID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+
+		var request PriceScheduleRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+		}
+
+		price, err := strconv.ParseFloat(request.Price, 64)
+		if err != nil || price < 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Preço inválido"})
+		}
+
+		startsAt, err := parseScheduleTime(request.StartsAt)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Início inválido. Use AAAA-MM-DD HH:MM:SS"})
+		}
+		var endsAt interface{}
+		if request.EndsAt != "" {
+			end, err := parseScheduleTime(request.EndsAt)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "Fim inválido. Use AAAA-MM-DD HH:MM:SS"})
+			}
+			if !end.After(startsAt) {
+				return c.Status(400).JSON(fiber.Map{"error": "O fim deve ser posterior ao início"})
+			}
+			if !end.After(time.Now()) {
+				return c.Status(400).JSON(fiber.Map{"error": "O período informado já terminou"})
+			}
+			endsAt = end.Format("2006-01-02 15:04:05")
+		}
+
+		actor, err := authorizeProductManager(db, c, productID)
+		if err != nil {
+			return respondError(c, err, "Erro ao verificar permissões:", "Erro ao programar preço")
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao programar preço"})
+		}
+		defer tx.Rollback()
+
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM products WHERE id = ? AND deleted_at IS NULL FOR UPDATE", productID).Scan(&exists); err != nil {
+			log.Println("Erro ao verificar produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao programar preço"})
+		}
+		if exists == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Produto não encontrado"})
+		}
+
+		// Períodos abertos (sem fim) se estendem indefinidamente
+		var overlapping int
+		err = tx.QueryRow(`
+			SELECT COUNT(*) FROM product_price_schedules
+			WHERE products_id = ? AND status IN ('scheduled', 'active')
+				AND (ends_at IS NULL OR ends_at > ?)
+				AND (? IS NULL OR starts_at < ?)`,
+			productID, startsAt.Format("2006-01-02 15:04:05"), endsAt, endsAt).Scan(&overlapping)
+		if err != nil {
+			log.Println("Erro ao verificar preços programados:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao programar preço"})
+		}
+		if overlapping > 0 {
+			return c.Status(409).JSON(fiber.Map{"error": "Já existe um preço programado para este período"})
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO product_price_schedules (products_id, price, starts_at, ends_at, users_id)
+			VALUES (?, ?, ?, ?, ?)`,
+			productID, request.Price, startsAt.Format("2006-01-02 15:04:05"), endsAt, nullableID(int64(actor)))
+		if err != nil {
+			log.Println("Erro ao programar preço:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao programar preço"})
+		}
+		scheduleID, err := result.LastInsertId()
+		if err != nil {
+			log.Println("Erro ao obter ID do preço programado:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao programar preço"})
+		}
+
+		schedule, err := scanPriceSchedule(tx.QueryRow("SELECT "+priceScheduleColumns+" FROM product_price_schedules WHERE id = ?", scheduleID))
+		if err != nil {
+			log.Println("Erro ao buscar preço programado:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao programar preço"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao programar preço"})
+		}
+
+		// Preços com início imediato não esperam a próxima rodada do agendador
+		if !startsAt.After(time.Now()) {
+			if err := applyPriceSchedule(db, schedule.ID); err != nil {
+				log.Println("Erro ao aplicar preço programado:", err)
+			}
+		}
+
+		return c.Status(201).JSON(schedule)
+	}
+}
+
+// @Summary Preços programados do produto
+// @Description Lista os preços programados do produto, dos mais recentes aos mais antigos
+// @Tags Products
+// @Param id path int true "ID do produto"
+// @Param status query string false "scheduled, active, completed ou cancelled"
+// @Success 200 {object} map[string]interface{} "Preços programados"
+// @Failure 400 {object} map[string]string "Status inválido"
+// @Failure 500 {object} map[string]string "Erro ao buscar preços programados"
+// @Router /products/id/{id}/price-schedules [get]
+func GetPriceSchedules(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		productID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+
+		query := "SELECT " + priceScheduleColumns + " FROM product_price_schedules WHERE products_id = ?"
+		args := []interface{}{productID}
+		if status := c.Query("status"); status != "" {
+			switch status {
+			case PriceScheduleScheduled, PriceScheduleActive, PriceScheduleCompleted, PriceScheduleCancelled:
+			default:
+				return c.Status(400).JSON(fiber.Map{"error": "Status inválido. Use: scheduled, active, completed ou cancelled"})
+			}
+			query += " AND status = ?"
+			args = append(args, status)
+		}
+
+		rows, err := db.Query(query+" ORDER BY starts_at DESC, id DESC", args...)
+		if err != nil {
+			log.Println("Erro ao buscar preços programados:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar preços programados"})
+		}
+		defer rows.Close()
+
+		schedules := []PriceSchedule{}
+		for rows.Next() {
+			schedule, err := scanPriceSchedule(rows)
+			if err != nil {
+				log.Println("Erro ao ler preço programado:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler preço programado"})
+			}
+			schedules = append(schedules, schedule)
+		}
+
+		if err := rows.Err(); err != nil {
+			log.Println("Erro com as linhas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar preços programados"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{"products_id": productID, "data": schedules})
+	}
+}
+
+// @Summary Cancelar preço programado
+// @Description Cancela um preço programado. Se ele estiver vigente, o preço anterior é restaurado imediatamente. Restrito a owners e managers do vendor do produto e a administradores
+// @Tags Products
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do produto"
+// @Param schedule_id path int true "ID do preço programado"
+// @Success 200 {object} PriceSchedule "Preço programado cancelado"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Produto pertence a outro vendor"
+// @Failure 404 {object} map[string]string "Preço programado não encontrado"
+// @Failure 409 {object} map[string]string "Preço programado já encerrado"
+// @Failure 500 {object} map[string]string "Erro ao cancelar preço programado"
+// @Router /products/id/{id}/price-schedules/{schedule_id} [delete]
+func CancelPriceSchedule(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor, err := authorizeProductManager(db, c, c.Params("id"))
+		if err != nil {
+			return respondError(c, err, "Erro ao verificar permissões:", "Erro ao cancelar preço programado")
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cancelar preço programado"})
+		}
+		defer tx.Rollback()
+
+		schedule, err := scanPriceSchedule(tx.QueryRow(
+			"SELECT "+priceScheduleColumns+" FROM product_price_schedules WHERE id = ? AND products_id = ? FOR UPDATE",
+			c.Params("schedule_id"), c.Params("id")))
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Preço programado não encontrado"})
+		} else if err != nil {
+			log.Println("Erro ao buscar preço programado:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cancelar preço programado"})
+		}
+
+		switch schedule.Status {
+		case PriceScheduleScheduled:
+			_, err = tx.Exec("UPDATE product_price_schedules SET status = 'cancelled' WHERE id = ?", schedule.ID)
+		case PriceScheduleActive:
+			err = revertPriceSchedule(tx, schedule, PriceScheduleCancelled, int64(actor), fmt.Sprintf("Cancelamento do preço programado #%d", schedule.ID))
+		default:
+			return c.Status(409).JSON(fiber.Map{"error": "Preço programado já encerrado"})
+		}
+		if err != nil {
+			log.Println("Erro ao cancelar preço programado:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cancelar preço programado"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cancelar preço programado"})
+		}
+
+		schedule.Status = PriceScheduleCancelled
+		return c.Status(200).JSON(schedule)
+	}
+}
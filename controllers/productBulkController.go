@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Maior quantidade de operações aceita por requisição em lote
+const maxBulkOperations = 500
+
+// BulkProductOperation altera preço e estoque de um produto identificado
+// pelo id ou pelo sku. quantity define o estoque; delta soma ao estoque atual.
+type BulkProductOperation struct {
+	ID       *int    `json:"id,omitempty"`
+	SKU      *string `json:"sku,omitempty"`
+	Price    *string `json:"price,omitempty"`
+	Quantity *int    `json:"quantity,omitempty"`
+	Delta    *int    `json:"delta,omitempty"`
+}
+
+// BulkProductUpdate é o corpo de PATCH /products/bulk
+type BulkProductUpdate struct {
+	Operations []BulkProductOperation `json:"operations"`
+}
+
+// BulkOperationResult é o resultado de uma operação do lote
+type BulkOperationResult struct {
+	Index    int      `json:"index"`
+	ID       int      `json:"id,omitempty"`
+	SKU      string   `json:"sku,omitempty"`
+	Price    *float64 `json:"price,omitempty"`
+	Quantity *int     `json:"quantity,omitempty"`
+	Status   int      `json:"status"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// applyBulkOperation aplica uma operação do lote na transação, validando a
+// posse do produto e reaproveitando as regras da atualização individual
+func applyBulkOperation(tx *sql.Tx, operation BulkProductOperation, callerID int, admin bool, result *BulkOperationResult) error {
+	if (operation.ID == nil) == (operation.SKU == nil) {
+		return &requestError{400, "Informe o id ou o sku do produto"}
+	}
+	if operation.Price == nil && operation.Quantity == nil && operation.Delta == nil {
+		return &requestError{400, "Informe price, quantity ou delta"}
+	}
+	if operation.Quantity != nil && operation.Delta != nil {
+		return &requestError{400, "Use quantity ou delta, não ambos"}
+	}
+	if operation.Delta != nil && *operation.Delta == 0 {
+		return &requestError{400, "O delta não pode ser zero"}
+	}
+
+	condition, key := "id = ?", interface{}(nil)
+	if operation.ID != nil {
+		key = *operation.ID
+	} else {
+		condition, key = "sku = ?", *operation.SKU
+	}
+
+	var createdBy int
+	var vendorID *int
+	err := tx.QueryRow("SELECT id, sku, users_id, vendors_id FROM products WHERE "+condition+" AND deleted_at IS NULL FOR UPDATE", key).
+		Scan(&result.ID, &result.SKU, &createdBy, &vendorID)
+	if err == sql.ErrNoRows {
+		return &requestError{404, "Produto não encontrado"}
+	}
+	if err != nil {
+		return err
+	}
+	if !admin {
+		allowed, err := canManageProduct(tx, vendorID, createdBy, callerID)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return &requestError{403, "Produto pertence a outro vendor"}
+		}
+	}
+
+	update := ProductUpdate{Price: operation.Price}
+	if operation.Quantity != nil {
+		quantity := strconv.Itoa(*operation.Quantity)
+		update.Quantity = &quantity
+	}
+	if !update.empty() {
+		if _, err := updateProduct(tx, result.ID, update, callerID); err != nil {
+			return err
+		}
+	}
+
+	if operation.Delta != nil {
+		if _, err := adjustStock(tx, result.ID, MovementAdjustment, *operation.Delta, int64(callerID), 0, "Atualização em lote"); err != nil {
+			return err
+		}
+	}
+
+	var price float64
+	var quantity int
+	if err := tx.QueryRow("SELECT price, quantity FROM products WHERE id = ?", result.ID).Scan(&price, &quantity); err != nil {
+		return err
+	}
+	result.Price, result.Quantity = &price, &quantity
+	return nil
+}
+
+// @Summary Atualizar preço e estoque em lote
+// @Description Aplica uma lista de operações de preço e estoque em uma única transação: ou todas são aplicadas, ou nenhuma. Cada operação identifica o produto por id ou sku e informa price, quantity (novo estoque) ou delta (soma ao estoque). Apenas produtos do usuário do cabeçalho X-User-ID podem ser alterados, exceto por administradores.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param operations body BulkProductUpdate true "Operações"
+// @Success 200 {object} map[string]interface{} "Todas as operações aplicadas"
+// @Failure 400 {object} map[string]interface{} "Nenhuma operação aplicada; resultados por item"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 500 {object} map[string]string "Erro ao atualizar produtos"
+// @Router /products/bulk [patch]
+func BulkUpdateProducts(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		callerID, ok := callerUserID(c)
+		if !ok {
+			return c.Status(401).JSON(fiber.Map{"error": "Usuário não identificado"})
+		}
+
+		var request BulkProductUpdate
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados inválidos"})
+		}
+		if len(request.Operations) == 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Nenhuma operação informada"})
+		}
+		if len(request.Operations) > maxBulkOperations {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("Limite de %d operações por requisição", maxBulkOperations)})
+		}
+
+		admin, err := isAdminUser(db, callerID)
+		if err != nil {
+			log.Println("Erro ao verificar permissões do usuário:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar permissões"})
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar produtos"})
+		}
+		defer tx.Rollback()
+
+		// Cada operação roda em seu savepoint para que todas sejam validadas e
+		// relatadas, mesmo depois da primeira falha
+		results := make([]BulkOperationResult, len(request.Operations))
+		failed := 0
+		for i, operation := range request.Operations {
+			result := &results[i]
+			result.Index = i
+			result.Status = 200
+
+			if _, err := tx.Exec("SAVEPOINT bulk_operation"); err != nil {
+				log.Println("Erro ao criar savepoint:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar produtos"})
+			}
+
+			err := applyBulkOperation(tx, operation, callerID, admin, result)
+			if err == nil {
+				if _, err := tx.Exec("RELEASE SAVEPOINT bulk_operation"); err != nil {
+					log.Println("Erro ao liberar savepoint:", err)
+					return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar produtos"})
+				}
+				continue
+			}
+
+			var reqErr *requestError
+			if !errors.As(err, &reqErr) {
+				log.Println("Erro ao aplicar operação em lote:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar produtos"})
+			}
+			result.Status, result.Error = reqErr.status, reqErr.message
+			result.Price, result.Quantity = nil, nil
+			failed++
+			if _, err := tx.Exec("ROLLBACK TO SAVEPOINT bulk_operation"); err != nil {
+				log.Println("Erro ao desfazer operação:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar produtos"})
+			}
+		}
+
+		if failed > 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"error":   fmt.Sprintf("Nenhuma alteração aplicada: %d operação(ões) com erro", failed),
+				"applied": false,
+				"results": results,
+			})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar produtos"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{
+			"message": "Produtos atualizados com sucesso",
+			"applied": true,
+			"results": results,
+		})
+	}
+}
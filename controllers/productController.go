@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"api/pagination"
 	"database/sql"
+	"errors"
 	"log"
+	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,8 +19,10 @@ type ProductBySKU struct {
 	Name       string  `json:"name"`
 	Price      float64 `json:"price"`
 	UsersId    int     `json:"users_id"`
+	VendorsID  *int    `json:"vendors_id"`
 	Quantity   int     `json:"quantity"`
 	CategoryId int     `json:"categories_product_id"`
+	Status     string  `json:"status"`
 }
 
 type Product struct {
@@ -28,17 +33,36 @@ type Product struct {
 	UsersId      int     `json:"users_id"`
 	Quantity     int     `json:"quantity"`
 	CategoryName string  `json:"category_name"`
+	Status       string  `json:"status"`
+	// Produtos com variantes são comprados pela variante escolhida
+	HasVariants  bool    `json:"has_variants"`
+	// Média e quantidade das avaliações publicadas
+	RatingAverage *float64 `json:"rating_average"`
+	RatingCount   int      `json:"rating_count"`
 }
 
 type ProductByID struct {
 	ID           int     `json:"id"`
 	SKU          string  `json:"sku"`
 	Name         string  `json:"name"`
+	Description  string  `json:"description"`
 	Price        float64 `json:"price"`
 	UsersId      int     `json:"users_id"`
+	// Vendor dono do produto
+	VendorsID    *int    `json:"vendors_id"`
 	Quantity     int     `json:"quantity"`
 	CategoryId   int     `json:"categories_product_id"`  
 	CategoryName string  `json:"category_name"`
+	Status       string  `json:"status"`
+	ReorderThreshold *int `json:"reorder_threshold"`
+	Attributes   map[string]interface{} `json:"attributes"`
+	// Média e quantidade das avaliações publicadas
+	RatingAverage *float64 `json:"rating_average"`
+	RatingCount   int      `json:"rating_count"`
+	// Produto principal, quando este produto é uma variante
+	ParentID          *int              `json:"parent_id"`
+	VariantAttributes map[string]string `json:"variant_attributes,omitempty"`
+	Variants          []ProductVariant  `json:"variants,omitempty"`
 }
 
 type ProductHome struct {
@@ -53,160 +77,46 @@ type ProductHome struct {
 type ProductCreate struct {
 	SKU        string  `json:"sku"`
 	Name       string  `json:"name"`
+	Description string `json:"description"`
 	Price      string  `json:"price"`
 	UsersId    int     `json:"users_id"`
+	VendorsID  *int    `json:"vendors_id,omitempty"`
 	Quantity   string  `json:"quantity"`
 	CategoryId int     `json:"categories_product_id"`
+	Status     string  `json:"status"`
+	ReorderThreshold *int `json:"reorder_threshold,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
 type ProductCreateRaw struct {
 	SKU        string      `json:"sku"`
 	Name       string      `json:"name"`
+	Description string     `json:"description"`
 	Price      string 	   `json:"price"`
-	UsersId    int         `json:"users_id"`
+	// Vendor dono do produto; opcional quando o usuário é owner ou manager de
+	// um único vendor. O usuário que cadastra vem do cabeçalho X-User-ID.
+	VendorsID  *int        `json:"vendors_id"`
 	Quantity   string       `json:"quantity"`
 	CategoryId int         `json:"categories_product_id"`
+	// Status inicial (draft, published, archived ou out_of_season); padrão published
+	Status     string      `json:"status"`
+	// Estoque mínimo que dispara o alerta de estoque baixo para o vendor
+	ReorderThreshold *int  `json:"reorder_threshold"`
+	Attributes map[string]interface{} `json:"attributes"`
 }
 
 // ProductUpdate representa os dados para atualização parcial
 type ProductUpdate struct {
 	Name       *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
 	Price      *string `json:"price,omitempty"`
 	Quantity   *string `json:"quantity,omitempty"`
 	CategoryId *int    `json:"categories_product_id,omitempty"`
-}
-
-// @Summary Pesquisar produtos
-// @Description Pesquisa produtos por nome, SKU ou categoria com paginação
-// @Tags Products
-// @Param q query string false "Termo de pesquisa (busca em nome, SKU e categoria)"
-// @Param page query int false "Número da página" default(1)
-// @Param limit query int false "Limite de itens por página" default(10)
-// @Success 200 {object} map[string]interface{} "Lista de produtos com informações de paginação"
-// @Failure 500 {object} map[string]string "Erro ao buscar produtos"
-// @Router /products/search [get]
-func SearchProducts(db *sql.DB) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		searchTerm := c.Query("q", "")
-		page := c.QueryInt("page", 1)
-		limit := c.QueryInt("limit", 10)
-		offset := (page - 1) * limit
-
-		// Se não houver termo de pesquisa, retorna todos os produtos
-		var countQuery, productsQuery string
-		var args []interface{}
-
-		if searchTerm == "" {
-			// Conta total de produtos
-			countQuery = "SELECT COUNT(*) FROM products p"
-			
-			productsQuery = `
-				SELECT 
-					p.id, 
-					p.sku, 
-					p.name, 
-					p.price, 
-					p.quantity, 
-					cp.name AS category_name 
-				FROM products p
-				INNER JOIN categories_products cp 
-					ON p.categories_products_id = cp.id
-				ORDER BY p.id DESC
-				LIMIT ? OFFSET ?
-			`
-			args = []interface{}{limit, offset}
-		} else {
-			// Pesquisa com termo
-			searchPattern := "%" + searchTerm + "%"
-			
-			countQuery = `
-				SELECT COUNT(*) 
-				FROM products p
-				INNER JOIN categories_products cp 
-					ON p.categories_products_id = cp.id
-				WHERE p.name LIKE ? 
-					OR p.sku LIKE ? 
-					OR cp.name LIKE ?
-			`
-			
-			productsQuery = `
-				SELECT 
-					p.id, 
-					p.sku, 
-					p.name, 
-					p.price, 
-					p.quantity, 
-					cp.name AS category_name 
-				FROM products p
-				INNER JOIN categories_products cp 
-					ON p.categories_products_id = cp.id
-				WHERE p.name LIKE ? 
-					OR p.sku LIKE ? 
-					OR cp.name LIKE ?
-				ORDER BY p.id DESC
-				LIMIT ? OFFSET ?
-			`
-			args = []interface{}{searchPattern, searchPattern, searchPattern, limit, offset}
-		}
-
-		// Conta o total de produtos
-		var totalCount int
-		var err error
-		
-		if searchTerm == "" {
-			err = db.QueryRow(countQuery).Scan(&totalCount)
-		} else {
-			searchPattern := "%" + searchTerm + "%"
-			err = db.QueryRow(countQuery, searchPattern, searchPattern, searchPattern).Scan(&totalCount)
-		}
-		
-		if err != nil {
-			log.Println("Erro ao contar produtos:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao contar produtos"})
-		}
-
-		// Calcula o total de páginas
-		totalPages := 0
-		if totalCount > 0 {
-			totalPages = (totalCount + limit - 1) / limit
-		}
-
-		// Busca os produtos
-		rows, err := db.Query(productsQuery, args...)
-		if err != nil {
-			log.Println("Erro ao buscar produtos:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar produtos"})
-		}
-		defer rows.Close()
-
-		var products []Product
-		for rows.Next() {
-			var product Product
-			if err := rows.Scan(&product.ID, &product.SKU, &product.Name, &product.Price, &product.Quantity, &product.CategoryName); err != nil {
-				log.Println("Erro ao escanear produto:", err)
-				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler produto"})
-			}
-			products = append(products, product)
-		}
-
-		if err := rows.Err(); err != nil {
-			log.Println("Erro com as linhas:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar produtos"})
-		}
-
-		// Retorna array vazio se não houver produtos
-		if products == nil {
-			products = []Product{}
-		}
-
-		return c.Status(200).JSON(fiber.Map{
-			"products":    products,
-			"currentPage": page,
-			"totalCount":  totalCount,
-			"totalPages":  totalPages,
-			"searchTerm":  searchTerm,
-		})
-	}
+	Status     *string `json:"status,omitempty"`
+	// Zero remove o limite de reposição
+	ReorderThreshold *int `json:"reorder_threshold,omitempty"`
+	// Atributos enviados com valor null são removidos do produto
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
 // @Summary Obter produto por ID
@@ -226,15 +136,22 @@ func GetProductByID(db *sql.DB) fiber.Handler {
 				p.id, 
 				p.sku, 
 				p.name, 
+				COALESCE(p.description, ''),
 				p.price, 
 				p.users_id,
+				p.vendors_id,
 				p.quantity,
 				p.categories_products_id,
+				p.status,
+				p.reorder_threshold,
+				p.parent_id,
+				p.rating_average,
+				p.rating_count,
 				cp.name AS category_name 
 			FROM products p
 			INNER JOIN categories_products cp 
 				ON p.categories_products_id = cp.id
-			WHERE p.id = ?
+			WHERE p.id = ? AND p.deleted_at IS NULL
 		`
 
 		row := db.QueryRow(productQuery, id)
@@ -245,10 +162,17 @@ func GetProductByID(db *sql.DB) fiber.Handler {
 			&product.ID, 
 			&product.SKU, 
 			&product.Name, 
+			&product.Description,
 			&product.Price, 
 			&product.UsersId, 
+			&product.VendorsID,
 			&product.Quantity,
 			&product.CategoryId,  // ADICIONAR ESTA LINHA
+			&product.Status,
+			&product.ReorderThreshold,
+			&product.ParentID,
+			&product.RatingAverage,
+			&product.RatingCount,
 			&product.CategoryName,
 		); err != nil {
 			if err == sql.ErrNoRows {
@@ -258,160 +182,99 @@ func GetProductByID(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar produto"})
 		}
 
+		// Atributos estruturados conforme o esquema da categoria
+		schema, err := effectiveCategoryAttributes(db, product.CategoryId)
+		if err != nil {
+			log.Println("Erro ao buscar esquema de atributos:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar produto"})
+		}
+		storedAttributes, err := loadProductAttributeValues(db, product.ID)
+		if err != nil {
+			log.Println("Erro ao buscar atributos do produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar produto"})
+		}
+		product.Attributes = typedAttributeValues(schema, storedAttributes)
+
+		// Variantes do produto principal ou atributos da própria variante
+		if product.ParentID != nil {
+			variantAttributes, err := loadVariantAttributes(db, product.ID)
+			if err != nil {
+				log.Println("Erro ao buscar atributos da variante:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar produto"})
+			}
+			product.VariantAttributes = variantAttributes[product.ID]
+		} else if product.Variants, err = loadProductVariants(db, product.ID); err != nil {
+			log.Println("Erro ao buscar variantes do produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar produto"})
+		}
+
 		return c.Status(200).JSON(product)
 	}
 }
 
-// @Summary Obter produtos por nome da categoria com paginação
-// @Description Obtém todos os produtos de uma categoria específica pelo nome da categoria com paginação
+// @Summary Obter produtos por nome da categoria
+// @Description Obtém os produtos de uma categoria específica pelo nome da categoria, com paginação por cursor
 // @Tags Products
 // @Param category_name path string true "Nome da Categoria"
-// @Param page query int false "Número da página" default(1)
-// @Param limit query int false "Limite de itens por página" default(10)
-// @Success 200 {array} Product
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
 // @Failure 500 {object} map[string]string "Erro ao buscar produtos"
 // @Router /products/category/{category_name} [get]
 func GetProductsByCategoryName(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		categoryName := c.Params("category_name")
-		page := c.QueryInt("page", 1)
-		limit := c.QueryInt("limit", 10)
-		offset := (page - 1) * limit
-
-		productsQuery := `
-            SELECT 
-                p.id, 
-                p.sku, 
-                p.name, 
-                p.price, 
-                p.quantity, 
-                cp.name AS category_name 
-            FROM products p
-            INNER JOIN categories_products cp 
-                ON p.categories_products_id = cp.id
-            WHERE cp.name = ?
-            LIMIT ? OFFSET ?
-        `
-
-		rows, err := db.Query(productsQuery, categoryName, limit, offset)
-		if err != nil {
-			log.Println("Erro ao buscar produtos:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar produtos"})
-		}
-		defer rows.Close()
-
-		var products []Product
-		for rows.Next() {
-			var product Product
-			if err := rows.Scan(&product.ID, &product.SKU, &product.Name, &product.Price, &product.Quantity, &product.CategoryName); err != nil {
-				log.Println("Erro ao escanear produto:", err)
-				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler produto"})
-			}
-			products = append(products, product)
-		}
-
-		if err := rows.Err(); err != nil {
-			log.Println("Erro com as linhas:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar produtos"})
-		}
-
-		return c.Status(200).JSON(products)
+		return listProducts(db, c, "cp.name = ? AND "+listingProductCondition, categoryName)
 	}
 }
 
-// @Summary Obter produtos por ID da categoria com paginação
-// @Description Obtém todos os produtos de uma categoria específica pelo ID da categoria com paginação
+// @Summary Obter produtos por ID da categoria
+// @Description Obtém os produtos de uma categoria específica pelo ID da categoria, com paginação por cursor
 // @Tags Products
 // @Param category_id path int true "ID da Categoria"
-// @Param page query int false "Número da página" default(1)
-// @Param limit query int false "Limite de itens por página" default(10)
-// @Success 200 {object} map[string]interface{} "Lista de produtos com informações de paginação"
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
 // @Failure 500 {object} map[string]string "Erro ao buscar produtos"
 // @Router /products/category/id/{category_id} [get]
 func GetProductsByCategoryID(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		categoryID := c.Params("category_id")
-		page := c.QueryInt("page", 1)
-		limit := c.QueryInt("limit", 10)
-		offset := (page - 1) * limit
-
-		// Conta o total de produtos na categoria
-		var totalCount int
-		countQuery := `
-			SELECT COUNT(*) 
-			FROM products p
-			WHERE p.categories_products_id = ?
-		`
-		err := db.QueryRow(countQuery, categoryID).Scan(&totalCount)
-		if err != nil {
-			log.Println("Erro ao contar produtos:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao contar produtos"})
-		}
-
-		// Calcula o total de páginas
-		totalPages := (totalCount + limit - 1) / limit
-
-		productsQuery := `
-            SELECT 
-                p.id, 
-                p.sku, 
-                p.name, 
-                p.price, 
-                p.quantity, 
-                cp.name AS category_name 
-            FROM products p
-            INNER JOIN categories_products cp 
-                ON p.categories_products_id = cp.id
-            WHERE cp.id = ?
-            LIMIT ? OFFSET ?
-        `
-
-		rows, err := db.Query(productsQuery, categoryID, limit, offset)
-		if err != nil {
-			log.Println("Erro ao buscar produtos:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar produtos"})
-		}
-		defer rows.Close()
-
-		var products []Product
-		for rows.Next() {
-			var product Product
-			if err := rows.Scan(&product.ID, &product.SKU, &product.Name, &product.Price, &product.Quantity, &product.CategoryName); err != nil {
-				log.Println("Erro ao escanear produto:", err)
-				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler produto"})
-			}
-			products = append(products, product)
-		}
-
-		if err := rows.Err(); err != nil {
-			log.Println("Erro com as linhas:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar produtos"})
-		}
-
-		return c.Status(200).JSON(fiber.Map{
-			"products":    products,
-			"currentPage": page,
-			"totalCount":  totalCount,
-			"totalPages":  totalPages,
-		})
+		return listProducts(db, c, "cp.id = ? AND "+listingProductCondition, categoryID)
 	}
 }
 
 // @Summary Obter todos os produtos em destaque
-// @Description Obtém todos os produtos com imagens em destaque
+// @Description Obtém os produtos com imagens em destaque, com paginação por cursor
 // @Tags Products
-// @Success 200 {array} Product
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
 // @Router /products/home [get]
 func GetAllProductsHome(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		keyset, keysetArgs := params.Keyset("p.id")
+
 		productsQuery := `
-			SELECT p.id, p.sku, p.name, p.price, p.quantity, i.path AS imagePath
+			SELECT p.id, p.sku, p.name, ` + listingPriceExpr + `, ` + listingQuantityExpr + `, i.path AS imagePath
 			FROM products p
 			LEFT JOIN images i ON p.id = i.products_id
-			WHERE i.type = 'featured_image'
+			WHERE i.type = 'featured_image' AND ` + listingProductCondition + ` AND ` + keyset + `
+			ORDER BY p.id DESC
+			LIMIT ?
 		`
 
-		rows, err := db.Query(productsQuery)
+		rows, err := db.Query(productsQuery, append(keysetArgs, params.FetchLimit())...)
 		if err != nil {
 			log.Println("Erro ao buscar produtos:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar produtos"})
@@ -433,105 +296,169 @@ func GetAllProductsHome(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar produtos"})
 		}
 
-		return c.Status(200).JSON(products)
+		page := pagination.NewPage(products, params, func(p ProductHome) int { return p.ID })
+
+		if params.IncludeTotal {
+			var total int
+			err := db.QueryRow(`
+				SELECT COUNT(DISTINCT p.id)
+				FROM products p
+				INNER JOIN images i ON p.id = i.products_id
+				WHERE i.type = 'featured_image' AND ` + listingProductCondition).Scan(&total)
+			if err != nil {
+				log.Println("Erro ao contar produtos:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao contar produtos"})
+			}
+			page = page.WithTotal(total)
+		}
+
+		return c.Status(200).JSON(page)
 	}
 }
 
 // @Summary Obter todos os produtos
-// @Description Obtém todos os produtos
+// @Description Obtém os produtos com paginação por cursor
 // @Tags Products
-// @Success 200 {array} Product
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
 // @Router /products [get]
 func GetAllProducts(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		productsQuery := `
-			SELECT 
-				p.id, 
-				p.sku, 
-				p.name, 
-				p.price, 
-				p.quantity, 
-				cp.name AS category_name 
-			FROM products p
-			INNER JOIN categories_products cp 
-				ON p.categories_products_id = cp.id
-		`
-
-		rows, err := db.Query(productsQuery)
-		if err != nil {
-			log.Println("Erro ao buscar produtos:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar produtos"})
-		}
-		defer rows.Close()
-
-		var products []Product
-		for rows.Next() {
-			var product Product
-			if err := rows.Scan(&product.ID, &product.SKU, &product.Name, &product.Price, &product.Quantity, &product.CategoryName); err != nil {
-				log.Println("Erro ao escanear produto:", err)
-				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler produto"})
-			}
-			products = append(products, product)
-		}
-
-		if err := rows.Err(); err != nil {
-			log.Println("Erro com as linhas:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar produtos"})
-		}
-
-		return c.Status(200).JSON(products)
+		return listProducts(db, c, listingProductCondition)
 	}
 }
 
 // @Summary Obter todos os produtos por ID do usuário
-// @Description Obtém todos os produtos associados a um usuário específico
+// @Description Obtém os produtos cadastrados por um usuário específico em qualquer status, com paginação por cursor. O catálogo completo do vendor está em /vendors/{vendor_id}/products
 // @Tags Products
 // @Param user_id path int true "ID do Usuário"
-// @Success 200 {array} Product
+// @Param status query string false "Filtra por status (draft, published, archived, out_of_season, pending_review, rejected ou deleted para a lixeira)"
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
 // @Failure 500 {object} map[string]string "Erro ao buscar produtos"
 // @Router /products/user/{user_id} [get]
 func GetAllProductsByUserID(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userID := c.Params("user_id")
+		return listProductsByStatus(db, c, "p.users_id = ?", c.Params("user_id"))
+	}
+}
 
-		productsQuery := `
-			SELECT 
-				p.id, 
-				p.sku, 
-				p.name, 
-				p.price, 
-				p.quantity, 
-				cp.name AS category_name 
-			FROM products p
-			INNER JOIN categories_products cp 
-				ON p.categories_products_id = cp.id
-			WHERE p.users_id = ?
-		`
+// @Summary Catálogo do vendor
+// @Description Obtém os produtos do vendor em qualquer status, cadastrados por qualquer membro da equipe, com paginação por cursor
+// @Tags Vendors
+// @Param vendor_id path int true "ID do vendor"
+// @Param status query string false "Filtra por status (draft, published, archived, out_of_season, pending_review, rejected ou deleted para a lixeira)"
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
+// @Failure 500 {object} map[string]string "Erro ao buscar produtos"
+// @Router /vendors/{vendor_id}/products [get]
+func GetVendorProducts(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return listProductsByStatus(db, c, "p.vendors_id = ?", c.Params("vendor_id"))
+	}
+}
 
-		rows, err := db.Query(productsQuery, userID)
-		if err != nil {
-			log.Println("Erro ao buscar produtos:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar produtos"})
-		}
-		defer rows.Close()
+// listProductsByStatus lista os produtos do dono informado, filtrando pelo
+// status da query (deleted lista a lixeira)
+func listProductsByStatus(db *sql.DB, c *fiber.Ctx, ownerCondition string, ownerID interface{}) error {
+	switch status := c.Query("status"); {
+	case status == "":
+		return listProducts(db, c, ownerCondition+" AND p.deleted_at IS NULL", ownerID)
+	case status == productStatusDeleted:
+		return listProducts(db, c, ownerCondition+" AND p.deleted_at IS NOT NULL", ownerID)
+	case validProductListStatus(status):
+		return listProducts(db, c, ownerCondition+" AND p.deleted_at IS NULL AND p.status = ?", ownerID, status)
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Status inválido. Use: draft, published, archived, out_of_season, pending_review, rejected ou deleted"})
+	}
+}
 
-		var products []Product
-		for rows.Next() {
-			var product Product
-			if err := rows.Scan(&product.ID, &product.SKU, &product.Name, &product.Price, &product.Quantity, &product.CategoryName); err != nil {
-				log.Println("Erro ao escanear produto:", err)
-				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler produto"})
-			}
-			products = append(products, product)
-		}
+// listProducts responde com uma página de produtos que atendem à condição
+func listProducts(db *sql.DB, c *fiber.Ctx, condition string, args ...interface{}) error {
+	params, err := pagination.FromRequest(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 
-		if err := rows.Err(); err != nil {
-			log.Println("Erro com as linhas:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar produtos"})
+	page, err := fetchProductPage(db, params, condition, args...)
+	if err != nil {
+		return respondError(c, err, "Erro ao buscar produtos:", "Erro ao buscar produtos")
+	}
+
+	return c.Status(200).JSON(page)
+}
+
+// fetchProductPage busca a página de produtos que atendem à condição
+func fetchProductPage(db *sql.DB, params pagination.Params, condition string, args ...interface{}) (pagination.Page[Product], error) {
+	keyset, keysetArgs := params.Keyset("p.id")
+
+	productsQuery := `
+		SELECT 
+			p.id, 
+			p.sku, 
+			p.name, 
+			` + listingPriceExpr + `, 
+			` + listingQuantityExpr + `, 
+			cp.name AS category_name,
+			p.status,
+			` + productHasVariantsCondition + `,
+			p.rating_average,
+			p.rating_count
+		FROM products p
+		INNER JOIN categories_products cp 
+			ON p.categories_products_id = cp.id
+		WHERE ` + condition + ` AND ` + keyset + `
+		ORDER BY p.id DESC
+		LIMIT ?
+	`
+
+	queryArgs := append(append(append([]interface{}{}, args...), keysetArgs...), params.FetchLimit())
+	rows, err := db.Query(productsQuery, queryArgs...)
+	if err != nil {
+		return pagination.Page[Product]{}, err
+	}
+	defer rows.Close()
+
+	var products []Product
+	for rows.Next() {
+		var product Product
+		if err := rows.Scan(&product.ID, &product.SKU, &product.Name, &product.Price, &product.Quantity, &product.CategoryName, &product.Status, &product.HasVariants,
+			&product.RatingAverage, &product.RatingCount); err != nil {
+			return pagination.Page[Product]{}, err
 		}
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return pagination.Page[Product]{}, err
+	}
+
+	page := pagination.NewPage(products, params, func(p Product) int { return p.ID })
 
-		return c.Status(200).JSON(products)
+	if params.IncludeTotal {
+		var total int
+		countQuery := `
+			SELECT COUNT(*)
+			FROM products p
+			INNER JOIN categories_products cp
+				ON p.categories_products_id = cp.id
+			WHERE ` + condition
+		if err := db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+			return pagination.Page[Product]{}, err
+		}
+		page = page.WithTotal(total)
 	}
+
+	return page, nil
 }
 
 // @Summary Obter produto por SKU
@@ -546,9 +473,9 @@ func GetProductBySKU(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		sku := c.Params("sku")
 
-		row := db.QueryRow("SELECT * FROM products WHERE sku = ?", sku)
+		row := db.QueryRow("SELECT id, sku, name, price, users_id, vendors_id, quantity, categories_products_id, status FROM products WHERE sku = ? AND deleted_at IS NULL", sku)
 		var product ProductBySKU
-		if err := row.Scan(&product.ID, &product.SKU, &product.Name, &product.Price, &product.UsersId, &product.Quantity, &product.CategoryId); err != nil {
+		if err := row.Scan(&product.ID, &product.SKU, &product.Name, &product.Price, &product.UsersId, &product.VendorsID, &product.Quantity, &product.CategoryId, &product.Status); err != nil {
 			if err == sql.ErrNoRows {
 				return c.Status(404).JSON(fiber.Map{"message": "Produto não encontrado"})
 			}
@@ -561,17 +488,26 @@ func GetProductBySKU(db *sql.DB) fiber.Handler {
 }
 
 // @Summary Criar um novo produto
-// @Description Cria um novo produto no banco de dados
+// @Description Cria um novo produto no banco de dados em nome do usuário do cabeçalho X-User-ID. O produto pertence ao vendor informado em vendors_id, de que o usuário precisa ser owner ou manager; sem vendors_id vale o único vendor em que o usuário tem esses papéis
 // @Tags Products
 // @Accept json
 // @Produce json
-// @Param product body ProductCreate true "Dados do produto"
+// @Param X-User-ID header int true "ID do usuário"
+// @Param product body ProductCreateRaw true "Dados do produto"
 // @Success 200 {object} map[string]interface{} "Produto criado com sucesso"
+// @Success 202 {object} map[string]interface{} "Produto criado e aguardando moderação"
 // @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Usuário sem papel no vendor"
 // @Failure 500 {object} map[string]string "Erro ao criar produto"
 // @Router /products [post]
 func CreateProduct(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		userID, ok := callerUserID(c)
+		if !ok {
+			return c.Status(401).JSON(fiber.Map{"error": "Usuário não identificado"})
+		}
+
 		var productRaw ProductCreateRaw
 		
 		// Parse do body da requisição
@@ -585,86 +521,245 @@ func CreateProduct(db *sql.DB) fiber.Handler {
 		product := ProductCreate{
 			SKU:        productRaw.SKU,
 			Name:       productRaw.Name,
+			Description: productRaw.Description,
 			Price:      productRaw.Price,
-			UsersId:    productRaw.UsersId,
+			UsersId:    userID,
+			VendorsID:  productRaw.VendorsID,
 			Quantity:   productRaw.Quantity,
 			CategoryId: productRaw.CategoryId,
+			Status:     productRaw.Status,
+			ReorderThreshold: productRaw.ReorderThreshold,
+			Attributes: productRaw.Attributes,
 		}
 
-		// Validações básicas
-		if product.SKU == "" || product.Name == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "SKU e Nome são obrigatórios"})
-		}
-
-		if product.Price == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "Preço não pode ser vazio"})
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar produto"})
 		}
+		defer tx.Rollback()
 
-		if product.Quantity == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "Quantidade não pode ser negativa"})
+		// O produto só pode ser cadastrado em nome de um vendor de cuja equipe o
+		// usuário é owner ou manager
+		if product.VendorsID != nil {
+			allowed, err := canManageProduct(tx, product.VendorsID, userID, userID)
+			if err != nil {
+				log.Println("Erro ao verificar equipe do vendor:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar produto"})
+			}
+			if !allowed {
+				return c.Status(403).JSON(fiber.Map{"error": "O usuário precisa ser owner ou manager do vendor para cadastrar produtos"})
+			}
 		}
 
-		// Verifica se o SKU já existe
-		var exists int
-		checkQuery := "SELECT COUNT(*) FROM products WHERE sku = ?"
-		err := db.QueryRow(checkQuery, product.SKU).Scan(&exists)
+		productID, moderationID, err := createProduct(tx, &product, "Estoque inicial")
 		if err != nil {
-			log.Println("Erro ao verificar SKU:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar produto"})
-		}
-
-		if exists > 0 {
-			return c.Status(400).JSON(fiber.Map{"error": "Produto com este SKU já existe"})
+			return respondError(c, err, "Erro ao criar produto:", "Erro ao criar produto")
 		}
 
-		// Insere o produto no banco de dados
-		insertQuery := `
-			INSERT INTO products (sku, name, price, users_id, quantity, categories_products_id)
-			VALUES (?, ?, ?, ?, ?, ?)
-		`
-
-		result, err := db.Exec(insertQuery, 
-			product.SKU, 
-			product.Name, 
-			product.Price, 
-			product.UsersId, 
-			product.Quantity, 
-			product.CategoryId,
-		)
-
-		if err != nil {
-			log.Println("Erro ao inserir produto:", err)
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar produto"})
 		}
 
-		// Obtém o ID do produto inserido
-		productID, err := result.LastInsertId()
-		if err != nil {
-			log.Println("Erro ao obter ID do produto:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao obter ID do produto"})
-		}
+		reindexProduct(db, productID)
 
-		// Retorna o produto criado com sucesso
-		return c.Status(200).JSON(fiber.Map{
+		response := fiber.Map{
 			"message": "Produto criado com sucesso",
 			"product": fiber.Map{
 				"id":                      productID,
 				"sku":                     product.SKU,
 				"name":                    product.Name,
+				"description":             product.Description,
 				"price":                   product.Price,
 				"users_id":                product.UsersId,
+				"vendors_id":              product.VendorsID,
 				"quantity":                product.Quantity,
 				"categories_product_id":   product.CategoryId,
+				"status":                  product.Status,
+				"attributes":              product.Attributes,
 			},
-		})
+		}
+
+		if moderationID != 0 {
+			response["message"] = "Produto criado e enviado para moderação"
+			response["moderation_id"] = moderationID
+			return c.Status(202).JSON(response)
+		}
+
+		// Retorna o produto criado com sucesso
+		return c.Status(200).JSON(response)
 	}
 }
 
+// productVendorForUser define o vendor dos produtos cadastrados sem vendors_id:
+// o único vendor de que o usuário é owner ou manager. Todo produto pertence a
+// um vendor, então usuários sem vendor não cadastram produtos.
+func productVendorForUser(q sqlQueryer, userID int) (*int, error) {
+	vendorIDs, err := scanIDs(q.Query("SELECT vendors_id FROM vendor_members WHERE users_id = ? AND role IN (?, ?)",
+		userID, MemberOwner, MemberManager))
+	if err != nil {
+		return nil, err
+	}
+	switch len(vendorIDs) {
+	case 0:
+		return nil, &requestError{400, "O usuário não é owner nem manager de nenhum vendor; cadastre um vendor antes dos produtos"}
+	case 1:
+		return &vendorIDs[0], nil
+	}
+	return nil, &requestError{400, "O usuário faz parte de mais de um vendor; informe vendors_id"}
+}
+
+// createProduct valida e grava um novo produto na transação informada,
+// registrando o estoque inicial no livro-razão com o motivo informado. Em
+// categorias moderadas o produto fica pendente de revisão e o ID da revisão
+// aberta é devolvido em moderationID. Erros de validação são *requestError.
+func createProduct(tx sqlQueryer, product *ProductCreate, stockReason string) (productID int64, moderationID int64, err error) {
+	if product.VendorsID == nil {
+		if product.VendorsID, err = productVendorForUser(tx, product.UsersId); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if product.Status == "" {
+		product.Status = ProductStatusPublished
+		// Vendors com cadastro ainda não aprovado montam o catálogo como rascunho
+		approved, err := vendorApproved(tx, product.VendorsID)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !approved {
+			product.Status = ProductStatusDraft
+		}
+	}
+	if !validProductStatus(product.Status) {
+		return 0, 0, &requestError{400, "Status inválido. Use: draft, published, archived ou out_of_season"}
+	}
+	if product.Status == ProductStatusPublished {
+		if err := ensureVendorApproved(tx, product.VendorsID); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	// Validações básicas
+	if product.SKU == "" || product.Name == "" {
+		return 0, 0, &requestError{400, "SKU e Nome são obrigatórios"}
+	}
+
+	if product.Price == "" {
+		return 0, 0, &requestError{400, "Preço não pode ser vazio"}
+	}
+	if price, err := strconv.ParseFloat(product.Price, 64); err != nil || price < 0 {
+		return 0, 0, &requestError{400, "Preço inválido"}
+	}
+
+	if product.Quantity == "" {
+		return 0, 0, &requestError{400, "Quantidade não pode ser negativa"}
+	}
+
+	initialStock, err := strconv.Atoi(product.Quantity)
+	if err != nil || initialStock < 0 {
+		return 0, 0, &requestError{400, "Quantidade inválida"}
+	}
+
+	if product.ReorderThreshold != nil && *product.ReorderThreshold < 0 {
+		return 0, 0, &requestError{400, "Limite de reposição inválido"}
+	}
+
+	// Verifica se o SKU já existe
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM products WHERE sku = ?", product.SKU).Scan(&exists); err != nil {
+		return 0, 0, err
+	}
+	if exists > 0 {
+		return 0, 0, &requestError{400, "Produto com este SKU já existe"}
+	}
+
+	var categoryExists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM categories_products WHERE id = ?", product.CategoryId).Scan(&categoryExists); err != nil {
+		return 0, 0, err
+	}
+	if categoryExists == 0 {
+		return 0, 0, &requestError{400, "Categoria inválida"}
+	}
+
+	// Valida os atributos contra o esquema da categoria
+	schema, err := effectiveCategoryAttributes(tx, product.CategoryId)
+	if err != nil {
+		return 0, 0, err
+	}
+	attributes, err := validateProductAttributes(schema, product.Attributes, true)
+	if err != nil {
+		return 0, 0, &requestError{400, err.Error()}
+	}
+
+	// Em categorias moderadas o produto só recebe o status pedido após aprovação
+	requiresModeration, err := productRequiresModeration(tx, product.CategoryId)
+	if err != nil {
+		return 0, 0, err
+	}
+	requestedStatus := product.Status
+	if requiresModeration {
+		product.Status = ProductStatusPendingReview
+	}
+
+	// Insere o produto no banco de dados
+	result, err := tx.Exec(`
+		INSERT INTO products (sku, name, description, price, users_id, vendors_id, quantity, categories_products_id, status, reorder_threshold)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		product.SKU,
+		product.Name,
+		product.Description,
+		product.Price,
+		product.UsersId,
+		product.VendorsID,
+		initialStock,
+		product.CategoryId,
+		product.Status,
+		reorderThresholdValue(product.ReorderThreshold),
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	productID, err = result.LastInsertId()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := saveProductAttributes(tx, productID, attributes); err != nil {
+		return 0, 0, err
+	}
+
+	if initialStock > 0 {
+		err = logInventoryMovement(tx, productID, MovementReceipt, initialStock, initialStock, int64(product.UsersId), 0, stockReason)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if err := logPriceChange(tx, productID, nil, product.Price, int64(product.UsersId), 0, "Preço inicial"); err != nil {
+		return 0, 0, err
+	}
+
+	if requiresModeration {
+		moderationID, err = submitProductModeration(tx, productID, product.UsersId, ModerationActionCreate, moderationCreatePayload{Status: requestedStatus})
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return productID, moderationID, nil
+}
+
 // @Summary Excluir produto por ID
-// @Description Exclui um produto com base no ID
+// @Description Exclui logicamente um produto com base no ID. O produto sai das vitrines e dos carrinhos, mas continua referenciado pelos pedidos e pode ser restaurado. Restrito a owners e managers do vendor do produto e a administradores
 // @Tags Products
+// @Param X-User-ID header int true "ID do usuário"
 // @Param id path int true "ID do produto"
 // @Success 200 {object} map[string]string "Produto excluído com sucesso"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Produto pertence a outro vendor"
 // @Failure 404 {object} map[string]string "Produto não encontrado"
 // @Failure 500 {object} map[string]string "Erro ao excluir produto"
 // @Router /products/id/{id} [delete]
@@ -674,7 +769,7 @@ func DeleteProductByID(db *sql.DB) fiber.Handler {
 
 		// Verifica se o produto existe
 		var exists int
-		checkQuery := "SELECT COUNT(*) FROM products WHERE id = ?"
+		checkQuery := "SELECT COUNT(*) FROM products WHERE id = ? AND deleted_at IS NULL"
 		err := db.QueryRow(checkQuery, id).Scan(&exists)
 		if err != nil {
 			log.Println("Erro ao verificar produto:", err)
@@ -685,14 +780,42 @@ func DeleteProductByID(db *sql.DB) fiber.Handler {
 			return c.Status(404).JSON(fiber.Map{"message": "Produto não encontrado"})
 		}
 
-		// Exclui o produto
-		deleteQuery := "DELETE FROM products WHERE id = ?"
-		_, err = db.Exec(deleteQuery, id)
+		if _, err := authorizeProductManager(db, c, id); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões:", "Erro ao excluir produto")
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao excluir produto"})
+		}
+		defer tx.Rollback()
+
+		// Exclusão lógica: o histórico de pedidos continua apontando para o
+		// produto. As variantes do produto principal saem junto.
+		_, err = tx.Exec("UPDATE products SET deleted_at = NOW() WHERE (id = ? OR parent_id = ?) AND deleted_at IS NULL", id, id)
 		if err != nil {
 			log.Println("Erro ao excluir produto:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao excluir produto"})
 		}
 
+		// O produto deixa de poder ser comprado
+		if err := releaseReservations(tx, "r.products_id IN (SELECT id FROM products WHERE id = ? OR parent_id = ?)", id, id); err != nil {
+			log.Println("Erro ao liberar reservas do produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao excluir produto"})
+		}
+		if _, err := tx.Exec("DELETE FROM cart_items WHERE products_id IN (SELECT id FROM products WHERE id = ? OR parent_id = ?)", id, id); err != nil {
+			log.Println("Erro ao remover produto dos carrinhos:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao excluir produto"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao excluir produto"})
+		}
+
+		reindexProduct(db, id)
+
 		return c.Status(200).JSON(fiber.Map{
 			"message": "Produto excluído com sucesso",
 			"id":      id,
@@ -700,114 +823,310 @@ func DeleteProductByID(db *sql.DB) fiber.Handler {
 	}
 }
 
-// @Summary Atualizar produto por ID (parcial)
-// @Description Atualiza parcialmente os dados de um produto existente. Envie apenas os campos que deseja atualizar.
-// @Tags Products
-// @Accept json
-// @Produce json
-// @Param id path int true "ID do produto"
-// @Param product body ProductUpdate true "Dados do produto para atualização (campos opcionais)"
-// @Success 200 {object} map[string]interface{} "Produto atualizado com sucesso"
-// @Failure 400 {object} map[string]string "Dados inválidos"
-// @Failure 404 {object} map[string]string "Produto não encontrado"
-// @Failure 500 {object} map[string]string "Erro ao atualizar produto"
-// @Router /products/id/{id} [patch]
-func UpdateProductByID(db *sql.DB) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		id := c.Params("id")
+// requestError é um erro de validação que deve ser devolvido ao cliente com o
+// status HTTP informado
+type requestError struct {
+	status  int
+	message string
+}
 
-		var productUpdate ProductUpdate
-		
-		// Parse do body da requisição
-		if err := c.BodyParser(&productUpdate); err != nil {
-			log.Println("Erro ao fazer parse do body:", err)
-			return c.Status(400).JSON(fiber.Map{"error": "Dados inválidos"})
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// respondError devolve um requestError ao cliente ou registra o erro inesperado
+// e responde com a mensagem genérica
+func respondError(c *fiber.Ctx, err error, logMessage, genericMessage string) error {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		// Recursos não encontrados seguem o formato das demais respostas 404
+		if reqErr.status == 404 {
+			return c.Status(404).JSON(fiber.Map{"message": reqErr.message})
 		}
+		return c.Status(reqErr.status).JSON(fiber.Map{"error": reqErr.message})
+	}
+	log.Println(logMessage, err)
+	return c.Status(500).JSON(fiber.Map{"error": genericMessage})
+}
 
-		// Verifica se pelo menos um campo foi enviado
-		if productUpdate.Name == nil && productUpdate.Price == nil && 
-		   productUpdate.Quantity == nil && productUpdate.CategoryId == nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Nenhum campo para atualizar foi fornecido"})
+// empty indica se nenhum campo foi enviado na atualização
+func (u ProductUpdate) empty() bool {
+	return u.Name == nil && u.Price == nil && u.Quantity == nil && u.CategoryId == nil &&
+		u.Description == nil && u.Status == nil && u.ReorderThreshold == nil && u.Attributes == nil
+}
+
+// hasContentChanges indica se a atualização altera o conteúdo revisado na
+// moderação (nome, descrição, categoria ou atributos)
+func (u ProductUpdate) hasContentChanges() bool {
+	return u.Name != nil || u.Description != nil || u.CategoryId != nil || u.Attributes != nil
+}
+
+// splitForModeration separa as alterações de conteúdo, que aguardam revisão,
+// das operacionais (preço, estoque, status e limite de reposição), aplicadas
+// imediatamente
+func (u ProductUpdate) splitForModeration() (content ProductUpdate, operational ProductUpdate) {
+	content = ProductUpdate{Name: u.Name, Description: u.Description, CategoryId: u.CategoryId, Attributes: u.Attributes}
+	operational = ProductUpdate{Price: u.Price, Quantity: u.Quantity, Status: u.Status, ReorderThreshold: u.ReorderThreshold}
+	return content, operational
+}
+
+// productUpdatePlan é uma atualização de produto já validada
+type productUpdatePlan struct {
+	updates    []string
+	args       []interface{}
+	price      *string
+	quantity   *int
+	categoryID *int
+	attributes map[string]attributeValue
+}
+
+// planProductUpdate valida a atualização parcial de um produto e monta as
+// alterações a aplicar. Erros de validação são devolvidos como *requestError.
+func planProductUpdate(q sqlQueryer, id interface{}, currentCategoryID int, productUpdate ProductUpdate) (*productUpdatePlan, error) {
+	// Validações dos campos enviados
+	if productUpdate.Name != nil && *productUpdate.Name == "" {
+		return nil, &requestError{400, "Nome não pode ser vazio"}
+	}
+
+	if productUpdate.Price != nil && *productUpdate.Price == "" {
+		return nil, &requestError{400, "Preço não pode ser vazio"}
+	}
+
+	if productUpdate.Quantity != nil && *productUpdate.Quantity == "" {
+		return nil, &requestError{400, "Quantidade não pode ser vazia"}
+	}
+
+	plan := &productUpdatePlan{}
+
+	if productUpdate.Price != nil {
+		if price, err := strconv.ParseFloat(*productUpdate.Price, 64); err != nil || price < 0 {
+			return nil, &requestError{400, "Preço inválido"}
+		}
+	}
+
+	if productUpdate.Quantity != nil {
+		quantity, err := strconv.Atoi(*productUpdate.Quantity)
+		if err != nil || quantity < 0 {
+			return nil, &requestError{400, "Quantidade inválida"}
+		}
+		plan.quantity = &quantity
+	}
+
+	if productUpdate.Status != nil && !validProductStatus(*productUpdate.Status) {
+		return nil, &requestError{400, "Status inválido. Use: draft, published, archived ou out_of_season"}
+	}
+
+	// Só vendors com cadastro aprovado publicam produtos
+	if productUpdate.Status != nil && *productUpdate.Status == ProductStatusPublished {
+		var vendorID *int
+		if err := q.QueryRow("SELECT vendors_id FROM products WHERE id = ?", id).Scan(&vendorID); err != nil {
+			return nil, err
+		}
+		if err := ensureVendorApproved(q, vendorID); err != nil {
+			return nil, err
+		}
+	}
+
+	if productUpdate.ReorderThreshold != nil && *productUpdate.ReorderThreshold < 0 {
+		return nil, &requestError{400, "Limite de reposição inválido"}
+	}
+
+	// Verifica se a categoria existe (se foi enviada)
+	if productUpdate.CategoryId != nil {
+		// A categoria das variantes acompanha a do produto principal
+		var isVariant bool
+		if err := q.QueryRow("SELECT parent_id IS NOT NULL FROM products WHERE id = ?", id).Scan(&isVariant); err != nil {
+			return nil, err
+		}
+		if isVariant && *productUpdate.CategoryId != currentCategoryID {
+			return nil, &requestError{400, "A categoria da variante segue a do produto principal"}
 		}
 
-		// Validações dos campos enviados
-		if productUpdate.Name != nil && *productUpdate.Name == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "Nome não pode ser vazio"})
+		var categoryExists int
+		checkCategoryQuery := "SELECT COUNT(*) FROM categories_products WHERE id = ?"
+		if err := q.QueryRow(checkCategoryQuery, *productUpdate.CategoryId).Scan(&categoryExists); err != nil {
+			return nil, err
 		}
 
-		if productUpdate.Price != nil && *productUpdate.Price == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "Preço não pode ser vazio"})
+		if categoryExists == 0 {
+			return nil, &requestError{400, "Categoria inválida"}
 		}
+	}
 
-		if productUpdate.Quantity != nil && *productUpdate.Quantity == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "Quantidade não pode ser vazia"})
+	// Revalida os atributos quando eles ou a categoria mudam
+	if productUpdate.Attributes != nil || productUpdate.CategoryId != nil {
+		targetCategoryID := currentCategoryID
+		if productUpdate.CategoryId != nil {
+			targetCategoryID = *productUpdate.CategoryId
 		}
 
-		// Verifica se o produto existe
-		var exists int
-		checkProductQuery := "SELECT COUNT(*) FROM products WHERE id = ?"
-		err := db.QueryRow(checkProductQuery, id).Scan(&exists)
+		schema, err := effectiveCategoryAttributes(q, targetCategoryID)
 		if err != nil {
-			log.Println("Erro ao verificar produto:", err)
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar produto"})
+			return nil, err
 		}
 
-		if exists == 0 {
-			return c.Status(404).JSON(fiber.Map{"message": "Produto não encontrado"})
+		merged, err := loadProductAttributeValues(q, id)
+		if err != nil {
+			return nil, err
 		}
 
-		// Verifica se a categoria existe (se foi enviada)
-		if productUpdate.CategoryId != nil {
-			var categoryExists int
-			checkCategoryQuery := "SELECT COUNT(*) FROM categories_products WHERE id = ?"
-			err = db.QueryRow(checkCategoryQuery, *productUpdate.CategoryId).Scan(&categoryExists)
-			if err != nil {
-				log.Println("Erro ao verificar categoria:", err)
-				return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar categoria"})
+		// Atributos que não existem no esquema da nova categoria deixam de valer
+		inSchema := make(map[string]bool, len(schema))
+		for _, attribute := range schema {
+			inSchema[attribute.Name] = true
+		}
+		for name := range merged {
+			if !inSchema[name] {
+				delete(merged, name)
 			}
+		}
 
-			if categoryExists == 0 {
-				return c.Status(400).JSON(fiber.Map{"error": "Categoria inválida"})
+		for name, value := range productUpdate.Attributes {
+			if value == nil {
+				delete(merged, name)
+			} else {
+				merged[name] = value
 			}
 		}
 
-		// Constrói a query de atualização dinâmica
-		var updates []string
-		var args []interface{}
+		plan.attributes, err = validateProductAttributes(schema, merged, true)
+		if err != nil {
+			return nil, &requestError{400, err.Error()}
+		}
+	}
+
+	// Constrói a query de atualização dinâmica
+	if productUpdate.Name != nil {
+		plan.updates = append(plan.updates, "name = ?")
+		plan.args = append(plan.args, *productUpdate.Name)
+	}
+
+	if productUpdate.Description != nil {
+		plan.updates = append(plan.updates, "description = ?")
+		plan.args = append(plan.args, *productUpdate.Description)
+	}
+
+	if productUpdate.Price != nil {
+		plan.price = productUpdate.Price
+	}
+
+	if productUpdate.CategoryId != nil {
+		plan.updates = append(plan.updates, "categories_products_id = ?")
+		plan.args = append(plan.args, *productUpdate.CategoryId)
+		plan.categoryID = productUpdate.CategoryId
+	}
+
+	if productUpdate.Status != nil {
+		plan.updates = append(plan.updates, "status = ?")
+		plan.args = append(plan.args, *productUpdate.Status)
+	}
+
+	if productUpdate.ReorderThreshold != nil {
+		plan.updates = append(plan.updates, "reorder_threshold = ?")
+		plan.args = append(plan.args, reorderThresholdValue(productUpdate.ReorderThreshold))
+	}
+
+	return plan, nil
+}
+
+// apply grava a atualização dentro da transação informada. Mudanças de
+// estoque entram no livro-razão como ajuste feito por actorID e mudanças de
+// preço, no histórico de preços.
+func (plan *productUpdatePlan) apply(tx sqlQueryer, id interface{}, actorID int64) error {
+	if len(plan.updates) > 0 {
+		updateQuery := "UPDATE products SET " + strings.Join(plan.updates, ", ") + " WHERE id = ?"
+		if _, err := tx.Exec(updateQuery, append(append([]interface{}{}, plan.args...), id)...); err != nil {
+			return err
+		}
+	}
+
+	if plan.categoryID != nil {
+		if _, err := tx.Exec("UPDATE products SET categories_products_id = ? WHERE parent_id = ?", *plan.categoryID, id); err != nil {
+			return err
+		}
+	}
 
-		if productUpdate.Name != nil {
-			updates = append(updates, "name = ?")
-			args = append(args, *productUpdate.Name)
+	if plan.price != nil {
+		if err := setProductPrice(tx, id, *plan.price, actorID, 0, "Edição do produto"); err != nil {
+			return err
 		}
+	}
 
-		if productUpdate.Price != nil {
-			updates = append(updates, "price = ?")
-			args = append(args, *productUpdate.Price)
+	if plan.quantity != nil {
+		if err := setStockLevel(tx, id, *plan.quantity, actorID, "Ajuste na edição do produto"); err != nil {
+			return err
 		}
+	}
 
-		if productUpdate.Quantity != nil {
-			updates = append(updates, "quantity = ?")
-			args = append(args, *productUpdate.Quantity)
+	if plan.attributes != nil {
+		if _, err := tx.Exec("DELETE FROM product_attributes WHERE products_id = ?", id); err != nil {
+			return err
+		}
+		if err := saveProductAttributes(tx, id, plan.attributes); err != nil {
+			return err
 		}
+	}
 
-		if productUpdate.CategoryId != nil {
-			updates = append(updates, "categories_products_id = ?")
-			args = append(args, *productUpdate.CategoryId)
+	return nil
+}
+
+// @Summary Atualizar produto por ID (parcial)
+// @Description Atualiza parcialmente os dados de um produto existente. Envie apenas os campos que deseja atualizar. Em categorias com moderação, alterações de nome, descrição, categoria e atributos aguardam aprovação (resposta 202); preço, estoque e status são aplicados imediatamente. Restrito a owners e managers do vendor do produto e a administradores.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do produto"
+// @Param product body ProductUpdate true "Dados do produto para atualização (campos opcionais)"
+// @Success 200 {object} map[string]interface{} "Produto atualizado com sucesso"
+// @Success 202 {object} map[string]interface{} "Alterações enviadas para moderação"
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Produto pertence a outro vendor"
+// @Failure 404 {object} map[string]string "Produto não encontrado"
+// @Failure 409 {object} map[string]string "Produto aguardando moderação"
+// @Failure 500 {object} map[string]string "Erro ao atualizar produto"
+// @Router /products/id/{id} [patch]
+func UpdateProductByID(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+
+		var productUpdate ProductUpdate
+		
+		// Parse do body da requisição
+		if err := c.BodyParser(&productUpdate); err != nil {
+			log.Println("Erro ao fazer parse do body:", err)
+			return c.Status(400).JSON(fiber.Map{"error": "Dados inválidos"})
 		}
 
-		// Adiciona o ID ao final dos argumentos
-		args = append(args, id)
+		// Verifica se pelo menos um campo foi enviado
+		if productUpdate.empty() {
+			return c.Status(400).JSON(fiber.Map{"error": "Nenhum campo para atualizar foi fornecido"})
+		}
 
-		// Monta a query final
-		updateQuery := "UPDATE products SET " + strings.Join(updates, ", ") + " WHERE id = ?"
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar produto"})
+		}
+		defer tx.Rollback()
 
-		// Executa a atualização
-		_, err = db.Exec(updateQuery, args...)
+		submitter, err := authorizeProductManager(tx, c, id)
 		if err != nil {
-			log.Println("Erro ao atualizar produto:", err)
+			return respondError(c, err, "Erro ao verificar permissões:", "Erro ao atualizar produto")
+		}
+		moderationID, err := updateProduct(tx, id, productUpdate, submitter)
+		if err != nil {
+			return respondError(c, err, "Erro ao atualizar produto:", "Erro ao atualizar produto")
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar produto"})
 		}
 
+		reindexProduct(db, id)
+
 		// Busca o produto atualizado para retornar
 		productQuery := `
 			SELECT 
@@ -816,7 +1135,8 @@ func UpdateProductByID(db *sql.DB) fiber.Handler {
 				p.name, 
 				p.price, 
 				p.quantity, 
-				cp.name AS category_name 
+				cp.name AS category_name,
+				p.status
 			FROM products p
 			INNER JOIN categories_products cp 
 				ON p.categories_products_id = cp.id
@@ -825,7 +1145,7 @@ func UpdateProductByID(db *sql.DB) fiber.Handler {
 
 		row := db.QueryRow(productQuery, id)
 		var product Product
-		if err := row.Scan(&product.ID, &product.SKU, &product.Name, &product.Price, &product.Quantity, &product.CategoryName); err != nil {
+		if err := row.Scan(&product.ID, &product.SKU, &product.Name, &product.Price, &product.Quantity, &product.CategoryName, &product.Status); err != nil {
 			log.Println("Erro ao buscar produto atualizado:", err)
 			// Retorna sucesso mesmo sem buscar o produto atualizado
 			return c.Status(200).JSON(fiber.Map{
@@ -834,10 +1154,95 @@ func UpdateProductByID(db *sql.DB) fiber.Handler {
 			})
 		}
 
+		if moderationID != 0 {
+			return c.Status(202).JSON(fiber.Map{
+				"message":       "Alterações enviadas para moderação",
+				"moderation_id": moderationID,
+				"product":       product,
+			})
+		}
+
 		// Retorna o produto atualizado com sucesso
 		return c.Status(200).JSON(fiber.Map{
 			"message": "Produto atualizado com sucesso",
 			"product": product,
 		})
 	}
-}
\ No newline at end of file
+}
+
+// updateProduct aplica a atualização parcial do produto na transação
+// informada. Em categorias moderadas as alterações de conteúdo são enviadas
+// para revisão e o ID da revisão é devolvido em moderationID; preço, estoque
+// e status são aplicados imediatamente. Erros de validação são *requestError.
+func updateProduct(tx sqlQueryer, id interface{}, productUpdate ProductUpdate, submitter int) (moderationID int64, err error) {
+	// Verifica se o produto existe
+	var currentCategoryID int
+	var currentStatus string
+	checkProductQuery := "SELECT categories_products_id, status FROM products WHERE id = ? AND deleted_at IS NULL FOR UPDATE"
+	err = tx.QueryRow(checkProductQuery, id).Scan(&currentCategoryID, &currentStatus)
+	if err == sql.ErrNoRows {
+		return 0, &requestError{404, "Produto não encontrado"}
+	} else if err != nil {
+		return 0, err
+	}
+
+	// Moderação vale tanto para a categoria atual quanto para a de destino
+	moderatedCategories := []int{currentCategoryID}
+	if productUpdate.CategoryId != nil {
+		moderatedCategories = append(moderatedCategories, *productUpdate.CategoryId)
+	}
+	requiresModeration, err := productRequiresModeration(tx, moderatedCategories...)
+	if err != nil {
+		return 0, err
+	}
+
+	underReview := currentStatus == ProductStatusPendingReview || currentStatus == ProductStatusRejected
+	if currentStatus == ProductStatusPendingReview && productUpdate.Status != nil {
+		return 0, &requestError{409, "Produto aguardando moderação; o status será definido na aprovação"}
+	}
+
+	// Separa o que aguarda revisão do que é aplicado imediatamente. Produtos
+	// ainda não aprovados são revisados por inteiro na aprovação do cadastro.
+	directUpdate := productUpdate
+	var moderatedUpdate *ProductUpdate
+	if requiresModeration && !underReview && productUpdate.hasContentChanges() {
+		content, operational := productUpdate.splitForModeration()
+		moderatedUpdate = &content
+		directUpdate = operational
+
+		// Valida já as alterações de conteúdo para devolver erros ao vendor
+		if _, err := planProductUpdate(tx, id, currentCategoryID, content); err != nil {
+			return 0, err
+		}
+	}
+
+	// Um produto rejeitado que recebe novo status volta para a fila
+	var resubmittedStatus *string
+	if requiresModeration && currentStatus == ProductStatusRejected && productUpdate.Status != nil {
+		if !validProductStatus(*productUpdate.Status) {
+			return 0, &requestError{400, "Status inválido. Use: draft, published, archived ou out_of_season"}
+		}
+		resubmittedStatus = directUpdate.Status
+		directUpdate.Status = nil
+	}
+
+	plan, err := planProductUpdate(tx, id, currentCategoryID, directUpdate)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := plan.apply(tx, id, int64(submitter)); err != nil {
+		return 0, err
+	}
+
+	if moderatedUpdate != nil {
+		return submitProductModeration(tx, id, submitter, ModerationActionUpdate, moderatedUpdate)
+	}
+	if resubmittedStatus != nil {
+		if _, err := tx.Exec("UPDATE products SET status = ? WHERE id = ?", ProductStatusPendingReview, id); err != nil {
+			return 0, err
+		}
+		return submitProductModeration(tx, id, submitter, ModerationActionCreate, moderationCreatePayload{Status: *resubmittedStatus})
+	}
+	return 0, nil
+}
@@ -0,0 +1,506 @@
+package controllers
+
+import (
+	"api/spreadsheet"
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Modos da importação de catálogo
+const (
+	ImportModeDryRun = "dry_run"
+	ImportModeCommit = "commit"
+)
+
+const (
+	// Maior quantidade de linhas de produtos aceita por importação
+	maxImportRows = 5000
+	// Maior quantidade de colunas lidas da planilha (A a Z); sobra espaço
+	// para colunas em branco além das colunas do catálogo
+	maxImportColumns = 26
+)
+
+// Colunas da planilha de catálogo, na ordem da exportação
+var catalogColumns = []string{
+	"sku", "name", "description", "price", "quantity", "categories_product_id", "status", "reorder_threshold",
+}
+
+// Preço com até duas casas decimais, separadas por ponto ou vírgula
+var importPricePattern = regexp.MustCompile(`^\d+([.,]\d{1,2})?$`)
+
+// ImportRowResult é o resultado da importação de uma linha da planilha
+type ImportRowResult struct {
+	Row          int      `json:"row"`
+	SKU          string   `json:"sku"`
+	Action       string   `json:"action"`
+	ProductID    int64    `json:"product_id,omitempty"`
+	ModerationID int64    `json:"moderation_id,omitempty"`
+	Errors       []string `json:"errors,omitempty"`
+}
+
+// catalogRow é uma linha da planilha já separada por coluna; células vazias
+// ficam ausentes do mapa
+type catalogRow map[string]string
+
+// productChanges converte a linha na atualização parcial equivalente,
+// acumulando os erros de formato encontrados
+func (row catalogRow) productChanges() (ProductUpdate, []string) {
+	var update ProductUpdate
+	var errs []string
+
+	if name, ok := row["name"]; ok {
+		update.Name = &name
+	}
+	if description, ok := row["description"]; ok {
+		update.Description = &description
+	}
+	if price, ok := row["price"]; ok {
+		if importPricePattern.MatchString(price) {
+			normalized := strings.Replace(price, ",", ".", 1)
+			update.Price = &normalized
+		} else {
+			errs = append(errs, "Preço inválido: use números com até duas casas decimais")
+		}
+	}
+	if quantity, ok := row["quantity"]; ok {
+		if value, err := strconv.Atoi(quantity); err == nil && value >= 0 {
+			update.Quantity = &quantity
+		} else {
+			errs = append(errs, "Quantidade inválida")
+		}
+	}
+	if category, ok := row["categories_product_id"]; ok {
+		if id, err := strconv.Atoi(category); err == nil {
+			update.CategoryId = &id
+		} else {
+			errs = append(errs, "Categoria inválida")
+		}
+	}
+	if status, ok := row["status"]; ok {
+		// Status de moderação vêm da exportação e só valem se não mudarem
+		if validProductListStatus(status) {
+			update.Status = &status
+		} else {
+			errs = append(errs, "Status inválido. Use: draft, published, archived ou out_of_season")
+		}
+	}
+	if threshold, ok := row["reorder_threshold"]; ok {
+		if value, err := strconv.Atoi(threshold); err == nil && value >= 0 {
+			update.ReorderThreshold = &value
+		} else {
+			errs = append(errs, "Limite de reposição inválido")
+		}
+	}
+	return update, errs
+}
+
+// dropUnchanged descarta os campos iguais aos valores atuais do produto, para
+// que reimportar uma exportação não gere alterações nem revisões de moderação
+func (u *ProductUpdate) dropUnchanged(current catalogRow) {
+	sameNumber := func(value *string, column string) bool {
+		a, errA := strconv.ParseFloat(*value, 64)
+		b, errB := strconv.ParseFloat(current[column], 64)
+		return errA == nil && errB == nil && a == b
+	}
+	if u.Name != nil && *u.Name == current["name"] {
+		u.Name = nil
+	}
+	if u.Description != nil && *u.Description == current["description"] {
+		u.Description = nil
+	}
+	if u.Price != nil && sameNumber(u.Price, "price") {
+		u.Price = nil
+	}
+	if u.Quantity != nil && sameNumber(u.Quantity, "quantity") {
+		u.Quantity = nil
+	}
+	if u.CategoryId != nil && strconv.Itoa(*u.CategoryId) == current["categories_product_id"] {
+		u.CategoryId = nil
+	}
+	if u.Status != nil && *u.Status == current["status"] {
+		u.Status = nil
+	}
+	if u.ReorderThreshold != nil && strconv.Itoa(*u.ReorderThreshold) == current["reorder_threshold"] {
+		u.ReorderThreshold = nil
+	}
+}
+
+// vendorUserID devolve o usuário que cadastrou o vendor
+func vendorUserID(q sqlQueryer, vendorID interface{}) (int, error) {
+	var userID int
+	err := q.QueryRow("SELECT users_id FROM vendors WHERE id = ?", vendorID).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, &requestError{404, "Vendor não encontrado"}
+	}
+	return userID, err
+}
+
+// importCatalogRow cria ou atualiza, pelo SKU, o produto da linha no catálogo
+// do vendor, em nome do usuário informado
+func importCatalogRow(tx *sql.Tx, vendorID, userID int, row catalogRow, result *ImportRowResult) error {
+	changes, errs := row.productChanges()
+	if len(errs) > 0 {
+		result.Errors = errs
+		return nil
+	}
+
+	var productID int64
+	var productVendor *int
+	var deleted bool
+	current := catalogRow{}
+	var name, description, price, quantity, categoryID, status, threshold string
+	err := tx.QueryRow(`
+		SELECT id, vendors_id, deleted_at IS NOT NULL, name, COALESCE(description, ''), price, quantity,
+			categories_products_id, status, COALESCE(reorder_threshold, 0)
+		FROM products WHERE sku = ? FOR UPDATE`, result.SKU).
+		Scan(&productID, &productVendor, &deleted, &name, &description, &price, &quantity, &categoryID, &status, &threshold)
+	if err == nil {
+		current = catalogRow{
+			"name": name, "description": description, "price": price, "quantity": quantity,
+			"categories_product_id": categoryID, "status": status, "reorder_threshold": threshold,
+		}
+	}
+	switch {
+	case err == sql.ErrNoRows:
+		var missing []string
+		for _, column := range []string{"name", "price", "quantity", "categories_product_id"} {
+			if _, ok := row[column]; !ok {
+				missing = append(missing, column)
+			}
+		}
+		if len(missing) > 0 {
+			result.Errors = []string{"Colunas obrigatórias para novos produtos: " + strings.Join(missing, ", ")}
+			return nil
+		}
+
+		product := ProductCreate{
+			SKU:              result.SKU,
+			Name:             *changes.Name,
+			Price:            *changes.Price,
+			UsersId:          userID,
+			VendorsID:        &vendorID,
+			Quantity:         *changes.Quantity,
+			CategoryId:       *changes.CategoryId,
+			ReorderThreshold: changes.ReorderThreshold,
+		}
+		if changes.Description != nil {
+			product.Description = *changes.Description
+		}
+		if changes.Status != nil {
+			product.Status = *changes.Status
+		}
+
+		result.Action = "created"
+		result.ProductID, result.ModerationID, err = createProduct(tx, &product, "Importação de planilha")
+		return err
+	case err != nil:
+		return err
+	case productVendor == nil || *productVendor != vendorID:
+		result.Errors = []string{"SKU já cadastrado por outro vendor"}
+		return nil
+	case deleted:
+		result.Errors = []string{"SKU pertence a um produto excluído; restaure-o antes de importar"}
+		return nil
+	}
+
+	result.Action = "updated"
+	result.ProductID = productID
+	changes.dropUnchanged(current)
+	if changes.Status != nil && !validProductStatus(*changes.Status) {
+		result.Errors = []string{"Status inválido. Use: draft, published, archived ou out_of_season"}
+		return nil
+	}
+	if changes.empty() {
+		result.Action = "unchanged"
+		return nil
+	}
+	result.ModerationID, err = updateProduct(tx, productID, changes, userID)
+	return err
+}
+
+// readSpreadsheetUpload lê a planilha enviada no campo file do formulário
+func readSpreadsheetUpload(c *fiber.Ctx) ([][]string, error) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, &requestError{400, "Envie a planilha no campo file"}
+	}
+
+	format, err := spreadsheet.FormatFromFilename(header.Filename)
+	if formatName := c.FormValue("format"); formatName != "" {
+		format, err = spreadsheet.ParseFormat(formatName)
+	}
+	if err != nil {
+		return nil, &requestError{400, err.Error()}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// O cabeçalho conta como uma linha a mais
+	rows, err := spreadsheet.Read(format, data, spreadsheet.Limits{MaxRows: maxImportRows + 1, MaxColumns: maxImportColumns})
+	switch {
+	case errors.Is(err, spreadsheet.ErrTooManyRows):
+		return nil, &requestError{400, fmt.Sprintf("A planilha excede o limite de %d linhas", maxImportRows)}
+	case errors.Is(err, spreadsheet.ErrTooManyColumns):
+		return nil, &requestError{400, fmt.Sprintf("A planilha excede o limite de %d colunas", maxImportColumns)}
+	case err != nil:
+		return nil, &requestError{400, "Não foi possível ler a planilha: " + err.Error()}
+	}
+	return rows, nil
+}
+
+// @Summary Importar catálogo do vendor
+// @Description Importa produtos de uma planilha CSV ou XLSX (primeira linha de cabeçalho com as colunas sku, name, description, price, quantity, categories_product_id, status e reorder_threshold), criando ou atualizando pelo SKU. Células vazias mantêm o valor atual. No modo dry_run (padrão) nada é gravado; no modo commit as linhas válidas são gravadas e as inválidas, relatadas. Restrito a owners e managers do vendor e a administradores; os produtos criados ficam em nome de quem importou.
+// @Tags Vendors
+// @Accept multipart/form-data
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param file formData file true "Planilha .csv ou .xlsx"
+// @Param format formData string false "Formato (csv ou xlsx); padrão pela extensão do arquivo"
+// @Param mode query string false "dry_run ou commit" default(dry_run)
+// @Success 200 {object} map[string]interface{} "Resumo e relatório por linha"
+// @Failure 400 {object} map[string]string "Planilha inválida"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Usuário sem acesso ao vendor"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao importar produtos"
+// @Router /vendors/{vendor_id}/products/import [post]
+func ImportVendorProducts(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		mode := c.Query("mode", ImportModeDryRun)
+		if mode != ImportModeDryRun && mode != ImportModeCommit {
+			return c.Status(400).JSON(fiber.Map{"error": "Modo inválido. Use: dry_run ou commit"})
+		}
+
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+		// Os produtos criados pela importação ficam em nome de quem importou
+		userID, _, err := authorizeMember(db, c, vendorOrganization, vendorID, MemberManager)
+		if err != nil {
+			return respondError(c, err, "Erro ao verificar permissões:", "Erro ao importar produtos")
+		}
+
+		rows, err := readSpreadsheetUpload(c)
+		if err != nil {
+			return respondError(c, err, "Erro ao ler planilha:", "Erro ao importar produtos")
+		}
+
+		// Mapeia as colunas pelo cabeçalho
+		columns := make(map[int]string)
+		known := make(map[string]bool, len(catalogColumns))
+		for _, column := range catalogColumns {
+			known[column] = true
+		}
+		hasSKU := false
+		for i, name := range rows[0] {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if !known[name] {
+				if name != "" {
+					return c.Status(400).JSON(fiber.Map{"error": "Coluna desconhecida: " + name})
+				}
+				continue
+			}
+			columns[i] = name
+			hasSKU = hasSKU || name == "sku"
+		}
+		if !hasSKU {
+			return c.Status(400).JSON(fiber.Map{"error": "A planilha precisa da coluna sku"})
+		}
+
+		// Todas as linhas rodam na mesma transação, cada uma em seu savepoint;
+		// no dry_run a transação é desfeita ao final, de forma que a validação
+		// é idêntica à do commit (inclusive SKUs repetidos na planilha)
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao importar produtos"})
+		}
+		defer tx.Rollback()
+
+		results := []ImportRowResult{}
+		var touched []int
+		created, updated, unchanged, failed := 0, 0, 0, 0
+		for i, values := range rows[1:] {
+			row := catalogRow{}
+			for position, value := range values {
+				value = strings.TrimSpace(value)
+				if column, ok := columns[position]; ok && value != "" {
+					row[column] = value
+				}
+			}
+			if len(row) == 0 {
+				continue
+			}
+
+			result := ImportRowResult{Row: i + 2, SKU: row["sku"]}
+			if result.SKU == "" {
+				result.Action = "error"
+				result.Errors = []string{"SKU é obrigatório"}
+				results = append(results, result)
+				failed++
+				continue
+			}
+
+			if _, err := tx.Exec("SAVEPOINT import_row"); err != nil {
+				log.Println("Erro ao criar savepoint:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao importar produtos"})
+			}
+
+			err := importCatalogRow(tx, vendorID, userID, row, &result)
+			if err != nil {
+				var reqErr *requestError
+				if errors.As(err, &reqErr) {
+					result.Errors = []string{reqErr.message}
+				} else {
+					log.Println("Erro ao importar linha da planilha:", err)
+					return c.Status(500).JSON(fiber.Map{"error": "Erro ao importar produtos"})
+				}
+			}
+
+			if len(result.Errors) > 0 {
+				result.Action = "error"
+				result.ProductID, result.ModerationID = 0, 0
+				failed++
+				if _, err := tx.Exec("ROLLBACK TO SAVEPOINT import_row"); err != nil {
+					log.Println("Erro ao desfazer linha:", err)
+					return c.Status(500).JSON(fiber.Map{"error": "Erro ao importar produtos"})
+				}
+			} else {
+				switch result.Action {
+				case "created":
+					created++
+				case "updated":
+					updated++
+				case "unchanged":
+					unchanged++
+				}
+				touched = append(touched, int(result.ProductID))
+				if _, err := tx.Exec("RELEASE SAVEPOINT import_row"); err != nil {
+					log.Println("Erro ao liberar savepoint:", err)
+					return c.Status(500).JSON(fiber.Map{"error": "Erro ao importar produtos"})
+				}
+			}
+			results = append(results, result)
+		}
+
+		committed := false
+		if mode == ImportModeCommit && created+updated > 0 {
+			if err := tx.Commit(); err != nil {
+				log.Println("Erro ao finalizar transação:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao importar produtos"})
+			}
+			committed = true
+
+			if len(touched) > 0 {
+				condition, args := listingIDsCondition(touched)
+				reindexProductsLogged(db, condition, args...)
+			}
+		}
+
+		return c.Status(200).JSON(fiber.Map{
+			"mode":      mode,
+			"committed": committed,
+			"summary": fiber.Map{
+				"rows":      len(results),
+				"created":   created,
+				"updated":   updated,
+				"unchanged": unchanged,
+				"failed":    failed,
+			},
+			"rows": results,
+		})
+	}
+}
+
+// @Summary Exportar catálogo do vendor
+// @Description Exporta os produtos não excluídos do vendor em CSV ou XLSX, com as mesmas colunas aceitas pela importação. Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Produce octet-stream
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param format query string false "csv ou xlsx" default(csv)
+// @Success 200 {file} file "Planilha do catálogo"
+// @Failure 400 {object} map[string]string "Formato inválido"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Usuário sem acesso ao vendor"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao exportar produtos"
+// @Router /vendors/{vendor_id}/products/export [get]
+func ExportVendorProducts(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		format, err := spreadsheet.ParseFormat(c.Query("format", string(spreadsheet.CSV)))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões:", "Erro ao exportar produtos")
+		}
+
+		rows, err := db.Query(`
+			SELECT sku, name, COALESCE(description, ''), price, quantity, categories_products_id, status,
+				COALESCE(reorder_threshold, 0)
+			FROM products
+			WHERE vendors_id = ? AND deleted_at IS NULL
+			ORDER BY id`, vendorID)
+		if err != nil {
+			log.Println("Erro ao buscar produtos para exportação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao exportar produtos"})
+		}
+		defer rows.Close()
+
+		sheet := [][]string{catalogColumns}
+		for rows.Next() {
+			var sku, name, description, price, status string
+			var quantity, categoryID, threshold int
+			if err := rows.Scan(&sku, &name, &description, &price, &quantity, &categoryID, &status, &threshold); err != nil {
+				log.Println("Erro ao ler produto:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao exportar produtos"})
+			}
+			thresholdCell := ""
+			if threshold > 0 {
+				thresholdCell = strconv.Itoa(threshold)
+			}
+			sheet = append(sheet, []string{
+				sku, name, description, price, strconv.Itoa(quantity), strconv.Itoa(categoryID), status, thresholdCell,
+			})
+		}
+		if err := rows.Err(); err != nil {
+			log.Println("Erro com as linhas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao exportar produtos"})
+		}
+
+		var buffer bytes.Buffer
+		if err := spreadsheet.Write(&buffer, format, sheet); err != nil {
+			log.Println("Erro ao gerar planilha:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao exportar produtos"})
+		}
+
+		c.Set(fiber.HeaderContentType, format.ContentType())
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="produtos.%s"`, format))
+		return c.Status(200).Send(buffer.Bytes())
+	}
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"database/sql"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Status do ciclo de vida de um produto
+const (
+	ProductStatusDraft       = "draft"
+	ProductStatusPublished   = "published"
+	ProductStatusArchived    = "archived"
+	ProductStatusOutOfSeason = "out_of_season"
+
+	// Definidos pela moderação; o vendor não pode atribuí-los diretamente
+	ProductStatusPendingReview = "pending_review"
+	ProductStatusRejected      = "rejected"
+)
+
+// Filtro de listagem que retorna os produtos excluídos (lixeira do vendor)
+const productStatusDeleted = "deleted"
+
+// Condição SQL dos produtos visíveis nas vitrines públicas (alias p)
+const publicProductCondition = "p.status = 'published' AND p.deleted_at IS NULL AND " + approvedSellerCondition
+
+// validProductStatus indica se o status informado é um status de produto
+func validProductStatus(status string) bool {
+	switch status {
+	case ProductStatusDraft, ProductStatusPublished, ProductStatusArchived, ProductStatusOutOfSeason:
+		return true
+	}
+	return false
+}
+
+// validProductListStatus indica se o status pode ser usado como filtro de
+// listagem, incluindo os status definidos pela moderação
+func validProductListStatus(status string) bool {
+	return validProductStatus(status) || status == ProductStatusPendingReview || status == ProductStatusRejected
+}
+
+// productPurchasable verifica se o produto existe, não foi excluído, está
+// publicado e pertence a um vendor aprovado, devolvendo o estoque vendável
+// (sem lotes vencidos)
+func productPurchasable(q sqlQueryer, productID interface{}) (bool, int, error) {
+	var status string
+	var deleted, approved bool
+	var stock int
+	err := q.QueryRow("SELECT p.status, p.deleted_at IS NOT NULL, "+approvedSellerCondition+", "+sellableQuantityExpr+" FROM products p WHERE p.id = ?", productID).
+		Scan(&status, &deleted, &approved, &stock)
+	if err == sql.ErrNoRows {
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	return status == ProductStatusPublished && !deleted && approved, stock, nil
+}
+
+// @Summary Restaurar produto excluído
+// @Description Restaura um produto excluído logicamente, mantendo o status que ele tinha. As variantes excluídas junto com o produto principal também são restauradas. Restrito a owners e managers do vendor do produto e a administradores
+// @Tags Products
+// @Param X-User-ID header int true "ID do usuário"
+// @Param id path int true "ID do produto"
+// @Success 200 {object} map[string]interface{} "Produto restaurado com sucesso"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Produto pertence a outro vendor"
+// @Failure 404 {object} map[string]string "Produto não encontrado"
+// @Failure 409 {object} map[string]string "Produto não está excluído"
+// @Failure 500 {object} map[string]string "Erro ao restaurar produto"
+// @Router /products/id/{id}/restore [post]
+func RestoreProductByID(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Params("id")
+
+		var deleted bool
+		err := db.QueryRow("SELECT deleted_at IS NOT NULL FROM products WHERE id = ?", id).Scan(&deleted)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Produto não encontrado"})
+		} else if err != nil {
+			log.Println("Erro ao verificar produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar produto"})
+		}
+
+		if _, err := authorizeProductManager(db, c, id); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões:", "Erro ao restaurar produto")
+		}
+
+		if !deleted {
+			return c.Status(409).JSON(fiber.Map{"error": "Produto não está excluído"})
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao restaurar produto"})
+		}
+		defer tx.Rollback()
+
+		// Variantes excluídas em outro momento continuam na lixeira
+		_, err = tx.Exec(`
+			UPDATE products v
+			INNER JOIN products p ON p.id = v.parent_id
+			SET v.deleted_at = NULL
+			WHERE p.id = ? AND v.deleted_at = p.deleted_at`, id)
+		if err != nil {
+			log.Println("Erro ao restaurar variantes:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao restaurar produto"})
+		}
+
+		if _, err := tx.Exec("UPDATE products SET deleted_at = NULL WHERE id = ?", id); err != nil {
+			log.Println("Erro ao restaurar produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao restaurar produto"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao restaurar produto"})
+		}
+
+		reindexProduct(db, id)
+
+		return c.Status(200).JSON(fiber.Map{
+			"message": "Produto restaurado com sucesso",
+			"id":      id,
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"database/sql"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Validade padrão, em minutos, da reserva feita pelo carrinho
+const defaultReservationTTLMinutes = 15
+
+// Condição SQL das reservas que ainda seguram estoque (alias r)
+const activeReservationCondition = "r.status = 'active' AND r.expires_at > NOW()"
+
+// Expressão SQL da quantidade reservada do produto (alias p) pelos itens do
+// carrinho informado no parâmetro; somada a sellableQuantityExpr dá o estoque
+// disponível para esse carrinho
+const cartHeldQuantityExpr = `COALESCE((
+	SELECT SUM(r.quantity) FROM stock_reservations r
+	WHERE r.products_id = p.id AND r.cart_id = ? AND ` + activeReservationCondition + `), 0)`
+
+// reservationTTLMinutes lê a validade das reservas (RESERVATION_TTL_MINUTES)
+func reservationTTLMinutes() int {
+	minutes, err := strconv.Atoi(os.Getenv("RESERVATION_TTL_MINUTES"))
+	if err != nil || minutes <= 0 {
+		return defaultReservationTTLMinutes
+	}
+	return minutes
+}
+
+// reserveCartItem reserva a quantidade do item de carrinho, substituindo a
+// reserva anterior do item. A linha do produto é travada para que reservas
+// concorrentes não ultrapassem o estoque vendável; a falta de estoque é
+// devolvida como *requestError. Devolve o vencimento da reserva.
+func reserveCartItem(tx *sql.Tx, cartItemID int, quantity int) (string, error) {
+	var cartID, productID int
+	if err := tx.QueryRow("SELECT cart_id, products_id FROM cart_items WHERE id = ?", cartItemID).Scan(&cartID, &productID); err != nil {
+		return "", err
+	}
+
+	// Reservas vencidas que a varredura ainda não marcou não valem mais
+	if _, err := tx.Exec(`
+		UPDATE stock_reservations SET status = 'expired'
+		WHERE cart_items_id = ? AND status = 'active' AND expires_at <= NOW()`, cartItemID); err != nil {
+		return "", err
+	}
+
+	var sellable, held int
+	err := tx.QueryRow(`
+		SELECT `+sellableQuantityExpr+`,
+			COALESCE((SELECT SUM(r.quantity) FROM stock_reservations r
+				WHERE r.cart_items_id = ? AND `+activeReservationCondition+`), 0)
+		FROM products p WHERE p.id = ? FOR UPDATE`, cartItemID, productID).Scan(&sellable, &held)
+	if err != nil {
+		return "", err
+	}
+	if quantity > sellable+held {
+		return "", &requestError{400, "Quantidade solicitada excede o estoque disponível"}
+	}
+
+	ttl := reservationTTLMinutes()
+	result, err := tx.Exec(`
+		UPDATE stock_reservations SET quantity = ?, expires_at = NOW() + INTERVAL ? MINUTE
+		WHERE cart_items_id = ? AND status = 'active'`, quantity, ttl, cartItemID)
+	if err != nil {
+		return "", err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		_, err = tx.Exec(`
+			INSERT INTO stock_reservations (cart_items_id, cart_id, products_id, quantity, expires_at)
+			VALUES (?, ?, ?, ?, NOW() + INTERVAL ? MINUTE)`, cartItemID, cartID, productID, quantity, ttl)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Atividade no carrinho prorroga as demais reservas ainda válidas
+	if err := extendCartReservations(tx, cartID); err != nil {
+		return "", err
+	}
+
+	var expiresAt string
+	err = tx.QueryRow("SELECT expires_at FROM stock_reservations WHERE cart_items_id = ? AND status = 'active'", cartItemID).Scan(&expiresAt)
+	return expiresAt, err
+}
+
+// extendCartReservations prorroga as reservas ativas do carrinho
+func extendCartReservations(q sqlQueryer, cartID int) error {
+	_, err := q.Exec(`
+		UPDATE stock_reservations r SET r.expires_at = NOW() + INTERVAL ? MINUTE
+		WHERE r.cart_id = ? AND `+activeReservationCondition, reservationTTLMinutes(), cartID)
+	return err
+}
+
+// releaseReservations libera as reservas ativas que atendem à condição
+// (alias r), por exemplo quando o item ou o carrinho é removido
+func releaseReservations(q sqlQueryer, condition string, args ...interface{}) error {
+	_, err := q.Exec("UPDATE stock_reservations r SET r.status = 'released' WHERE r.status = 'active' AND "+condition, args...)
+	return err
+}
+
+// convertCartItemReservation marca a reserva do item como convertida no
+// pedido; o estoque é baixado pela venda na mesma transação
+func convertCartItemReservation(tx *sql.Tx, cartItemID int, orderID int64) error {
+	_, err := tx.Exec(`
+		UPDATE stock_reservations SET status = 'converted', orders_id = ?
+		WHERE cart_items_id = ? AND status = 'active'`, orderID, cartItemID)
+	return err
+}
+
+// ExpireStockReservations marca como vencidas as reservas cujo prazo passou,
+// devolvendo quantas foram liberadas
+func ExpireStockReservations(db *sql.DB) (int64, error) {
+	result, err := db.Exec("UPDATE stock_reservations SET status = 'expired' WHERE status = 'active' AND expires_at <= NOW()")
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// StartReservationSweeper executa ExpireStockReservations periodicamente em
+// segundo plano
+func StartReservationSweeper(db *sql.DB, interval time.Duration) {
+	go func() {
+		for {
+			if expired, err := ExpireStockReservations(db); err != nil {
+				log.Println("Erro ao liberar reservas vencidas:", err)
+			} else if expired > 0 {
+				log.Printf("%d reserva(s) de estoque vencida(s) liberada(s)", expired)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
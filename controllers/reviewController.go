@@ -0,0 +1,540 @@
+package controllers
+
+import (
+	"api/pagination"
+	"database/sql"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Status das avaliações de produto
+const (
+	ReviewStatusPublished = "published"
+	ReviewStatusHidden    = "hidden"
+)
+
+// Tamanho máximo do comentário e da resposta do vendor
+const maxReviewTextLength = 2000
+
+// ProductReview é a avaliação de um comprador sobre um produto entregue
+type ProductReview struct {
+	ID               int     `json:"id"`
+	ProductID        int     `json:"products_id"`
+	OrderItemID      int     `json:"order_items_id"`
+	UsersID          int     `json:"users_id"`
+	Rating           int     `json:"rating"`
+	Comment          *string `json:"comment"`
+	Status           string  `json:"status"`
+	ModerationReason *string `json:"moderation_reason,omitempty"`
+	VendorReply      *string `json:"vendor_reply"`
+	VendorRepliedAt  *string `json:"vendor_replied_at"`
+	CreatedAt        string  `json:"created_at"`
+}
+
+// ProductReviewRequest é o corpo da avaliação. Sem order_items_id é usado o
+// item entregue mais recente do comprador que ainda não foi avaliado.
+type ProductReviewRequest struct {
+	OrderItemID *int   `json:"order_items_id,omitempty"`
+	Rating      int    `json:"rating"`
+	Comment     string `json:"comment"`
+}
+
+// ProductReviewReply é a resposta do vendor a uma avaliação
+type ProductReviewReply struct {
+	Reply string `json:"reply"`
+}
+
+const productReviewColumns = `id, products_id, order_items_id, users_id, rating, comment, status, moderation_reason,
+	vendor_reply, vendor_replied_at, created_at`
+
+func scanProductReview(row interface{ Scan(...interface{}) error }) (ProductReview, error) {
+	var review ProductReview
+	err := row.Scan(&review.ID, &review.ProductID, &review.OrderItemID, &review.UsersID, &review.Rating, &review.Comment,
+		&review.Status, &review.ModerationReason, &review.VendorReply, &review.VendorRepliedAt, &review.CreatedAt)
+	return review, err
+}
+
+// refreshProductRating recalcula a média e a quantidade das avaliações
+// publicadas do produto
+func refreshProductRating(q sqlQueryer, productID interface{}) error {
+	_, err := q.Exec(`
+		UPDATE products p SET
+			rating_average = (SELECT ROUND(AVG(r.rating), 2) FROM product_reviews r WHERE r.products_id = p.id AND r.status = 'published'),
+			rating_count = (SELECT COUNT(*) FROM product_reviews r WHERE r.products_id = p.id AND r.status = 'published')
+		WHERE p.id = ?`, productID)
+	return err
+}
+
+// reviewableOrderItem encontra o item de pedido entregue do comprador que
+// será avaliado. Itens de variantes valem para o produto principal.
+func reviewableOrderItem(tx *sql.Tx, userID, productID int, orderItemID *int) (int, error) {
+	query := `
+		SELECT oi.id, o.status, EXISTS (SELECT 1 FROM product_reviews pr WHERE pr.order_items_id = oi.id)
+		FROM order_items oi
+		INNER JOIN orders o ON o.id = oi.orders_id
+		INNER JOIN products p ON p.id = oi.products_id
+		WHERE o.users_id = ? AND COALESCE(p.parent_id, p.id) = ?`
+	args := []interface{}{userID, productID}
+	if orderItemID != nil {
+		query += " AND oi.id = ?"
+		args = append(args, *orderItemID)
+	} else {
+		query += ` AND o.status = 'delivered'
+			ORDER BY EXISTS (SELECT 1 FROM product_reviews pr WHERE pr.order_items_id = oi.id), oi.id DESC
+			LIMIT 1`
+	}
+
+	var itemID int
+	var orderStatus string
+	var reviewed bool
+	err := tx.QueryRow(query, args...).Scan(&itemID, &orderStatus, &reviewed)
+	if err == sql.ErrNoRows {
+		if orderItemID != nil {
+			return 0, &requestError{404, "Item de pedido não encontrado"}
+		}
+		return 0, &requestError{403, "Apenas compradores com pedido entregue deste produto podem avaliá-lo"}
+	} else if err != nil {
+		return 0, err
+	}
+	if orderStatus != "delivered" {
+		return 0, &requestError{403, "Só é possível avaliar itens de pedidos entregues"}
+	}
+	if reviewed {
+		return 0, &requestError{409, "Este item já foi avaliado"}
+	}
+	return itemID, nil
+}
+
+// @Summary Avaliações do produto
+// @Description Lista as avaliações publicadas do produto (mais recentes primeiro), com a média, a quantidade e a distribuição das notas
+// @Tags Reviews
+// @Param id path int true "ID do produto"
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Resumo das notas e envelope com data e next_cursor"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
+// @Failure 404 {object} map[string]string "Produto não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar avaliações"
+// @Router /products/id/{id}/reviews [get]
+func GetProductReviews(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		productID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		// Variantes exibem as avaliações do produto principal
+		var ratingAverage *float64
+		var ratingCount int
+		err = db.QueryRow(`
+			SELECT p.id, p.rating_average, p.rating_count
+			FROM products p
+			WHERE p.id = (SELECT COALESCE(parent_id, id) FROM products WHERE id = ?) AND p.deleted_at IS NULL`, productID).
+			Scan(&productID, &ratingAverage, &ratingCount)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Produto não encontrado"})
+		} else if err != nil {
+			log.Println("Erro ao buscar produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar avaliações"})
+		}
+
+		distribution := map[string]int{"1": 0, "2": 0, "3": 0, "4": 0, "5": 0}
+		rows, err := db.Query("SELECT rating, COUNT(*) FROM product_reviews WHERE products_id = ? AND status = 'published' GROUP BY rating", productID)
+		if err != nil {
+			log.Println("Erro ao buscar distribuição das notas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar avaliações"})
+		}
+		for rows.Next() {
+			var rating, count int
+			if err := rows.Scan(&rating, &count); err != nil {
+				rows.Close()
+				log.Println("Erro ao ler distribuição das notas:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar avaliações"})
+			}
+			distribution[strconv.Itoa(rating)] = count
+		}
+		rows.Close()
+
+		keyset, keysetArgs := params.Keyset("id")
+		args := append(append([]interface{}{productID}, keysetArgs...), params.FetchLimit())
+		rows, err = db.Query(`
+			SELECT `+productReviewColumns+`
+			FROM product_reviews
+			WHERE products_id = ? AND status = 'published' AND `+keyset+`
+			ORDER BY id DESC
+			LIMIT ?`, args...)
+		if err != nil {
+			log.Println("Erro ao buscar avaliações:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar avaliações"})
+		}
+		defer rows.Close()
+
+		var reviews []ProductReview
+		for rows.Next() {
+			review, err := scanProductReview(rows)
+			if err != nil {
+				log.Println("Erro ao ler avaliação:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler avaliação"})
+			}
+			reviews = append(reviews, review)
+		}
+
+		if err := rows.Err(); err != nil {
+			log.Println("Erro com as linhas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar avaliações"})
+		}
+
+		page := pagination.NewPage(reviews, params, func(r ProductReview) int { return r.ID })
+
+		response := fiber.Map{
+			"products_id":    productID,
+			"rating_average": ratingAverage,
+			"rating_count":   ratingCount,
+			"distribution":   distribution,
+			"data":           page.Data,
+			"next_cursor":    page.NextCursor,
+		}
+		if params.IncludeTotal {
+			response["total"] = ratingCount
+		}
+
+		return c.Status(200).JSON(response)
+	}
+}
+
+// @Summary Avaliar produto
+// @Description Registra a nota (1 a 5) e o comentário do comprador do cabeçalho X-User-ID. Apenas itens de pedidos entregues podem ser avaliados, uma vez cada
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do comprador"
+// @Param id path int true "ID do produto"
+// @Param review body ProductReviewRequest true "Avaliação"
+// @Success 201 {object} ProductReview "Avaliação registrada"
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem pedido entregue do produto"
+// @Failure 404 {object} map[string]string "Produto ou item não encontrado"
+// @Failure 409 {object} map[string]string "Item já avaliado"
+// @Failure 500 {object} map[string]string "Erro ao registrar avaliação"
+// @Router /products/id/{id}/reviews [post]
+func CreateProductReview(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := callerUserID(c)
+		if !ok {
+			return c.Status(401).JSON(fiber.Map{"error": "Usuário não identificado"})
+		}
+
+		productID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+
+		var request ProductReviewRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+		}
+		if request.Rating < 1 || request.Rating > 5 {
+			return c.Status(400).JSON(fiber.Map{"error": "A nota deve ser de 1 a 5"})
+		}
+		request.Comment = strings.TrimSpace(request.Comment)
+		if len(request.Comment) > maxReviewTextLength {
+			return c.Status(400).JSON(fiber.Map{"error": "Comentário muito longo"})
+		}
+		var comment interface{}
+		if request.Comment != "" {
+			comment = request.Comment
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao registrar avaliação"})
+		}
+		defer tx.Rollback()
+
+		// Avaliações de variantes ficam no produto principal
+		err = tx.QueryRow("SELECT COALESCE(parent_id, id) FROM products WHERE id = ? AND deleted_at IS NULL FOR UPDATE", productID).Scan(&productID)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Produto não encontrado"})
+		} else if err != nil {
+			log.Println("Erro ao buscar produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao registrar avaliação"})
+		}
+
+		orderItemID, err := reviewableOrderItem(tx, userID, productID, request.OrderItemID)
+		if err != nil {
+			return respondError(c, err, "Erro ao buscar item do pedido:", "Erro ao registrar avaliação")
+		}
+
+		result, err := tx.Exec("INSERT INTO product_reviews (products_id, order_items_id, users_id, rating, comment) VALUES (?, ?, ?, ?, ?)",
+			productID, orderItemID, userID, request.Rating, comment)
+		if err != nil {
+			log.Println("Erro ao registrar avaliação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao registrar avaliação"})
+		}
+		reviewID, err := result.LastInsertId()
+		if err != nil {
+			log.Println("Erro ao obter ID da avaliação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao registrar avaliação"})
+		}
+
+		if err := refreshProductRating(tx, productID); err != nil {
+			log.Println("Erro ao atualizar nota do produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao registrar avaliação"})
+		}
+
+		review, err := scanProductReview(tx.QueryRow("SELECT "+productReviewColumns+" FROM product_reviews WHERE id = ?", reviewID))
+		if err != nil {
+			log.Println("Erro ao buscar avaliação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao registrar avaliação"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao registrar avaliação"})
+		}
+
+		return c.Status(201).JSON(review)
+	}
+}
+
+// @Summary Responder avaliação
+// @Description Grava (ou substitui) a resposta do vendor dono do produto, identificado pelo cabeçalho X-User-ID
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário do vendor"
+// @Param id path int true "ID do produto"
+// @Param review_id path int true "ID da avaliação"
+// @Param reply body ProductReviewReply true "Resposta"
+// @Success 200 {object} ProductReview "Avaliação com a resposta"
+// @Failure 400 {object} map[string]string "Resposta inválida"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Produto de outro vendor"
+// @Failure 404 {object} map[string]string "Avaliação não encontrada"
+// @Failure 500 {object} map[string]string "Erro ao responder avaliação"
+// @Router /products/id/{id}/reviews/{review_id}/reply [put]
+func ReplyProductReview(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := callerUserID(c)
+		if !ok {
+			return c.Status(401).JSON(fiber.Map{"error": "Usuário não identificado"})
+		}
+
+		var request ProductReviewReply
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+		}
+		request.Reply = strings.TrimSpace(request.Reply)
+		if request.Reply == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "A resposta não pode ser vazia"})
+		}
+		if len(request.Reply) > maxReviewTextLength {
+			return c.Status(400).JSON(fiber.Map{"error": "Resposta muito longa"})
+		}
+
+		var createdBy int
+		var vendorID *int
+		err := db.QueryRow(`
+			SELECT p.users_id, p.vendors_id
+			FROM product_reviews r
+			INNER JOIN products p ON p.id = r.products_id
+			WHERE r.id = ? AND r.products_id = (SELECT COALESCE(parent_id, id) FROM products WHERE id = ?)`,
+			c.Params("review_id"), c.Params("id")).Scan(&createdBy, &vendorID)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Avaliação não encontrada"})
+		} else if err != nil {
+			log.Println("Erro ao buscar avaliação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao responder avaliação"})
+		}
+		allowed, err := canManageProduct(db, vendorID, createdBy, userID)
+		if err != nil {
+			log.Println("Erro ao verificar equipe do vendor:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao responder avaliação"})
+		}
+		if !allowed {
+			return c.Status(403).JSON(fiber.Map{"error": "Apenas owners e managers do vendor do produto podem responder a avaliação"})
+		}
+
+		if _, err := db.Exec("UPDATE product_reviews SET vendor_reply = ?, vendor_replied_at = NOW() WHERE id = ?", request.Reply, c.Params("review_id")); err != nil {
+			log.Println("Erro ao responder avaliação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao responder avaliação"})
+		}
+
+		review, err := scanProductReview(db.QueryRow("SELECT "+productReviewColumns+" FROM product_reviews WHERE id = ?", c.Params("review_id")))
+		if err != nil {
+			log.Println("Erro ao buscar avaliação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao responder avaliação"})
+		}
+
+		return c.Status(200).JSON(review)
+	}
+}
+
+// @Summary Avaliações para moderação
+// @Description Lista as avaliações de todos os produtos por status, das mais recentes para as mais antigas
+// @Tags Moderation
+// @Param X-User-ID header int true "ID do administrador"
+// @Param status query string false "published ou hidden" default(published)
+// @Param rating query int false "Filtra pela nota"
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros inválidos"
+// @Failure 500 {object} map[string]string "Erro ao buscar avaliações"
+// @Router /admin/reviews [get]
+func GetReviewsForModeration(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		status := c.Query("status", ReviewStatusPublished)
+		if status != ReviewStatusPublished && status != ReviewStatusHidden {
+			return c.Status(400).JSON(fiber.Map{"error": "Status inválido. Use: published ou hidden"})
+		}
+		condition := "status = ?"
+		filterArgs := []interface{}{status}
+		if raw := c.Query("rating"); raw != "" {
+			rating, err := strconv.Atoi(raw)
+			if err != nil || rating < 1 || rating > 5 {
+				return c.Status(400).JSON(fiber.Map{"error": "Nota inválida"})
+			}
+			condition += " AND rating = ?"
+			filterArgs = append(filterArgs, rating)
+		}
+
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		keyset, keysetArgs := params.Keyset("id")
+
+		args := append(append(append([]interface{}{}, filterArgs...), keysetArgs...), params.FetchLimit())
+		rows, err := db.Query("SELECT "+productReviewColumns+" FROM product_reviews WHERE "+condition+" AND "+keyset+" ORDER BY id DESC LIMIT ?", args...)
+		if err != nil {
+			log.Println("Erro ao buscar avaliações:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar avaliações"})
+		}
+		defer rows.Close()
+
+		var reviews []ProductReview
+		for rows.Next() {
+			review, err := scanProductReview(rows)
+			if err != nil {
+				log.Println("Erro ao ler avaliação:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler avaliação"})
+			}
+			reviews = append(reviews, review)
+		}
+
+		if err := rows.Err(); err != nil {
+			log.Println("Erro com as linhas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar avaliações"})
+		}
+
+		page := pagination.NewPage(reviews, params, func(r ProductReview) int { return r.ID })
+
+		if params.IncludeTotal {
+			var total int
+			if err := db.QueryRow("SELECT COUNT(*) FROM product_reviews WHERE "+condition, filterArgs...).Scan(&total); err != nil {
+				log.Println("Erro ao contar avaliações:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao contar avaliações"})
+			}
+			page = page.WithTotal(total)
+		}
+
+		return c.Status(200).JSON(page)
+	}
+}
+
+// moderateReview altera o status da avaliação e recalcula a nota do produto
+func moderateReview(db *sql.DB, c *fiber.Ctx, status string, reason interface{}) error {
+	tx, err := db.Begin()
+	if err != nil {
+		log.Println("Erro ao iniciar transação:", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Erro ao moderar avaliação"})
+	}
+	defer tx.Rollback()
+
+	var productID int
+	err = tx.QueryRow("SELECT products_id FROM product_reviews WHERE id = ? FOR UPDATE", c.Params("id")).Scan(&productID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"message": "Avaliação não encontrada"})
+	} else if err != nil {
+		log.Println("Erro ao buscar avaliação:", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Erro ao moderar avaliação"})
+	}
+
+	_, err = tx.Exec("UPDATE product_reviews SET status = ?, moderation_reason = ?, moderated_by = ?, moderated_at = NOW() WHERE id = ?",
+		status, reason, c.Locals("admin_user_id"), c.Params("id"))
+	if err != nil {
+		log.Println("Erro ao moderar avaliação:", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Erro ao moderar avaliação"})
+	}
+
+	if err := refreshProductRating(tx, productID); err != nil {
+		log.Println("Erro ao atualizar nota do produto:", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Erro ao moderar avaliação"})
+	}
+
+	review, err := scanProductReview(tx.QueryRow("SELECT "+productReviewColumns+" FROM product_reviews WHERE id = ?", c.Params("id")))
+	if err != nil {
+		log.Println("Erro ao buscar avaliação:", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Erro ao moderar avaliação"})
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("Erro ao finalizar transação:", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Erro ao moderar avaliação"})
+	}
+
+	return c.Status(200).JSON(review)
+}
+
+// @Summary Ocultar avaliação
+// @Description Oculta uma avaliação com um motivo; ela deixa de aparecer no produto e de contar na nota
+// @Tags Moderation
+// @Param X-User-ID header int true "ID do administrador"
+// @Param id path int true "ID da avaliação"
+// @Param decision body ModerationDecision true "Motivo"
+// @Success 200 {object} ProductReview "Avaliação ocultada"
+// @Failure 400 {object} map[string]string "Motivo obrigatório"
+// @Failure 404 {object} map[string]string "Avaliação não encontrada"
+// @Failure 500 {object} map[string]string "Erro ao moderar avaliação"
+// @Router /admin/reviews/{id}/hide [post]
+func HideProductReview(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var decision ModerationDecision
+		if err := c.BodyParser(&decision); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+		}
+		decision.Reason = strings.TrimSpace(decision.Reason)
+		if decision.Reason == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "O motivo é obrigatório"})
+		}
+		return moderateReview(db, c, ReviewStatusHidden, decision.Reason)
+	}
+}
+
+// @Summary Publicar avaliação
+// @Description Volta a publicar uma avaliação ocultada
+// @Tags Moderation
+// @Param X-User-ID header int true "ID do administrador"
+// @Param id path int true "ID da avaliação"
+// @Success 200 {object} ProductReview "Avaliação publicada"
+// @Failure 404 {object} map[string]string "Avaliação não encontrada"
+// @Failure 500 {object} map[string]string "Erro ao moderar avaliação"
+// @Router /admin/reviews/{id}/publish [post]
+func PublishProductReview(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return moderateReview(db, c, ReviewStatusPublished, nil)
+	}
+}
@@ -0,0 +1,563 @@
+package controllers
+
+import (
+	"api/search"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Dimensões de filtro da busca (usadas para calcular cada faceta sem o
+// próprio filtro, permitindo ao usuário ver as alternativas)
+const (
+	searchDimensionTerm     = "term"
+	searchDimensionPrice    = "price"
+	searchDimensionCategory = "category"
+	searchDimensionVendor   = "vendor"
+	searchDimensionLocation = "location"
+	searchDimensionStock    = "stock"
+	searchDimensionAttrs    = "attributes"
+	searchDimensionRating   = "rating"
+	// Visibilidade pública do produto; nunca é ignorada nas facetas
+	searchDimensionVisibility = "visibility"
+)
+
+// Quantidade máxima de resultados do índice textual considerados por busca
+const maxSearchHits = 1000
+
+// Índice textual dos produtos, com pesos por campo
+var productIndex = search.NewIndex(map[string]float64{
+	"name":        3,
+	"sku":         3,
+	"category":    1.5,
+	"vendor":      1,
+	"description": 1,
+	"variants":    1,
+})
+
+// Faixas de preço usadas na faceta price_ranges
+var searchPriceBuckets = []float64{0, 10, 50, 100, 500}
+
+// FacetCount representa a contagem de produtos para um valor de faceta
+type FacetCount struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// PriceRangeFacet representa a contagem de produtos em uma faixa de preço
+// (max nulo indica faixa aberta)
+type PriceRangeFacet struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int      `json:"count"`
+}
+
+// SearchFacets agrupa as facetas retornadas pela busca
+type SearchFacets struct {
+	Categories  []FacetCount      `json:"categories"`
+	Vendors     []FacetCount      `json:"vendors"`
+	PriceRanges []PriceRangeFacet `json:"price_ranges"`
+}
+
+type searchCondition struct {
+	dimension string
+	clause    string
+	args      []interface{}
+}
+
+// productSearch acumula os filtros da busca de produtos
+type productSearch struct {
+	conditions []searchCondition
+}
+
+func (s *productSearch) add(dimension, clause string, args ...interface{}) {
+	s.conditions = append(s.conditions, searchCondition{dimension: dimension, clause: clause, args: args})
+}
+
+// where monta a cláusula WHERE ignorando a dimensão informada
+func (s *productSearch) where(exclude string) (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+	for _, condition := range s.conditions {
+		if condition.dimension == exclude {
+			continue
+		}
+		clauses = append(clauses, condition.clause)
+		args = append(args, condition.args...)
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(clauses, " AND "), args
+}
+
+// Junções comuns a todas as consultas da busca
+const productSearchFrom = `
+	FROM products p
+	INNER JOIN categories_products cp
+		ON p.categories_products_id = cp.id
+	LEFT JOIN vendors v
+		ON v.id = p.vendors_id
+`
+
+// InitSearchIndex carrega todos os produtos no índice textual e as sugestões
+// do autocompletar
+func InitSearchIndex(db *sql.DB) error {
+	indexed, err := reindexProducts(db, "")
+	if err != nil {
+		return err
+	}
+	log.Printf("Índice de busca carregado com %d produtos", len(indexed))
+	return RefreshSuggestions(db)
+}
+
+// reindexProducts (re)indexa os produtos publicados que atendem à condição
+// informada e retorna os IDs indexados. Variantes não são indexadas
+// sozinhas: seus SKUs e atributos entram no documento do produto principal.
+func reindexProducts(q sqlQueryer, condition string, args ...interface{}) ([]int, error) {
+	query := `
+		SELECT p.id, p.sku, p.name, COALESCE(p.description, ''), cp.name, COALESCE(v.name, ''),
+			COALESCE((SELECT GROUP_CONCAT(pv.sku SEPARATOR ' ') FROM products pv WHERE ` + publicVariantCondition + `), ''),
+			COALESCE((SELECT GROUP_CONCAT(va.value SEPARATOR ' ') FROM products pv
+				INNER JOIN product_variant_attributes va ON va.products_id = pv.id
+				WHERE ` + publicVariantCondition + `), '')
+	` + productSearchFrom + " WHERE " + listingProductCondition
+	if condition != "" {
+		query += " AND " + condition
+	}
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexed []int
+	for rows.Next() {
+		var id int
+		var sku, name, description, category, vendor, variantSKUs, variantValues string
+		if err := rows.Scan(&id, &sku, &name, &description, &category, &vendor, &variantSKUs, &variantValues); err != nil {
+			return indexed, err
+		}
+		productIndex.Upsert(search.Document{
+			ID: id,
+			Fields: map[string]string{
+				"name":        name,
+				"sku":         strings.TrimSpace(sku + " " + variantSKUs),
+				"description": description,
+				"category":    category,
+				"vendor":      vendor,
+				"variants":    variantValues,
+			},
+		})
+		indexed = append(indexed, id)
+	}
+	return indexed, rows.Err()
+}
+
+// reindexProduct atualiza um produto no índice textual, removendo-o caso não
+// exista mais ou não esteja publicado. Variantes atualizam o produto
+// principal. Falhas são apenas registradas, pois o banco continua sendo a
+// fonte da verdade.
+func reindexProduct(q sqlQueryer, productID interface{}) {
+	id, err := strconv.Atoi(fmt.Sprint(productID))
+	if err != nil {
+		return
+	}
+
+	var parentID sql.NullInt64
+	if err := q.QueryRow("SELECT parent_id FROM products WHERE id = ?", id).Scan(&parentID); err != nil && err != sql.ErrNoRows {
+		log.Println("Erro ao atualizar índice de busca:", err)
+		return
+	}
+	if parentID.Valid {
+		productIndex.Remove(id)
+		id = int(parentID.Int64)
+	}
+
+	indexed, err := reindexProducts(q, "p.id = ?", id)
+	if err != nil {
+		log.Println("Erro ao atualizar índice de busca:", err)
+		return
+	}
+	if len(indexed) == 0 {
+		productIndex.Remove(id)
+	}
+	refreshSuggestionsLater()
+}
+
+// reindexProductsLogged reindexa um conjunto de produtos registrando falhas.
+// Os produtos da condição que deixaram de ser listados (vendor suspenso,
+// produto despublicado) saem do índice.
+func reindexProductsLogged(q sqlQueryer, condition string, args ...interface{}) {
+	defer refreshSuggestionsLater()
+
+	candidates, err := scanIDs(q.Query("SELECT p.id"+productSearchFrom+" WHERE p.parent_id IS NULL AND "+condition, args...))
+	if err != nil {
+		log.Println("Erro ao atualizar índice de busca:", err)
+		return
+	}
+
+	indexed, err := reindexProducts(q, condition, args...)
+	if err != nil {
+		log.Println("Erro ao atualizar índice de busca:", err)
+		return
+	}
+	listed := make(map[int]bool, len(indexed))
+	for _, id := range indexed {
+		listed[id] = true
+	}
+	for _, id := range candidates {
+		if !listed[id] {
+			productIndex.Remove(id)
+		}
+	}
+}
+
+// searchTermHits consulta o índice textual e devolve os IDs em ordem de relevância
+func searchTermHits(searchTerm string) []int {
+	hits := productIndex.Search(searchTerm)
+	if len(hits) > maxSearchHits {
+		hits = hits[:maxSearchHits]
+	}
+	ids := make([]int, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+// parseProductSearch converte os parâmetros da requisição em filtros
+func parseProductSearch(db *sql.DB, c *fiber.Ctx, termHits []int) (*productSearch, *fiber.Map, error) {
+	search := &productSearch{}
+	search.add(searchDimensionVisibility, listingProductCondition)
+
+	if c.Query("q", "") != "" {
+		if len(termHits) == 0 {
+			search.add(searchDimensionTerm, "1 = 0")
+		} else {
+			search.add(searchDimensionTerm, "p.id IN ("+placeholders(len(termHits))+")", intArgs(termHits)...)
+		}
+	}
+
+	for _, bound := range []struct{ param, operator string }{{"min_price", ">="}, {"max_price", "<="}} {
+		if raw := c.Query(bound.param); raw != "" {
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil || value < 0 {
+				return nil, &fiber.Map{"error": "Valor inválido para " + bound.param}, nil
+			}
+			search.add(searchDimensionPrice, listingPriceExpr+" "+bound.operator+" ?", value)
+		}
+	}
+
+	if raw := c.Query("category_id"); raw != "" {
+		categoryID, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, &fiber.Map{"error": "category_id inválido"}, nil
+		}
+
+		categoryIDs := []int{categoryID}
+		if c.QueryBool("include_subcategories", true) {
+			parents, err := loadCategoryParents(db, false)
+			if err != nil {
+				return nil, nil, err
+			}
+			categoryIDs = append(categoryIDs, categoryDescendants(parents, categoryID)...)
+		}
+		search.add(searchDimensionCategory, "p.categories_products_id IN ("+placeholders(len(categoryIDs))+")", intArgs(categoryIDs)...)
+	}
+
+	if raw := c.Query("vendor_id"); raw != "" {
+		vendorID, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, &fiber.Map{"error": "vendor_id inválido"}, nil
+		}
+		search.add(searchDimensionVendor, "v.id = ?", vendorID)
+	}
+
+	if city := c.Query("city"); city != "" {
+		search.add(searchDimensionLocation, "v.city = ?", city)
+	}
+	if state := c.Query("state"); state != "" {
+		search.add(searchDimensionLocation, "v.state = ?", state)
+	}
+
+	if raw := c.Query("min_rating"); raw != "" {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value < 1 || value > 5 {
+			return nil, &fiber.Map{"error": "Valor inválido para min_rating"}, nil
+		}
+		search.add(searchDimensionRating, "p.rating_average >= ?", value)
+	}
+
+	if c.QueryBool("in_stock", false) {
+		search.add(searchDimensionStock, listingInStockCondition)
+	}
+
+	attributeConditions, err := attributeFilterConditions(c.Queries())
+	if err != nil {
+		return nil, &fiber.Map{"error": err.Error()}, nil
+	}
+	search.conditions = append(search.conditions, attributeConditions...)
+
+	return search, nil, nil
+}
+
+// productSearchOrder monta a ordenação solicitada. Sem parâmetro sort, a busca
+// por termo é ordenada por relevância (ordem dos resultados do índice) e a
+// listagem por mais recentes.
+func productSearchOrder(sortBy, searchTerm string, termHits []int) (string, []interface{}, bool) {
+	if sortBy == "" {
+		sortBy = "newest"
+		if searchTerm != "" {
+			sortBy = "relevance"
+		}
+	}
+
+	switch sortBy {
+	case "price_asc":
+		return "ORDER BY " + listingPriceExpr + " ASC, p.id DESC", nil, true
+	case "price_desc":
+		return "ORDER BY " + listingPriceExpr + " DESC, p.id DESC", nil, true
+	case "name_asc":
+		return "ORDER BY p.name ASC, p.id DESC", nil, true
+	case "name_desc":
+		return "ORDER BY p.name DESC, p.id DESC", nil, true
+	case "rating_desc":
+		return "ORDER BY p.rating_average IS NULL, p.rating_average DESC, p.rating_count DESC, p.id DESC", nil, true
+	case "newest":
+		return "ORDER BY p.id DESC", nil, true
+	case "relevance":
+		if searchTerm == "" || len(termHits) == 0 {
+			return "ORDER BY p.id DESC", nil, true
+		}
+		return "ORDER BY FIELD(p.id, " + placeholders(len(termHits)) + "), p.id DESC", intArgs(termHits), true
+	}
+
+	return "", nil, false
+}
+
+// loadSearchFacets calcula as facetas de categoria, vendor e faixa de preço
+func loadSearchFacets(db *sql.DB, search *productSearch) (*SearchFacets, error) {
+	facets := &SearchFacets{
+		Categories:  []FacetCount{},
+		Vendors:     []FacetCount{},
+		PriceRanges: []PriceRangeFacet{},
+	}
+
+	whereClause, args := search.where(searchDimensionCategory)
+	rows, err := db.Query(`
+		SELECT cp.id, cp.name, COUNT(*) `+productSearchFrom+whereClause+`
+		GROUP BY cp.id, cp.name
+		ORDER BY COUNT(*) DESC, cp.name`, args...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var facet FacetCount
+		if err := rows.Scan(&facet.ID, &facet.Name, &facet.Count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		facets.Categories = append(facets.Categories, facet)
+	}
+	rows.Close()
+
+	whereClause, args = search.where(searchDimensionVendor)
+	vendorCondition := "WHERE v.id IS NOT NULL"
+	if whereClause != "" {
+		vendorCondition = whereClause + " AND v.id IS NOT NULL"
+	}
+	rows, err = db.Query(`
+		SELECT v.id, v.name, COUNT(*) `+productSearchFrom+vendorCondition+`
+		GROUP BY v.id, v.name
+		ORDER BY COUNT(*) DESC, v.name`, args...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var facet FacetCount
+		if err := rows.Scan(&facet.ID, &facet.Name, &facet.Count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		facets.Vendors = append(facets.Vendors, facet)
+	}
+	rows.Close()
+
+	// Índice da faixa de preço de cada produto
+	var bucketCase strings.Builder
+	bucketCase.WriteString("CASE")
+	for i := len(searchPriceBuckets) - 1; i > 0; i-- {
+		bucketCase.WriteString(" WHEN " + listingPriceExpr + " >= " + strconv.FormatFloat(searchPriceBuckets[i], 'f', -1, 64) + " THEN " + strconv.Itoa(i))
+	}
+	bucketCase.WriteString(" ELSE 0 END")
+
+	whereClause, args = search.where(searchDimensionPrice)
+	rows, err = db.Query(`
+		SELECT `+bucketCase.String()+` AS bucket, COUNT(*) `+productSearchFrom+whereClause+`
+		GROUP BY bucket
+		ORDER BY bucket`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int)
+	for rows.Next() {
+		var bucket, count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		counts[bucket] = count
+	}
+	for i, min := range searchPriceBuckets {
+		facet := PriceRangeFacet{Min: min, Count: counts[i]}
+		if i+1 < len(searchPriceBuckets) {
+			max := searchPriceBuckets[i+1]
+			facet.Max = &max
+		}
+		facets.PriceRanges = append(facets.PriceRanges, facet)
+	}
+
+	return facets, rows.Err()
+}
+
+// @Summary Pesquisar produtos
+// @Description Pesquisa produtos com filtros, ordenação e facetas. Atributos podem ser filtrados com attr.<nome>=valor, attr.<nome>.min e attr.<nome>.max. Produtos com variantes aparecem uma única vez, com o menor preço e o estoque somado das variantes publicadas
+// @Tags Products
+// @Param q query string false "Termo de pesquisa (busca em nome, SKU, descrição, categoria e vendor; tolera acentos, plurais e erros de digitação)"
+// @Param min_price query number false "Preço mínimo"
+// @Param max_price query number false "Preço máximo"
+// @Param category_id query int false "ID da categoria"
+// @Param include_subcategories query bool false "Inclui as subcategorias de category_id" default(true)
+// @Param vendor_id query int false "ID do vendor"
+// @Param city query string false "Cidade do vendor"
+// @Param state query string false "Estado do vendor"
+// @Param in_stock query bool false "Somente produtos com estoque"
+// @Param min_rating query number false "Nota média mínima (1 a 5)"
+// @Param sort query string false "Ordenação (relevance, newest, price_asc, price_desc, name_asc, name_desc, rating_desc)"
+// @Param page query int false "Número da página" default(1)
+// @Param limit query int false "Limite de itens por página" default(10)
+// @Success 200 {object} map[string]interface{} "Lista de produtos com informações de paginação e facetas"
+// @Failure 400 {object} map[string]string "Filtro inválido"
+// @Failure 500 {object} map[string]string "Erro ao buscar produtos"
+// @Router /products/search [get]
+func SearchProducts(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		searchTerm := c.Query("q", "")
+		page := c.QueryInt("page", 1)
+		limit := c.QueryInt("limit", 10)
+		if page < 1 {
+			page = 1
+		}
+		if limit < 1 || limit > 100 {
+			limit = 10
+		}
+		offset := (page - 1) * limit
+
+		var termHits []int
+		if searchTerm != "" {
+			termHits = searchTermHits(searchTerm)
+		}
+
+		search, badRequest, err := parseProductSearch(db, c, termHits)
+		if err != nil {
+			log.Println("Erro ao preparar filtros da busca:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar produtos"})
+		}
+		if badRequest != nil {
+			return c.Status(400).JSON(badRequest)
+		}
+
+		sortBy := c.Query("sort", "")
+		orderClause, orderArgs, ok := productSearchOrder(sortBy, searchTerm, termHits)
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{"error": "Ordenação inválida. Use: relevance, newest, price_asc, price_desc, name_asc, name_desc ou rating_desc"})
+		}
+
+		whereClause, filterArgs := search.where("")
+
+		// Conta o total de produtos
+		var totalCount int
+		err = db.QueryRow("SELECT COUNT(*) "+productSearchFrom+whereClause, filterArgs...).Scan(&totalCount)
+		if err != nil {
+			log.Println("Erro ao contar produtos:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao contar produtos"})
+		}
+
+		// Calcula o total de páginas
+		totalPages := 0
+		if totalCount > 0 {
+			totalPages = (totalCount + limit - 1) / limit
+		}
+
+		productsQuery := `
+			SELECT
+				p.id,
+				p.sku,
+				p.name,
+				` + listingPriceExpr + `,
+				` + listingQuantityExpr + `,
+				cp.name AS category_name,
+				p.status,
+				` + productHasVariantsCondition + `,
+				p.rating_average,
+				p.rating_count
+			` + productSearchFrom + whereClause + `
+			` + orderClause + `
+			LIMIT ? OFFSET ?
+		`
+		args := append(append(append([]interface{}{}, filterArgs...), orderArgs...), limit, offset)
+
+		// Busca os produtos
+		rows, err := db.Query(productsQuery, args...)
+		if err != nil {
+			log.Println("Erro ao buscar produtos:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar produtos"})
+		}
+		defer rows.Close()
+
+		var products []Product
+		for rows.Next() {
+			var product Product
+			if err := rows.Scan(&product.ID, &product.SKU, &product.Name, &product.Price, &product.Quantity, &product.CategoryName, &product.Status,
+				&product.HasVariants, &product.RatingAverage, &product.RatingCount); err != nil {
+				log.Println("Erro ao escanear produto:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler produto"})
+			}
+			products = append(products, product)
+		}
+
+		if err := rows.Err(); err != nil {
+			log.Println("Erro com as linhas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar produtos"})
+		}
+
+		// Retorna array vazio se não houver produtos
+		if products == nil {
+			products = []Product{}
+		}
+
+		facets, err := loadSearchFacets(db, search)
+		if err != nil {
+			log.Println("Erro ao calcular facetas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao calcular facetas"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{
+			"products":    products,
+			"currentPage": page,
+			"totalCount":  totalCount,
+			"totalPages":  totalPages,
+			"searchTerm":  searchTerm,
+			"sort":        sortBy,
+			"facets":      facets,
+		})
+	}
+}
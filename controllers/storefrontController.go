@@ -0,0 +1,512 @@
+package controllers
+
+import (
+	"api/document"
+	"api/pagination"
+	"api/search"
+	"database/sql"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Tamanho máximo do slug da vitrine (coluna vendors.slug)
+const maxVendorSlugLength = 120
+
+// Tipos de região de entrega do vendor
+const (
+	DeliveryZoneState    = "state"
+	DeliveryZoneCity     = "city"
+	DeliveryZoneCEPRange = "cep_range"
+	DeliveryZoneRadius   = "radius"
+)
+
+// Formato aceito para o slug informado pelo vendor: letras minúsculas e
+// números separados por hífen
+var vendorSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Storefront é o perfil público do vendor exibido em /stores/:slug
+type Storefront struct {
+	ID            int      `json:"id"`
+	Slug          string   `json:"slug"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	Neighborhood  string   `json:"neighborhood"`
+	City          string   `json:"city"`
+	State         string   `json:"state"`
+	Country       string   `json:"country"`
+	LogoPath      *string  `json:"logo_path"`
+	BannerPath    *string  `json:"banner_path"`
+	RatingAverage *float64 `json:"rating_average"`
+	RatingCount   int      `json:"rating_count"`
+	// Dados cadastrais, presentes apenas para a equipe do vendor e administradores
+	Private *StorefrontPrivate `json:"private,omitempty"`
+}
+
+// StorefrontPrivate são os dados do vendor que não aparecem na vitrine pública
+type StorefrontPrivate struct {
+	Cnpj    string `json:"cnpj"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"`
+	Address string `json:"address"`
+	Cep     string `json:"cep"`
+	UsersId int    `json:"users_id"`
+	// Situação do cadastro do vendor
+	Status string `json:"status"`
+}
+
+// VendorDeliveryZone é uma região atendida pelo vendor
+type VendorDeliveryZone struct {
+	ID        int     `json:"id"`
+	VendorsID int     `json:"vendors_id"`
+	Type      string  `json:"type"`
+	State     string  `json:"state"`
+	City      *string `json:"city"`
+	// Faixa de CEPs atendida (tipo cep_range)
+	CepStart *string `json:"cep_start,omitempty"`
+	CepEnd   *string `json:"cep_end,omitempty"`
+	// Raio atendido a partir do CEP do vendor (tipo radius)
+	RadiusKm  *float64 `json:"radius_km,omitempty"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// VendorDeliveryZoneRequest é o corpo para cadastrar uma região de entrega.
+// Regiões do tipo city exigem a cidade; as do tipo state valem para o estado
+// inteiro; as do tipo cep_range exigem cep_start e cep_end de um mesmo estado;
+// as do tipo radius exigem radius_km, medido a partir do CEP do vendor.
+type VendorDeliveryZoneRequest struct {
+	Type     string  `json:"type"`
+	State    string  `json:"state"`
+	City     string  `json:"city"`
+	CepStart string  `json:"cep_start"`
+	CepEnd   string  `json:"cep_end"`
+	RadiusKm float64 `json:"radius_km"`
+}
+
+// slugify gera o slug a partir do nome ("Sítio São João" -> "sitio-sao-joao")
+func slugify(name string) string {
+	var builder strings.Builder
+	hyphen := false
+	for _, r := range search.Fold(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && builder.Len() > 0 {
+				builder.WriteByte('-')
+			}
+			builder.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+
+	slug := builder.String()
+	// Reserva espaço para o sufixo numérico de desempate
+	if len(slug) > maxVendorSlugLength-10 {
+		slug = strings.TrimRight(slug[:maxVendorSlugLength-10], "-")
+	}
+	if slug == "" {
+		slug = "loja"
+	}
+	return slug
+}
+
+// vendorSlugTaken indica se o slug já pertence a outro vendor
+func vendorSlugTaken(q sqlQueryer, slug string, vendorID int) (bool, error) {
+	var count int
+	err := q.QueryRow("SELECT COUNT(*) FROM vendors WHERE slug = ? AND id <> ?", slug, vendorID).Scan(&count)
+	return count > 0, err
+}
+
+// assignVendorSlug grava no vendor um slug livre derivado do nome, com sufixo
+// numérico quando outro vendor já usa o mesmo
+func assignVendorSlug(q sqlQueryer, vendorID int, name string) (string, error) {
+	base := slugify(name)
+	slug := base
+	for suffix := 2; ; suffix++ {
+		taken, err := vendorSlugTaken(q, slug, vendorID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			break
+		}
+		slug = base + "-" + strconv.Itoa(suffix)
+	}
+
+	if _, err := q.Exec("UPDATE vendors SET slug = ? WHERE id = ?", slug, vendorID); err != nil {
+		return "", err
+	}
+	return slug, nil
+}
+
+// EnsureVendorSlugs gera o slug dos vendors que ainda não têm um
+func EnsureVendorSlugs(db *sql.DB) error {
+	rows, err := db.Query("SELECT id, name FROM vendors WHERE slug IS NULL ORDER BY id")
+	if err != nil {
+		return err
+	}
+
+	type pendingVendor struct {
+		id   int
+		name string
+	}
+	var pending []pendingVendor
+	for rows.Next() {
+		var vendor pendingVendor
+		if err := rows.Scan(&vendor.id, &vendor.name); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, vendor)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, vendor := range pending {
+		if _, err := assignVendorSlug(db, vendor.id, vendor.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateVendorSlug confere o slug informado pelo vendor
+func validateVendorSlug(q sqlQueryer, value interface{}, vendorID int) (string, error) {
+	slug, ok := value.(string)
+	if !ok || len(slug) > maxVendorSlugLength || !vendorSlugPattern.MatchString(slug) {
+		return "", &requestError{400, "Slug inválido. Use letras minúsculas, números e hífens"}
+	}
+
+	taken, err := vendorSlugTaken(q, slug, vendorID)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		return "", &requestError{409, "Slug já está em uso por outro vendor"}
+	}
+	return slug, nil
+}
+
+// canSeeVendorPrivate indica se quem faz a requisição (X-User-ID) é da equipe
+// do vendor, em qualquer papel, ou um administrador. Sem o cabeçalho a
+// resposta é sempre pública.
+func canSeeVendorPrivate(q sqlQueryer, c *fiber.Ctx, vendorID int) (bool, error) {
+	userID, ok := callerUserID(c)
+	if !ok {
+		return false, nil
+	}
+	role, err := memberRole(q, vendorOrganization, vendorID, userID)
+	if err != nil || role != "" {
+		return role != "", err
+	}
+	return isAdminUser(q, userID)
+}
+
+// vendorPrivateAccess devolve a função que indica se quem faz a requisição
+// pode ver os dados privados do vendor informado, consultando a role de
+// administrador e as equipes do usuário uma única vez para listagens
+func vendorPrivateAccess(q sqlQueryer, c *fiber.Ctx) (func(vendorID int) bool, error) {
+	userID, ok := callerUserID(c)
+	if !ok {
+		return func(int) bool { return false }, nil
+	}
+
+	admin, err := isAdminUser(q, userID)
+	if err != nil {
+		return nil, err
+	}
+	memberOf, err := scanIDs(q.Query("SELECT vendors_id FROM vendor_members WHERE users_id = ?", userID))
+	if err != nil {
+		return nil, err
+	}
+	vendors := make(map[int]bool, len(memberOf))
+	for _, id := range memberOf {
+		vendors[id] = true
+	}
+	return func(vendorID int) bool { return admin || vendors[vendorID] }, nil
+}
+
+// hidePrivateFields remove do vendor os dados que só a equipe do vendor e os
+// administradores podem ver
+func (v *Vendor) hidePrivateFields() {
+	v.Cnpj = ""
+	v.StatusReason = nil
+}
+
+// authorizeVendorManager garante que quem faz a requisição é owner ou manager
+// do vendor, ou um administrador
+func authorizeVendorManager(q sqlQueryer, c *fiber.Ctx, vendorID int) error {
+	_, _, err := authorizeMember(q, c, vendorOrganization, vendorID, MemberManager)
+	return err
+}
+
+// loadDeliveryZones busca as regiões atendidas pelo vendor
+func loadDeliveryZones(q sqlQueryer, vendorID int) ([]VendorDeliveryZone, error) {
+	rows, err := q.Query(`
+		SELECT id, vendors_id, type, state, city, cep_start, cep_end, radius_km, created_at
+		FROM vendor_delivery_zones
+		WHERE vendors_id = ?
+		ORDER BY state, city IS NOT NULL, city`, vendorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	zones := []VendorDeliveryZone{}
+	for rows.Next() {
+		var zone VendorDeliveryZone
+		if err := rows.Scan(&zone.ID, &zone.VendorsID, &zone.Type, &zone.State, &zone.City,
+			&zone.CepStart, &zone.CepEnd, &zone.RadiusKm, &zone.CreatedAt); err != nil {
+			return nil, err
+		}
+		zones = append(zones, zone)
+	}
+	return zones, rows.Err()
+}
+
+// @Summary Vitrine do vendor
+// @Description Perfil público do vendor (logo, banner, avaliação média dos produtos, regiões atendidas e locais de retirada) com a página de produtos publicados. CNPJ e contatos aparecem em "private" apenas para a equipe do vendor ou administradores (cabeçalho X-User-ID)
+// @Tags Stores
+// @Param slug path string true "Slug da vitrine"
+// @Param X-User-ID header int false "ID do usuário que está consultando"
+// @Param cursor query string false "Cursor da próxima página de produtos"
+// @Param limit query int false "Limite de produtos por página" default(20)
+// @Param include_total query bool false "Inclui o total de produtos"
+// @Success 200 {object} map[string]interface{} "Perfil, regiões de entrega, locais de retirada e envelope de produtos"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
+// @Failure 404 {object} map[string]string "Vitrine não encontrada"
+// @Failure 500 {object} map[string]string "Erro ao buscar vitrine"
+// @Router /stores/{slug} [get]
+func GetStorefront(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		var store Storefront
+		var private StorefrontPrivate
+		err = db.QueryRow(`
+			SELECT id, slug, name, description, neighborhood, city, state, country, logo_path, banner_path,
+				cnpj, email, phone, address, cep, users_id, status
+			FROM agrofood.vendors
+			WHERE slug = ?`, c.Params("slug")).
+			Scan(&store.ID, &store.Slug, &store.Name, &store.Description, &store.Neighborhood, &store.City, &store.State,
+				&store.Country, &store.LogoPath, &store.BannerPath,
+				&private.Cnpj, &private.Email, &private.Phone, &private.Address, &private.Cep, &private.UsersId, &private.Status)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Vitrine não encontrada"})
+		} else if err != nil {
+			log.Println("Erro ao buscar vitrine:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar vitrine"})
+		}
+
+		allowed, err := canSeeVendorPrivate(db, c, store.ID)
+		if err != nil {
+			log.Println("Erro ao verificar permissões do usuário:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar permissões"})
+		}
+		if allowed {
+			private.Cnpj = document.FormatCNPJ(private.Cnpj)
+			store.Private = &private
+		} else if private.Status != VendorStatusApproved {
+			// Vitrines de vendors não aprovados só aparecem para a equipe do vendor
+			return c.Status(404).JSON(fiber.Map{"message": "Vitrine não encontrada"})
+		}
+
+		// Avaliações publicadas de todos os produtos do vendor
+		err = db.QueryRow(`
+			SELECT ROUND(AVG(r.rating), 2), COUNT(r.id)
+			FROM product_reviews r
+			INNER JOIN products p ON p.id = r.products_id
+			WHERE p.vendors_id = ? AND p.deleted_at IS NULL AND r.status = 'published'`, store.ID).
+			Scan(&store.RatingAverage, &store.RatingCount)
+		if err != nil {
+			log.Println("Erro ao calcular avaliação do vendor:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar vitrine"})
+		}
+
+		zones, err := loadDeliveryZones(db, store.ID)
+		if err != nil {
+			log.Println("Erro ao buscar regiões de entrega:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar vitrine"})
+		}
+
+		pickupPoints, err := loadPickupPoints(db, store.ID, true)
+		if err != nil {
+			log.Println("Erro ao buscar locais de retirada:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar vitrine"})
+		}
+
+		products, err := fetchProductPage(db, params, "p.vendors_id = ? AND "+listingProductCondition, store.ID)
+		if err != nil {
+			log.Println("Erro ao buscar produtos da vitrine:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar vitrine"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{
+			"store":          store,
+			"delivery_zones": zones,
+			"pickup_points":  pickupPoints,
+			"products":       products,
+		})
+	}
+}
+
+// @Summary Regiões de entrega do vendor
+// @Description Lista as regiões atendidas pelo vendor (estados, cidades, faixas de CEP e raios). Sem regiões cadastradas o vendor entrega em qualquer endereço
+// @Tags Vendors
+// @Param vendor_id path int true "ID do vendor"
+// @Success 200 {array} VendorDeliveryZone
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar regiões de entrega"
+// @Router /vendors/{vendor_id}/delivery-zones [get]
+func GetVendorDeliveryZones(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+
+		if _, err := vendorUserID(db, vendorID); err != nil {
+			return respondError(c, err, "Erro ao buscar vendor:", "Erro ao buscar regiões de entrega")
+		}
+
+		zones, err := loadDeliveryZones(db, vendorID)
+		if err != nil {
+			log.Println("Erro ao buscar regiões de entrega:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar regiões de entrega"})
+		}
+
+		return c.Status(200).JSON(zones)
+	}
+}
+
+// @Summary Cadastrar região de entrega
+// @Description Adiciona às regiões atendidas pelo vendor um estado, uma cidade, uma faixa de CEP ou um raio a partir do CEP do vendor. O raio exige que o CEP do vendor tenha coordenadas na base de CEPs. Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param zone body VendorDeliveryZoneRequest true "Região de entrega"
+// @Success 201 {object} VendorDeliveryZone
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 409 {object} map[string]string "Região já cadastrada"
+// @Failure 500 {object} map[string]string "Erro ao cadastrar região de entrega"
+// @Router /vendors/{vendor_id}/delivery-zones [post]
+func CreateVendorDeliveryZone(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+
+		var request VendorDeliveryZoneRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Erro ao analisar o corpo da requisição"})
+		}
+
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		zone, err := buildDeliveryZone(db, vendorID, request)
+		if err != nil {
+			return respondError(c, err, "Erro ao validar região de entrega:", "Erro ao cadastrar região de entrega")
+		}
+
+		var duplicates int
+		err = db.QueryRow(`
+			SELECT COUNT(*) FROM vendor_delivery_zones
+			WHERE vendors_id = ? AND type = ? AND state = ? AND city <=> ?
+				AND cep_start <=> ? AND cep_end <=> ? AND radius_km <=> ?`,
+			vendorID, zone.Type, zone.State, zone.City, zone.CepStart, zone.CepEnd, zone.RadiusKm).Scan(&duplicates)
+		if err != nil {
+			log.Println("Erro ao verificar região de entrega:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar região de entrega"})
+		}
+		if duplicates > 0 {
+			return c.Status(409).JSON(fiber.Map{"error": "Região de entrega já cadastrada"})
+		}
+
+		result, err := db.Exec(`
+			INSERT INTO vendor_delivery_zones (vendors_id, type, state, city, cep_start, cep_end, radius_km)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			vendorID, zone.Type, zone.State, zone.City, zone.CepStart, zone.CepEnd, zone.RadiusKm)
+		if err != nil {
+			log.Println("Erro ao cadastrar região de entrega:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar região de entrega"})
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			log.Println("Erro ao obter o ID da região de entrega:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar região de entrega"})
+		}
+
+		err = db.QueryRow("SELECT id, created_at FROM vendor_delivery_zones WHERE id = ?", id).Scan(&zone.ID, &zone.CreatedAt)
+		if err != nil {
+			log.Println("Erro ao buscar região de entrega:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao cadastrar região de entrega"})
+		}
+
+		return c.Status(201).JSON(zone)
+	}
+}
+
+// @Summary Remover região de entrega
+// @Description Remove uma região atendida pelo vendor. Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param zone_id path int true "ID da região de entrega"
+// @Success 200 {object} map[string]string "Região de entrega removida"
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Região de entrega não encontrada"
+// @Failure 500 {object} map[string]string "Erro ao remover região de entrega"
+// @Router /vendors/{vendor_id}/delivery-zones/{zone_id} [delete]
+func DeleteVendorDeliveryZone(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+		zoneID, err := strconv.Atoi(c.Params("zone_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID da região de entrega inválido"})
+		}
+
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		result, err := db.Exec("DELETE FROM vendor_delivery_zones WHERE id = ? AND vendors_id = ?", zoneID, vendorID)
+		if err != nil {
+			log.Println("Erro ao remover região de entrega:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover região de entrega"})
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.Println("Erro ao obter o número de linhas afetadas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover região de entrega"})
+		}
+		if rowsAffected == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Região de entrega não encontrada"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{"message": "Região de entrega removida com sucesso"})
+	}
+}
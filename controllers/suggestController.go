@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"api/search"
+	"database/sql"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Grupos de sugestões do autocompletar
+const (
+	suggestGroupProducts   = "products"
+	suggestGroupCategories = "categories"
+	suggestGroupVendors    = "vendors"
+)
+
+// Sugestões do autocompletar, reconstruídas a partir do banco
+var productSuggester = search.NewSuggester()
+
+// Consultas que carregam cada grupo de sugestões com sua popularidade:
+// produtos pela quantidade vendida, categorias pela quantidade de produtos e
+// vendors pela quantidade de pedidos. Apenas produtos publicados e vendors
+// aprovados são considerados; as vendas das variantes contam para o produto
+// principal.
+var suggestionQueries = map[string]string{
+	suggestGroupProducts: `
+		SELECT p.id, p.name, COALESCE(SUM(oi.quantity), 0)
+		FROM products p
+		LEFT JOIN products pv ON pv.id = p.id OR pv.parent_id = p.id
+		LEFT JOIN order_items oi ON oi.products_id = pv.id
+		WHERE ` + listingProductCondition + `
+		GROUP BY p.id, p.name`,
+	suggestGroupCategories: `
+		SELECT cp.id, cp.name, COUNT(p.id)
+		FROM categories_products cp
+		LEFT JOIN products p ON p.categories_products_id = cp.id AND ` + listingProductCondition + `
+		GROUP BY cp.id, cp.name`,
+	suggestGroupVendors: `
+		SELECT v.id, v.name, COUNT(o.id)
+		FROM vendors v
+		LEFT JOIN orders o ON o.vendors_id = v.id
+		WHERE v.status = 'approved'
+		GROUP BY v.id, v.name`,
+}
+
+// RefreshSuggestions reconstrói todos os grupos de sugestões
+func RefreshSuggestions(q sqlQueryer) error {
+	for group, query := range suggestionQueries {
+		rows, err := q.Query(query)
+		if err != nil {
+			return err
+		}
+
+		items := []search.Suggestion{}
+		for rows.Next() {
+			var item search.Suggestion
+			if err := rows.Scan(&item.ID, &item.Text, &item.Popularity); err != nil {
+				rows.Close()
+				return err
+			}
+			items = append(items, item)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		productSuggester.Replace(group, items)
+	}
+	return nil
+}
+
+// Indica que o catálogo mudou desde a última reconstrução das sugestões
+var suggestionsStale atomic.Bool
+
+// refreshSuggestionsLater marca as sugestões para reconstrução pelo
+// StartSuggestionRefresher. Reconstruir o conjunto inteiro a cada gravação
+// custaria uma leitura do catálogo por requisição.
+func refreshSuggestionsLater() {
+	suggestionsStale.Store(true)
+}
+
+// StartSuggestionRefresher reconstrói periodicamente as sugestões em segundo
+// plano, apenas quando houve alterações desde a última reconstrução
+func StartSuggestionRefresher(db *sql.DB, interval time.Duration) {
+	go func() {
+		for {
+			time.Sleep(interval)
+			if !suggestionsStale.Swap(false) {
+				continue
+			}
+			if err := RefreshSuggestions(db); err != nil {
+				log.Println("Erro ao atualizar sugestões de busca:", err)
+				suggestionsStale.Store(true)
+			}
+		}
+	}()
+}
+
+// @Summary Sugestões de busca
+// @Description Retorna sugestões por prefixo de produtos, categorias e vendors, ordenadas por popularidade
+// @Tags Products
+// @Param q query string true "Texto digitado"
+// @Param limit query int false "Quantidade máxima de sugestões por grupo" default(5)
+// @Success 200 {object} map[string]interface{} "Sugestões agrupadas"
+// @Router /products/suggest [get]
+func SuggestProducts(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		query := c.Query("q", "")
+		limit := c.QueryInt("limit", 5)
+		if limit < 1 || limit > 20 {
+			limit = 5
+		}
+
+		suggestions := productSuggester.Suggest(query, limit)
+
+		return c.Status(200).JSON(fiber.Map{
+			"query":      query,
+			"products":   suggestions[suggestGroupProducts],
+			"categories": suggestions[suggestGroupCategories],
+			"vendors":    suggestions[suggestGroupVendors],
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"api/document"
+	"api/pagination"
 	"database/sql"
 	"log"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -82,11 +85,22 @@ func validateUserData(db *sql.DB, cpf, username string, excludeID int) (*Validat
 // @Tags Users
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} User
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
 // @Router /users [get]
 func GetUsers(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		rows, err := db.Query("SELECT * FROM users_all")
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		keyset, keysetArgs := params.Keyset("id")
+
+		rows, err := db.Query("SELECT * FROM users_all WHERE "+keyset+" ORDER BY id DESC LIMIT ?",
+			append(keysetArgs, params.FetchLimit())...)
 		if err != nil {
 			log.Println("Erro ao consultar usuários:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Falha ao buscar usuários"})
@@ -108,7 +122,18 @@ func GetUsers(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar usuários"})
 		}
 
-		return c.Status(200).JSON(users)
+		page := pagination.NewPage(users, params, func(u User) int { return u.ID })
+
+		if params.IncludeTotal {
+			var total int
+			if err := db.QueryRow("SELECT COUNT(*) FROM users_all").Scan(&total); err != nil {
+				log.Println("Erro ao contar usuários:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha ao contar usuários"})
+			}
+			page = page.WithTotal(total)
+		}
+
+		return c.Status(200).JSON(page)
 	}
 }
 
@@ -137,6 +162,7 @@ func GetUserByID(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Falha ao buscar usuário"})
 		}
 
+		user.Cpf = document.FormatCPF(user.Cpf)
 		return c.Status(200).JSON(user)
 	}
 }
@@ -166,6 +192,7 @@ func GetUserDetailsByID(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Falha ao buscar detalhes do usuário"})
 		}
 
+		userDetails.Cpf = document.FormatCPF(userDetails.Cpf)
 		return c.Status(200).JSON(userDetails)
 	}
 }
@@ -251,8 +278,22 @@ func PatchUserByID(db *sql.DB) fiber.Handler {
 			params = append(params, user.Surname)
 		}
 		if user.Cpf != "" {
+			// O CPF é gravado só com dígitos e não pode pertencer a outro usuário
+			cpf, err := document.NormalizeCPF(user.Cpf)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+			}
+			userID, _ := strconv.Atoi(id)
+			validation, err := validateUserData(db, cpf, "", userID)
+			if err != nil {
+				log.Printf("Erro ao validar dados: %v", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha na validação"})
+			}
+			if validation.CpfExists {
+				return c.Status(400).JSON(fiber.Map{"error": "CPF já está cadastrado"})
+			}
 			query += "cpf = ?, "
-			params = append(params, user.Cpf)
+			params = append(params, cpf)
 		}
 		if user.RolesId != 0 {
 			query += "roles_id = ?, "
@@ -305,6 +346,13 @@ func CreateUser(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "Campos obrigatórios ausentes"})
 		}
 
+		// O CPF é gravado só com dígitos, sem pontuação
+		cpf, err := document.NormalizeCPF(user.Cpf)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		user.Cpf = cpf
+
 		// Validação otimizada em uma única consulta
 		validation, err := validateUserData(db, user.Cpf, user.Username, 0)
 		if err != nil {
@@ -371,6 +419,7 @@ func GetUserByUsername(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Falha ao buscar usuário"})
 		}
 
+		user.Cpf = document.FormatCPF(user.Cpf)
 		return c.Status(200).JSON(user)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,670 @@
+package controllers
+
+import (
+	"database/sql"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Limites dos atributos de variante (colunas de product_variant_attributes)
+const (
+	maxVariantAttributeName  = 50
+	maxVariantAttributeValue = 100
+)
+
+// Condição SQL dos produtos exibidos nas vitrines e na busca (alias p): os
+// publicados que não são variantes de outro produto
+const listingProductCondition = publicProductCondition + " AND p.parent_id IS NULL"
+
+// Condição SQL das variantes publicadas do produto de alias p (alias pv)
+const publicVariantCondition = "pv.parent_id = p.id AND pv.status = 'published' AND pv.deleted_at IS NULL"
+
+// Condição SQL dos produtos (alias p) que têm variantes e por isso não são
+// vendidos diretamente
+const productHasVariantsCondition = "EXISTS (SELECT 1 FROM products pv WHERE pv.parent_id = p.id AND pv.deleted_at IS NULL)"
+
+// Preço e estoque exibidos nas vitrines (alias p): o menor preço e a soma do
+// estoque das variantes publicadas, ou os do próprio produto sem variantes
+const (
+	listingPriceExpr    = "COALESCE((SELECT MIN(pv.price) FROM products pv WHERE " + publicVariantCondition + "), p.price)"
+	listingQuantityExpr = "COALESCE((SELECT SUM(pv.quantity) FROM products pv WHERE " + publicVariantCondition + "), p.quantity)"
+)
+
+// Condição SQL dos produtos (alias p) com estoque vendável próprio ou em
+// alguma variante publicada. A subconsulta reusa o alias p para as variantes.
+const listingInStockCondition = "((NOT " + productHasVariantsCondition + " AND " + sellableQuantityExpr + " > 0) OR p.id IN (" +
+	"SELECT p.parent_id FROM products p WHERE p.parent_id IS NOT NULL AND " + publicProductCondition + " AND " + sellableQuantityExpr + " > 0))"
+
+// Descrição da variante (alias p) a partir dos seus atributos, como
+// "embalagem: 25 kg, grau: especial"; nula para produtos sem atributos
+const variantLabelExpr = `(SELECT GROUP_CONCAT(CONCAT(va.name, ': ', va.value) ORDER BY va.name SEPARATOR ', ')
+	FROM product_variant_attributes va WHERE va.products_id = p.id)`
+
+// ProductVariant é uma variante do produto principal
+type ProductVariant struct {
+	ID       int     `json:"id"`
+	ParentID int     `json:"parent_id"`
+	SKU      string  `json:"sku"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Quantity int     `json:"quantity"`
+	// Estoque vendável (sem lotes vencidos nem reservas)
+	Available         int               `json:"available"`
+	Status            string            `json:"status"`
+	VariantAttributes map[string]string `json:"variant_attributes"`
+}
+
+// ProductVariantRequest cria uma variante do produto ou vincula como
+// variante um produto existente (product_id) do mesmo vendor e categoria.
+// Vendor, categoria, descrição e atributos da categoria vêm do produto
+// principal; o nome padrão é o do principal seguido dos atributos da variante.
+type ProductVariantRequest struct {
+	ProductID        *int                   `json:"product_id,omitempty"`
+	SKU              string                 `json:"sku"`
+	Name             string                 `json:"name"`
+	Price            string                 `json:"price"`
+	Quantity         string                 `json:"quantity"`
+	Status           string                 `json:"status"`
+	ReorderThreshold *int                   `json:"reorder_threshold,omitempty"`
+	Attributes       map[string]interface{} `json:"attributes,omitempty"`
+	// Atributos que distinguem a variante, como embalagem, classificação e tamanho
+	VariantAttributes map[string]string `json:"variant_attributes"`
+}
+
+// ProductVariantUpdate substitui os atributos da variante
+type ProductVariantUpdate struct {
+	VariantAttributes map[string]string `json:"variant_attributes"`
+}
+
+// normalizeVariantAttributes valida os atributos de variante, removendo
+// espaços nas pontas. Nomes são gravados em minúsculas para que "Embalagem" e
+// "embalagem" sejam o mesmo atributo.
+func normalizeVariantAttributes(raw map[string]string) (map[string]string, error) {
+	if len(raw) == 0 {
+		return nil, &requestError{400, "Informe ao menos um atributo da variante"}
+	}
+	attributes := make(map[string]string, len(raw))
+	for name, value := range raw {
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if name == "" || value == "" {
+			return nil, &requestError{400, "Atributos da variante não podem ser vazios"}
+		}
+		if len(name) > maxVariantAttributeName || len(value) > maxVariantAttributeValue {
+			return nil, &requestError{400, "Atributo da variante muito longo: " + name}
+		}
+		if _, duplicated := attributes[name]; duplicated {
+			return nil, &requestError{400, "Atributo da variante repetido: " + name}
+		}
+		attributes[name] = value
+	}
+	return attributes, nil
+}
+
+// variantAttributesKey identifica a combinação de atributos da variante
+func variantAttributesKey(attributes map[string]string) string {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + strings.ToLower(attributes[name])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// variantLabel descreve a variante pelos valores dos atributos, em ordem de nome
+func variantLabel(attributes map[string]string) string {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = attributes[name]
+	}
+	return strings.Join(values, ", ")
+}
+
+// loadVariantAttributes carrega os atributos de variante dos produtos informados
+func loadVariantAttributes(q sqlQueryer, productIDs ...int) (map[int]map[string]string, error) {
+	attributes := make(map[int]map[string]string, len(productIDs))
+	if len(productIDs) == 0 {
+		return attributes, nil
+	}
+
+	rows, err := q.Query("SELECT products_id, name, value FROM product_variant_attributes WHERE products_id IN ("+placeholders(len(productIDs))+")",
+		intArgs(productIDs)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int
+		var name, value string
+		if err := rows.Scan(&productID, &name, &value); err != nil {
+			return nil, err
+		}
+		if attributes[productID] == nil {
+			attributes[productID] = make(map[string]string)
+		}
+		attributes[productID][name] = value
+	}
+	return attributes, rows.Err()
+}
+
+// saveVariantAttributes substitui os atributos de variante do produto
+func saveVariantAttributes(q sqlQueryer, productID interface{}, attributes map[string]string) error {
+	if _, err := q.Exec("DELETE FROM product_variant_attributes WHERE products_id = ?", productID); err != nil {
+		return err
+	}
+	for name, value := range attributes {
+		if _, err := q.Exec("INSERT INTO product_variant_attributes (products_id, name, value) VALUES (?, ?, ?)",
+			productID, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureUniqueVariant impede duas variantes do mesmo produto com a mesma
+// combinação de atributos; exceptID ignora a própria variante na edição
+func ensureUniqueVariant(q sqlQueryer, parentID, exceptID int, attributes map[string]string) error {
+	rows, err := q.Query("SELECT id FROM products WHERE parent_id = ? AND id <> ? AND deleted_at IS NULL", parentID, exceptID)
+	if err != nil {
+		return err
+	}
+	var siblingIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		siblingIDs = append(siblingIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	siblings, err := loadVariantAttributes(q, siblingIDs...)
+	if err != nil {
+		return err
+	}
+	key := variantAttributesKey(attributes)
+	for _, sibling := range siblings {
+		if variantAttributesKey(sibling) == key {
+			return &requestError{409, "Já existe uma variante com estes atributos"}
+		}
+	}
+	return nil
+}
+
+// productHasVariants indica se o produto tem variantes; nesse caso a compra
+// é feita pela variante escolhida
+func productHasVariants(q sqlQueryer, productID interface{}) (bool, error) {
+	var hasVariants bool
+	err := q.QueryRow("SELECT "+productHasVariantsCondition+" FROM products p WHERE p.id = ?", productID).Scan(&hasVariants)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return hasVariants, err
+}
+
+// listingIDsCondition seleciona para reindexação os produtos informados e os
+// produtos principais das variantes entre eles
+func listingIDsCondition(productIDs []int) (string, []interface{}) {
+	list := placeholders(len(productIDs))
+	args := intArgs(productIDs)
+	return "(p.id IN (" + list + ") OR p.id IN (SELECT parent_id FROM products WHERE id IN (" + list + ")))", append(args, args...)
+}
+
+// loadProductVariants lista as variantes não excluídas do produto, da mais
+// barata para a mais cara
+func loadProductVariants(q sqlQueryer, parentID int) ([]ProductVariant, error) {
+	rows, err := q.Query(`
+		SELECT p.id, p.parent_id, p.sku, p.name, p.price, p.quantity, `+sellableQuantityExpr+`, p.status
+		FROM products p
+		WHERE p.parent_id = ? AND p.deleted_at IS NULL
+		ORDER BY p.price, p.id`, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variants []ProductVariant
+	var ids []int
+	for rows.Next() {
+		var variant ProductVariant
+		if err := rows.Scan(&variant.ID, &variant.ParentID, &variant.SKU, &variant.Name, &variant.Price, &variant.Quantity,
+			&variant.Available, &variant.Status); err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+		ids = append(ids, variant.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	attributes, err := loadVariantAttributes(q, ids...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range variants {
+		variants[i].VariantAttributes = attributes[variants[i].ID]
+	}
+	return variants, nil
+}
+
+// variantParent é o produto principal travado para alteração das variantes
+type variantParent struct {
+	ID          int
+	UsersID     int
+	VendorsID   *int
+	CategoryID  int
+	Name        string
+	Description string
+}
+
+// sameProductOwner indica se dois produtos são do mesmo vendor ou, sem
+// vendor, do mesmo usuário
+func sameProductOwner(usersA int, vendorA *int, usersB int, vendorB *int) bool {
+	if vendorA == nil || vendorB == nil {
+		return vendorA == nil && vendorB == nil && usersA == usersB
+	}
+	return *vendorA == *vendorB
+}
+
+// lockVariantParent trava o produto principal, que não pode ser variante
+func lockVariantParent(tx *sql.Tx, parentID interface{}) (*variantParent, error) {
+	var parent variantParent
+	var isVariant bool
+	err := tx.QueryRow(`
+		SELECT id, users_id, vendors_id, categories_products_id, name, COALESCE(description, ''), parent_id IS NOT NULL
+		FROM products WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, parentID).
+		Scan(&parent.ID, &parent.UsersID, &parent.VendorsID, &parent.CategoryID, &parent.Name, &parent.Description, &isVariant)
+	if err == sql.ErrNoRows {
+		return nil, &requestError{404, "Produto não encontrado"}
+	} else if err != nil {
+		return nil, err
+	}
+	if isVariant {
+		return nil, &requestError{409, "Uma variante não pode ter variantes"}
+	}
+	return &parent, nil
+}
+
+// createProductVariant cria ou vincula a variante na transação e devolve o
+// ID da variante e o da revisão aberta, em categorias moderadas. A variante
+// nova é registrada como cadastrada por createdBy.
+func createProductVariant(tx *sql.Tx, parent *variantParent, request ProductVariantRequest, createdBy int) (variantID int64, moderationID int64, err error) {
+	attributes, err := normalizeVariantAttributes(request.VariantAttributes)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := ensureUniqueVariant(tx, parent.ID, 0, attributes); err != nil {
+		return 0, 0, err
+	}
+
+	if request.ProductID != nil {
+		variantID = int64(*request.ProductID)
+		if variantID == int64(parent.ID) {
+			return 0, 0, &requestError{400, "Um produto não pode ser variante de si mesmo"}
+		}
+
+		var usersID, categoryID int
+		var vendorID *int
+		var isVariant bool
+		err := tx.QueryRow("SELECT users_id, vendors_id, categories_products_id, parent_id IS NOT NULL FROM products WHERE id = ? AND deleted_at IS NULL FOR UPDATE", variantID).
+			Scan(&usersID, &vendorID, &categoryID, &isVariant)
+		if err == sql.ErrNoRows {
+			return 0, 0, &requestError{404, "Produto da variante não encontrado"}
+		} else if err != nil {
+			return 0, 0, err
+		}
+		if isVariant {
+			return 0, 0, &requestError{409, "O produto já é variante de outro produto"}
+		}
+		if !sameProductOwner(usersID, vendorID, parent.UsersID, parent.VendorsID) {
+			return 0, 0, &requestError{400, "A variante deve ser do mesmo vendor do produto principal"}
+		}
+		if categoryID != parent.CategoryID {
+			return 0, 0, &requestError{400, "A variante deve ser da mesma categoria do produto principal"}
+		}
+		hasVariants, err := productHasVariants(tx, variantID)
+		if err != nil {
+			return 0, 0, err
+		}
+		if hasVariants {
+			return 0, 0, &requestError{409, "Um produto com variantes não pode ser variante"}
+		}
+	} else {
+		// Atributos da categoria herdados do principal, com os da variante por cima
+		categoryAttributes, err := loadProductAttributeValues(tx, parent.ID)
+		if err != nil {
+			return 0, 0, err
+		}
+		for name, value := range request.Attributes {
+			categoryAttributes[name] = value
+		}
+
+		product := ProductCreate{
+			SKU:              request.SKU,
+			Name:             request.Name,
+			Description:      parent.Description,
+			Price:            request.Price,
+			UsersId:          createdBy,
+			VendorsID:        parent.VendorsID,
+			Quantity:         request.Quantity,
+			CategoryId:       parent.CategoryID,
+			Status:           request.Status,
+			ReorderThreshold: request.ReorderThreshold,
+			Attributes:       categoryAttributes,
+		}
+		if product.Name == "" {
+			product.Name = parent.Name + " - " + variantLabel(attributes)
+		}
+		variantID, moderationID, err = createProduct(tx, &product, "Estoque inicial da variante")
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE products SET parent_id = ? WHERE id = ?", parent.ID, variantID); err != nil {
+		return 0, 0, err
+	}
+	if err := saveVariantAttributes(tx, variantID, attributes); err != nil {
+		return 0, 0, err
+	}
+
+	// O produto principal passa a ser vendido apenas pelas variantes
+	if err := releaseReservations(tx, "r.products_id = ?", parent.ID); err != nil {
+		return 0, 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM cart_items WHERE products_id = ?", parent.ID); err != nil {
+		return 0, 0, err
+	}
+
+	return variantID, moderationID, nil
+}
+
+// @Summary Variantes do produto
+// @Description Lista as variantes do produto (embalagem, classificação, tamanho), cada uma com SKU, preço e estoque próprios
+// @Tags Products
+// @Param id path int true "ID do produto principal"
+// @Success 200 {object} map[string]interface{} "Variantes do produto"
+// @Failure 404 {object} map[string]string "Produto não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar variantes"
+// @Router /products/id/{id}/variants [get]
+func GetProductVariants(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		productID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+
+		var exists int
+		if err := db.QueryRow("SELECT COUNT(*) FROM products WHERE id = ? AND deleted_at IS NULL", productID).Scan(&exists); err != nil {
+			log.Println("Erro ao verificar produto:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar variantes"})
+		}
+		if exists == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Produto não encontrado"})
+		}
+
+		variants, err := loadProductVariants(db, productID)
+		if err != nil {
+			log.Println("Erro ao buscar variantes:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar variantes"})
+		}
+		if variants == nil {
+			variants = []ProductVariant{}
+		}
+
+		return c.Status(200).JSON(fiber.Map{"products_id": productID, "data": variants})
+	}
+}
+
+// @Summary Criar variante do produto
+// @Description Cria uma variante do produto com SKU, preço, estoque e atributos de variante próprios, ou vincula como variante um produto existente (product_id) do mesmo vendor e categoria. Depois disso o produto principal é vendido apenas pelas variantes e sai dos carrinhos.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do produto principal"
+// @Param X-User-ID header int true "ID do usuário"
+// @Param variant body ProductVariantRequest true "Dados da variante"
+// @Success 201 {object} map[string]interface{} "Variante criada"
+// @Success 202 {object} map[string]interface{} "Variante criada e aguardando moderação"
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Produto pertence a outro vendor"
+// @Failure 404 {object} map[string]string "Produto não encontrado"
+// @Failure 409 {object} map[string]string "Variante repetida ou produto já vinculado"
+// @Failure 500 {object} map[string]string "Erro ao criar variante"
+// @Router /products/id/{id}/variants [post]
+func CreateProductVariant(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request ProductVariantRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+		}
+
+		actor, err := authorizeProductManager(db, c, c.Params("id"))
+		if err != nil {
+			return respondError(c, err, "Erro ao verificar permissões:", "Erro ao criar variante")
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar variante"})
+		}
+		defer tx.Rollback()
+
+		parent, err := lockVariantParent(tx, c.Params("id"))
+		if err != nil {
+			return respondError(c, err, "Erro ao buscar produto principal:", "Erro ao criar variante")
+		}
+
+		variantID, moderationID, err := createProductVariant(tx, parent, request, actor)
+		if err != nil {
+			return respondError(c, err, "Erro ao criar variante:", "Erro ao criar variante")
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar variante"})
+		}
+
+		// O produto vinculado deixa de aparecer sozinho na busca
+		productIndex.Remove(int(variantID))
+		reindexProduct(db, parent.ID)
+
+		variants, err := loadProductVariants(db, parent.ID)
+		if err != nil {
+			log.Println("Erro ao buscar variantes:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar variantes"})
+		}
+		var variant ProductVariant
+		for _, candidate := range variants {
+			if candidate.ID == int(variantID) {
+				variant = candidate
+			}
+		}
+
+		if moderationID > 0 {
+			return c.Status(202).JSON(fiber.Map{
+				"message":       "Variante criada e enviada para moderação",
+				"variant":       variant,
+				"moderation_id": moderationID,
+			})
+		}
+		return c.Status(201).JSON(fiber.Map{
+			"message": "Variante criada com sucesso",
+			"variant": variant,
+		})
+	}
+}
+
+// @Summary Atualizar atributos da variante
+// @Description Substitui os atributos de variante (embalagem, classificação, tamanho). Preço, estoque e status da variante são alterados em PATCH /products/id/{variant_id}
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do produto principal"
+// @Param variant_id path int true "ID da variante"
+// @Param X-User-ID header int true "ID do usuário"
+// @Param attributes body ProductVariantUpdate true "Atributos da variante"
+// @Success 200 {object} map[string]interface{} "Variante atualizada"
+// @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Produto pertence a outro vendor"
+// @Failure 404 {object} map[string]string "Variante não encontrada"
+// @Failure 409 {object} map[string]string "Variante repetida"
+// @Failure 500 {object} map[string]string "Erro ao atualizar variante"
+// @Router /products/id/{id}/variants/{variant_id} [put]
+func UpdateProductVariant(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		parentID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+		variantID, err := strconv.Atoi(c.Params("variant_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID da variante inválido"})
+		}
+
+		var request ProductVariantUpdate
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+		}
+		attributes, err := normalizeVariantAttributes(request.VariantAttributes)
+		if err != nil {
+			return respondError(c, err, "Erro ao validar variante:", "Erro ao atualizar variante")
+		}
+
+		if _, err := authorizeProductManager(db, c, parentID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões:", "Erro ao atualizar variante")
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar variante"})
+		}
+		defer tx.Rollback()
+
+		if _, err := lockVariantParent(tx, parentID); err != nil {
+			return respondError(c, err, "Erro ao buscar produto principal:", "Erro ao atualizar variante")
+		}
+
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM products WHERE id = ? AND parent_id = ? AND deleted_at IS NULL", variantID, parentID).
+			Scan(&exists); err != nil {
+			log.Println("Erro ao verificar variante:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar variante"})
+		}
+		if exists == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Variante não encontrada"})
+		}
+
+		if err := ensureUniqueVariant(tx, parentID, variantID, attributes); err != nil {
+			return respondError(c, err, "Erro ao verificar variantes:", "Erro ao atualizar variante")
+		}
+		if err := saveVariantAttributes(tx, variantID, attributes); err != nil {
+			log.Println("Erro ao gravar atributos da variante:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar variante"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar variante"})
+		}
+
+		reindexProduct(db, parentID)
+
+		return c.Status(200).JSON(fiber.Map{
+			"message":            "Variante atualizada com sucesso",
+			"id":                 variantID,
+			"variant_attributes": attributes,
+		})
+	}
+}
+
+// @Summary Desvincular variante
+// @Description Desvincula a variante do produto principal; ela continua existindo como produto independente, com SKU, preço e estoque
+// @Tags Products
+// @Param id path int true "ID do produto principal"
+// @Param variant_id path int true "ID da variante"
+// @Param X-User-ID header int true "ID do usuário"
+// @Success 200 {object} map[string]interface{} "Variante desvinculada"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Produto pertence a outro vendor"
+// @Failure 404 {object} map[string]string "Variante não encontrada"
+// @Failure 500 {object} map[string]string "Erro ao desvincular variante"
+// @Router /products/id/{id}/variants/{variant_id} [delete]
+func DetachProductVariant(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		parentID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID inválido"})
+		}
+		variantID, err := strconv.Atoi(c.Params("variant_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID da variante inválido"})
+		}
+
+		if _, err := authorizeProductManager(db, c, parentID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões:", "Erro ao desvincular variante")
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao desvincular variante"})
+		}
+		defer tx.Rollback()
+
+		result, err := tx.Exec("UPDATE products SET parent_id = NULL WHERE id = ? AND parent_id = ?", variantID, parentID)
+		if err != nil {
+			log.Println("Erro ao desvincular variante:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao desvincular variante"})
+		}
+		if affected, err := result.RowsAffected(); err != nil {
+			log.Println("Erro ao desvincular variante:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao desvincular variante"})
+		} else if affected == 0 {
+			return c.Status(404).JSON(fiber.Map{"message": "Variante não encontrada"})
+		}
+
+		if _, err := tx.Exec("DELETE FROM product_variant_attributes WHERE products_id = ?", variantID); err != nil {
+			log.Println("Erro ao remover atributos da variante:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao desvincular variante"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao desvincular variante"})
+		}
+
+		reindexProduct(db, parentID)
+		reindexProduct(db, variantID)
+
+		return c.Status(200).JSON(fiber.Map{
+			"message": "Variante desvinculada com sucesso",
+			"id":      variantID,
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"api/document"
+	"api/pagination"
 	"database/sql"
 	"fmt" // ⭐ Adicione se não tiver
 	"log"
@@ -32,7 +34,28 @@ type Vendor struct {
 	Email        string `json:"email"`
 	UsersId      int    `json:"users_id"`
 	Cep          string `json:"cep"`
-	Cnpj         string `json:"cnpj"`
+	// Omitido para quem não é da equipe do vendor nem administrador
+	Cnpj string `json:"cnpj,omitempty"`
+	// Endereço da vitrine pública (/stores/:slug) e imagens exibidas nela
+	Slug       *string `json:"slug"`
+	LogoPath   *string `json:"logo_path"`
+	BannerPath *string `json:"banner_path"`
+	// Situação do cadastro; apenas vendors aprovados vendem
+	Status       string  `json:"status"`
+	StatusReason *string `json:"status_reason,omitempty"`
+}
+
+// Colunas lidas por scanVendor
+const vendorColumns = `id, name, description, address, neighborhood, city, state, country, phone, email, users_id, cep, cnpj,
+	slug, logo_path, banner_path, status, status_reason`
+
+func scanVendor(row interface{ Scan(...interface{}) error }) (Vendor, error) {
+	var vendor Vendor
+	err := row.Scan(&vendor.ID, &vendor.Name, &vendor.Description, &vendor.Address, &vendor.Neighborhood, &vendor.City, &vendor.State,
+		&vendor.Country, &vendor.Phone, &vendor.Email, &vendor.UsersId, &vendor.Cep, &vendor.Cnpj, &vendor.Slug, &vendor.LogoPath,
+		&vendor.BannerPath, &vendor.Status, &vendor.StatusReason)
+	vendor.Cnpj = document.FormatCNPJ(vendor.Cnpj)
+	return vendor, err
 }
 
 type VendorValidationResult struct {
@@ -48,13 +71,20 @@ type CheckoutResponse struct {
 	Orders      []OrderDetail `json:"orders"` // ✅ Com info do vendor
 }
 
+// CheckoutRequest são os dados do checkout. Com address_id os campos de
+// entrega vêm do catálogo de endereços do comprador.
 type CheckoutRequest struct {
 	PaymentMethod   string `json:"payment_method"`
+	AddressID       *int   `json:"address_id,omitempty"`
 	ShippingAddress string `json:"shipping_address"`
 	ShippingCity    string `json:"shipping_city"`
 	ShippingState   string `json:"shipping_state"`
 	ShippingCEP     string `json:"shipping_cep"`
 	BuyersID        *int   `json:"buyers_id,omitempty"`
+	// Retirada no local do vendor, no checkout multi-vendor; os demais vendors entregam
+	Pickups []CheckoutPickup `json:"pickups,omitempty"`
+	// Janela de entrega escolhida por vendor, no checkout multi-vendor
+	Slots []CheckoutSlot `json:"slots,omitempty"`
 }
 
 // Struct para pedido com vendor
@@ -82,8 +112,10 @@ type OrderItemWithVendor struct {
 	OrdersID    int     `json:"orders_id"`
 	ProductsID  int     `json:"products_id"`
 	ProductName string  `json:"product_name"`
-	VendorID    int     `json:"vendor_id"`
-	VendorName  string  `json:"vendor_name"`
+	// Variante escolhida, como "embalagem: 25 kg"
+	VariantLabel *string `json:"variant_label,omitempty"`
+	VendorID     int     `json:"vendor_id"`
+	VendorName   string  `json:"vendor_name"`
 }
 
 // Define struct para pedido
@@ -134,6 +166,11 @@ type OrderDetail struct {
 	UsersID         int        `json:"users_id"`
 	BuyersID        *int       `json:"buyers_id,omitempty"`
 	Vendor          VendorInfo `json:"vendor"`
+	// Entrega (delivery) ou retirada (pickup) no local informado
+	Fulfillment string             `json:"fulfillment"`
+	PickupPoint *VendorPickupPoint `json:"pickup_point,omitempty"`
+	// Janela de entrega agendada, quando escolhida no checkout
+	DeliverySlot *DeliverySlot `json:"delivery_slot,omitempty"`
 }
 
 // validateVendorData - Função otimizada que usa uma única query para validar CNPJ e email
@@ -174,19 +211,32 @@ func validateVendorData(db *sql.DB, cnpj, email string, excludeID int) (*VendorV
 }
 
 // @Summary Obter todos os vendors
-// @Description Obtém todos os vendors
+// @Description Obtém os vendors com paginação por cursor
 // @Tags Vendors
-// @Success 200 {array} Vendor
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
 // @Failure 500 {object} map[string]string "Erro ao buscar vendors"
 // @Router /vendors [get]
 func GetAllVendors(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		keyset, keysetArgs := params.Keyset("id")
+
 		vendorsQuery := `
-            SELECT id, name, description, address, neighborhood, city, state, country, phone, email, users_id, cep, cnpj
+            SELECT ` + vendorColumns + `
             FROM agrofood.vendors
+            WHERE ` + keyset + `
+            ORDER BY id DESC
+            LIMIT ?
         `
 
-		rows, err := db.Query(vendorsQuery)
+		rows, err := db.Query(vendorsQuery, append(keysetArgs, params.FetchLimit())...)
 		if err != nil {
 			log.Println("Erro ao buscar vendors:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar vendors"})
@@ -195,8 +245,8 @@ func GetAllVendors(db *sql.DB) fiber.Handler {
 
 		var vendors []Vendor
 		for rows.Next() {
-			var vendor Vendor
-			if err := rows.Scan(&vendor.ID, &vendor.Name, &vendor.Description, &vendor.Address, &vendor.Neighborhood, &vendor.City, &vendor.State, &vendor.Country, &vendor.Phone, &vendor.Email, &vendor.UsersId, &vendor.Cep, &vendor.Cnpj); err != nil {
+			vendor, err := scanVendor(rows)
+			if err != nil {
 				log.Println("Erro ao escanear vendor:", err)
 				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler vendor"})
 			}
@@ -208,7 +258,29 @@ func GetAllVendors(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar vendors"})
 		}
 
-		return c.Status(200).JSON(vendors)
+		canSeePrivate, err := vendorPrivateAccess(db, c)
+		if err != nil {
+			log.Println("Erro ao verificar permissões do usuário:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar permissões"})
+		}
+		for i := range vendors {
+			if !canSeePrivate(vendors[i].ID) {
+				vendors[i].hidePrivateFields()
+			}
+		}
+
+		page := pagination.NewPage(vendors, params, func(v Vendor) int { return v.ID })
+
+		if params.IncludeTotal {
+			var total int
+			if err := db.QueryRow("SELECT COUNT(*) FROM agrofood.vendors").Scan(&total); err != nil {
+				log.Println("Erro ao contar vendors:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao contar vendors"})
+			}
+			page = page.WithTotal(total)
+		}
+
+		return c.Status(200).JSON(page)
 	}
 }
 
@@ -225,13 +297,12 @@ func GetVendorByID(db *sql.DB) fiber.Handler {
 		vendorID := c.Params("id")
 
 		vendorQuery := `
-            SELECT id, name, description, address, neighborhood, city, state, country, phone, email, users_id, cep, cnpj
+            SELECT ` + vendorColumns + `
             FROM agrofood.vendors
             WHERE id = ?
         `
 
-		var vendor Vendor
-		err := db.QueryRow(vendorQuery, vendorID).Scan(&vendor.ID, &vendor.Name, &vendor.Description, &vendor.Address, &vendor.Neighborhood, &vendor.City, &vendor.State, &vendor.Country, &vendor.Phone, &vendor.Email, &vendor.UsersId, &vendor.Cep, &vendor.Cnpj)
+		vendor, err := scanVendor(db.QueryRow(vendorQuery, vendorID))
 		if err != nil {
 			if err == sql.ErrNoRows {
 				return c.Status(404).JSON(fiber.Map{"error": "Vendor não encontrado"})
@@ -240,12 +311,21 @@ func GetVendorByID(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar vendor"})
 		}
 
+		canSeePrivate, err := canSeeVendorPrivate(db, c, vendor.ID)
+		if err != nil {
+			log.Println("Erro ao verificar permissões do usuário:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar permissões"})
+		}
+		if !canSeePrivate {
+			vendor.hidePrivateFields()
+		}
+
 		return c.Status(200).JSON(vendor)
 	}
 }
 
 // @Summary Criar um novo vendor
-// @Description Cria um novo vendor
+// @Description Cria um novo vendor com o cadastro submetido; ele só vende depois de enviar os documentos e ser aprovado
 // @Tags Vendors
 // @Accept json
 // @Produce json
@@ -262,6 +342,18 @@ func CreateVendor(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "Erro ao analisar o corpo da requisição"})
 		}
 
+		// O CNPJ é gravado só com dígitos e letras, sem pontuação
+		cnpj, err := document.NormalizeCNPJ(vendor.Cnpj)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		vendor.Cnpj = cnpj
+
+		// CEP só com dígitos e estado pela sigla da UF
+		if vendor.Cep, vendor.State, err = normalizeAddress(vendor.Cep, vendor.State); err != nil {
+			return respondError(c, err, "Erro ao validar endereço do vendor:", "Falha na validação")
+		}
+
 		// Validação otimizada em uma única consulta
 		validation, err := validateVendorData(db, vendor.Cnpj, vendor.Email, 0)
 		if err != nil {
@@ -280,12 +372,19 @@ func CreateVendor(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "Email já está cadastrado"})
 		}
 
+		// Sem slug informado, ele é gerado a partir do nome após o cadastro
+		if vendor.Slug != nil {
+			if _, err := validateVendorSlug(db, *vendor.Slug, 0); err != nil {
+				return respondError(c, err, "Erro ao validar slug do vendor:", "Falha na validação")
+			}
+		}
+
 		insertQuery := `
-            INSERT INTO agrofood.vendors (name, description, address, neighborhood, city, state, country, phone, email, users_id, cep, cnpj)
-            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+            INSERT INTO agrofood.vendors (name, description, address, neighborhood, city, state, country, phone, email, users_id, cep, cnpj, slug, logo_path, banner_path)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
         `
 
-		result, err := db.Exec(insertQuery, vendor.Name, vendor.Description, vendor.Address, vendor.Neighborhood, vendor.City, vendor.State, vendor.Country, vendor.Phone, vendor.Email, vendor.UsersId, vendor.Cep, vendor.Cnpj)
+		result, err := db.Exec(insertQuery, vendor.Name, vendor.Description, vendor.Address, vendor.Neighborhood, vendor.City, vendor.State, vendor.Country, vendor.Phone, vendor.Email, vendor.UsersId, vendor.Cep, vendor.Cnpj, vendor.Slug, vendor.LogoPath, vendor.BannerPath)
 		if err != nil {
 			log.Println("Erro ao criar vendor:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar vendor"})
@@ -298,7 +397,27 @@ func CreateVendor(db *sql.DB) fiber.Handler {
 		}
 
 		vendor.ID = int(id)
-		return c.Status(200).JSON(fiber.Map{"message": "Vendor cadastrado com sucesso!"})
+		// Quem cadastra o vendor é o primeiro owner da equipe
+		if err := addOrganizationOwner(db, vendorOrganization, vendor.ID, vendor.UsersId); err != nil {
+			log.Println("Erro ao registrar owner do vendor:", err)
+		}
+		if vendor.Slug == nil {
+			if _, err := assignVendorSlug(db, vendor.ID, vendor.Name); err != nil {
+				log.Println("Erro ao gerar slug do vendor:", err)
+			}
+		}
+		// O cadastro começa submetido e aguarda os documentos e a aprovação
+		if _, err := db.Exec("INSERT INTO vendor_status_history (vendors_id, to_status, users_id) VALUES (?, ?, ?)",
+			vendor.ID, VendorStatusSubmitted, vendor.UsersId); err != nil {
+			log.Println("Erro ao registrar histórico do cadastro:", err)
+		}
+		refreshSuggestionsLater()
+		return c.Status(200).JSON(fiber.Map{
+			"message":           "Vendor cadastrado com sucesso! Envie os documentos para análise do cadastro",
+			"id":                vendor.ID,
+			"status":            VendorStatusSubmitted,
+			"missing_documents": requiredVendorDocuments,
+		})
 
 	}
 }
@@ -336,6 +455,9 @@ func DeleteVendor(db *sql.DB) fiber.Handler {
 			return c.Status(404).JSON(fiber.Map{"error": "Vendor não encontrado"})
 		}
 
+		// Os produtos do vendor removido deixam de ter o nome dele no índice
+		reindexProductsLogged(db, "v.id IS NULL")
+
 		return c.Status(200).JSON(fiber.Map{"message": "Vendor deletado com sucesso"})
 	}
 }
@@ -392,6 +514,34 @@ func UpdateVendor(db *sql.DB) fiber.Handler {
 			"users_id":     "users_id",
 			"cep":          "cep",
 			"cnpj":         "cnpj",
+			"slug":         "slug",
+			"logo_path":    "logo_path",
+			"banner_path":  "banner_path",
+		}
+
+		// O CNPJ alterado é validado, normalizado e não pode pertencer a outro vendor
+		if cnpj, present, err := normalizeDocumentField(updateData, "cnpj", document.NormalizeCNPJ); err != nil {
+			return respondError(c, err, "Erro ao validar CNPJ:", "Falha na validação")
+		} else if present {
+			validation, err := validateVendorData(db, cnpj, "", existingID)
+			if err != nil {
+				log.Printf("Erro ao validar dados do vendor: %v", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Falha na validação"})
+			}
+			if validation.CnpjExists {
+				return c.Status(400).JSON(fiber.Map{"error": "CNPJ já está cadastrado"})
+			}
+		}
+
+		if err := normalizeAddressFields(updateData, "cep", "state"); err != nil {
+			return respondError(c, err, "Erro ao validar endereço do vendor:", "Falha na validação")
+		}
+
+		// O slug é o endereço público da vitrine e precisa ser único
+		if slug, exists := updateData["slug"]; exists {
+			if _, err := validateVendorSlug(db, slug, existingID); err != nil {
+				return respondError(c, err, "Erro ao validar slug do vendor:", "Erro ao atualizar vendor")
+			}
 		}
 
 		for field, value := range updateData {
@@ -420,24 +570,35 @@ func UpdateVendor(db *sql.DB) fiber.Handler {
 
 		// Buscar e retornar o vendor atualizado
 		vendorQuery := `
-			SELECT id, name, description, address, neighborhood, city, state, country, phone, email, users_id, cep, cnpj
+			SELECT ` + vendorColumns + `
 			FROM agrofood.vendors
 			WHERE id = ?
 		`
 
-		var vendor Vendor
-		err = db.QueryRow(vendorQuery, vendorID).Scan(&vendor.ID, &vendor.Name, &vendor.Description, &vendor.Address, &vendor.Neighborhood, &vendor.City, &vendor.State, &vendor.Country, &vendor.Phone, &vendor.Email, &vendor.UsersId, &vendor.Cep, &vendor.Cnpj)
+		vendor, err := scanVendor(db.QueryRow(vendorQuery, vendorID))
 		if err != nil {
 			log.Println("Erro ao buscar vendor atualizado:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar vendor atualizado"})
 		}
 
+		// O nome do vendor faz parte dos documentos indexados
+		reindexProductsLogged(db, "v.id = ?", vendor.ID)
+
+		canSeePrivate, err := canSeeVendorPrivate(db, c, vendor.ID)
+		if err != nil {
+			log.Println("Erro ao verificar permissões do usuário:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar permissões"})
+		}
+		if !canSeePrivate {
+			vendor.hidePrivateFields()
+		}
+
 		return c.Status(200).JSON(vendor)
 	}
 }
 
 // @Summary Obter vendor por User ID
-// @Description Obtém um vendor específico pelo users_id
+// @Description Obtém o vendor de cuja equipe o usuário faz parte, preferindo o de maior papel. GET /users/{id}/memberships lista todas as organizações do usuário
 // @Tags Vendors
 // @Param users_id path int true "ID do Usuário"
 // @Success 200 {object} Vendor
@@ -449,13 +610,17 @@ func GetVendorByUserID(db *sql.DB) fiber.Handler {
 		userID := c.Params("users_id")
 
 		vendorQuery := `
-            SELECT id, name, description, address, neighborhood, city, state, country, phone, email, users_id, cep, cnpj
+            SELECT ` + vendorColumns + `
             FROM agrofood.vendors
-            WHERE users_id = ?
+            WHERE id = (
+                SELECT vendors_id FROM vendor_members
+                WHERE users_id = ?
+                ORDER BY FIELD(role, 'owner', 'manager', 'operator'), vendors_id
+                LIMIT 1
+            )
         `
 
-		var vendor Vendor
-		err := db.QueryRow(vendorQuery, userID).Scan(&vendor.ID, &vendor.Name, &vendor.Description, &vendor.Address, &vendor.Neighborhood, &vendor.City, &vendor.State, &vendor.Country, &vendor.Phone, &vendor.Email, &vendor.UsersId, &vendor.Cep, &vendor.Cnpj)
+		vendor, err := scanVendor(db.QueryRow(vendorQuery, userID))
 		if err != nil {
 			if err == sql.ErrNoRows {
 				return c.Status(404).JSON(fiber.Map{"error": "Vendor não encontrado para este usuário"})
@@ -464,6 +629,15 @@ func GetVendorByUserID(db *sql.DB) fiber.Handler {
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar vendor"})
 		}
 
+		canSeePrivate, err := canSeeVendorPrivate(db, c, vendor.ID)
+		if err != nil {
+			log.Println("Erro ao verificar permissões do usuário:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao verificar permissões"})
+		}
+		if !canSeePrivate {
+			vendor.hidePrivateFields()
+		}
+
 		return c.Status(200).JSON(vendor)
 	}
 }
@@ -476,6 +650,12 @@ func GetVendorOrders(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
 		}
 
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		keyset, keysetArgs := params.Keyset("o.id")
+
 		// Query corrigida - removido u.email que não existe
 		query := `
 			SELECT 
@@ -485,11 +665,13 @@ func GetVendorOrders(db *sql.DB) fiber.Handler {
 				u.name as buyer_name
 			FROM orders o
 			INNER JOIN users u ON o.users_id = u.id
-			WHERE o.vendors_id = ?
-			ORDER BY o.created_at DESC
+			WHERE o.vendors_id = ? AND ` + keyset + `
+			ORDER BY o.id DESC
+			LIMIT ?
 		`
 
-		rows, err := db.Query(query, vendorID)
+		args := append(append([]interface{}{vendorID}, keysetArgs...), params.FetchLimit())
+		rows, err := db.Query(query, args...)
 		if err != nil {
 			log.Println("Erro ao buscar pedidos do vendor:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar pedidos"})
@@ -519,11 +701,18 @@ func GetVendorOrders(db *sql.DB) fiber.Handler {
 			orders = append(orders, order)
 		}
 
-		return c.Status(200).JSON(fiber.Map{
-			"success": true,
-			"total":   len(orders),
-			"orders":  orders,
-		})
+		page := pagination.NewPage(orders, params, func(o OrderWithBuyer) int { return o.ID })
+
+		if params.IncludeTotal {
+			var total int
+			if err := db.QueryRow("SELECT COUNT(*) FROM orders WHERE vendors_id = ?", vendorID).Scan(&total); err != nil {
+				log.Println("Erro ao contar pedidos do vendor:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao contar pedidos"})
+			}
+			page = page.WithTotal(total)
+		}
+
+		return c.Status(200).JSON(page)
 	}
 }
 
@@ -550,6 +739,9 @@ func GetVendorOrderDetails(db *sql.DB) fiber.Handler {
 			VendorsID       int     `json:"vendors_id"`
 			BuyerName       string  `json:"buyer_name"`
 			BuyerPhone      string  `json:"buyer_phone"`
+			Fulfillment     string  `json:"fulfillment"`
+			PickupPointsID  *int    `json:"pickup_points_id,omitempty"`
+			DeliverySlotsID *int    `json:"delivery_slots_id,omitempty"`
 		}
 
 		// Query corrigida - busca email e phone da tabela buyers se existir
@@ -559,7 +751,8 @@ func GetVendorOrderDetails(db *sql.DB) fiber.Handler {
 				o.shipping_address, o.shipping_city, o.shipping_state, o.shipping_cep,
 				o.created_at, o.users_id, o.vendors_id,
 				u.name as buyer_name,
-				COALESCE(b.phone, '') as buyer_phone
+				COALESCE(b.phone, '') as buyer_phone,
+				o.fulfillment, o.pickup_points_id, o.delivery_slots_id
 			FROM orders o
 			INNER JOIN users u ON o.users_id = u.id
 			LEFT JOIN buyers b ON o.buyers_id = b.id
@@ -572,6 +765,7 @@ func GetVendorOrderDetails(db *sql.DB) fiber.Handler {
 			&order.ShippingState, &order.ShippingCEP, &order.CreatedAt,
 			&order.UsersID, &order.VendorsID,
 			&order.BuyerName, &order.BuyerPhone,
+			&order.Fulfillment, &order.PickupPointsID, &order.DeliverySlotsID,
 		)
 
 		if err != nil {
@@ -588,7 +782,7 @@ func GetVendorOrderDetails(db *sql.DB) fiber.Handler {
 		itemsQuery := `
 			SELECT 
 				oi.id, oi.quantity, oi.price, oi.products_id,
-				p.name as product_name, p.sku as product_sku
+				p.name as product_name, p.sku as product_sku, oi.variant_label
 			FROM order_items oi
 			INNER JOIN products p ON oi.products_id = p.id
 			WHERE oi.orders_id = ?
@@ -602,20 +796,21 @@ func GetVendorOrderDetails(db *sql.DB) fiber.Handler {
 		defer rows.Close()
 
 		type OrderItemDetail struct {
-			ID          int     `json:"id"`
-			Quantity    int     `json:"quantity"`
-			Price       float64 `json:"price"`
-			ProductID   int     `json:"product_id"`
-			ProductName string  `json:"product_name"`
-			ProductSKU  string  `json:"product_sku"`
-			Subtotal    float64 `json:"subtotal"`
+			ID           int     `json:"id"`
+			Quantity     int     `json:"quantity"`
+			Price        float64 `json:"price"`
+			ProductID    int     `json:"product_id"`
+			ProductName  string  `json:"product_name"`
+			ProductSKU   string  `json:"product_sku"`
+			VariantLabel *string `json:"variant_label,omitempty"`
+			Subtotal     float64 `json:"subtotal"`
 		}
 
 		var items []OrderItemDetail
 		for rows.Next() {
 			var item OrderItemDetail
 			err := rows.Scan(&item.ID, &item.Quantity, &item.Price,
-				&item.ProductID, &item.ProductName, &item.ProductSKU)
+				&item.ProductID, &item.ProductName, &item.ProductSKU, &item.VariantLabel)
 			if err != nil {
 				log.Println("Erro ao ler item:", err)
 				continue
@@ -661,9 +856,17 @@ func UpdateOrderStatus(db *sql.DB) fiber.Handler {
 			})
 		}
 
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar status"})
+		}
+		defer tx.Rollback()
+
 		// Verificar se o pedido pertence ao vendor
 		var currentVendorID int
-		err := db.QueryRow("SELECT vendors_id FROM orders WHERE id = ?", orderID).Scan(&currentVendorID)
+		var currentStatus string
+		err = tx.QueryRow("SELECT vendors_id, status FROM orders WHERE id = ? FOR UPDATE", orderID).Scan(&currentVendorID, &currentStatus)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				return c.Status(404).JSON(fiber.Map{"error": "Pedido não encontrado"})
@@ -685,14 +888,38 @@ func UpdateOrderStatus(db *sql.DB) fiber.Handler {
 			})
 		}
 
+		// O estoque de um pedido cancelado já foi devolvido
+		if currentStatus == "cancelled" && statusUpdate.Status != "cancelled" {
+			return c.Status(409).JSON(fiber.Map{"error": "Pedido cancelado não pode ser reaberto"})
+		}
+
 		// Atualizar status
-		_, err = db.Exec("UPDATE orders SET status = ? WHERE id = ?",
+		_, err = tx.Exec("UPDATE orders SET status = ? WHERE id = ?",
 			statusUpdate.Status, orderID)
 		if err != nil {
 			log.Println("Erro ao atualizar status:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar status"})
 		}
 
+		// Devolve os itens ao estoque no cancelamento
+		if statusUpdate.Status == "cancelled" && currentStatus != "cancelled" {
+			orderIDInt, _ := strconv.ParseInt(orderID, 10, 64)
+			actor, _ := callerUserID(c)
+			if err := restockCancelledOrder(tx, orderIDInt, int64(actor)); err != nil {
+				log.Println("Erro ao devolver itens ao estoque:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar status"})
+			}
+			if err := releaseDeliverySlot(tx, orderIDInt); err != nil {
+				log.Println("Erro ao liberar janela de entrega:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar status"})
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao finalizar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar status"})
+		}
+
 		return c.Status(200).JSON(fiber.Map{
 			"success": true,
 			"message": "Status atualizado com sucesso",
@@ -774,14 +1001,19 @@ func generateOrderNumber() string {
 
 // FinalizeCheckout processa a finalização da compra
 // @Summary Finaliza a compra do carrinho
+// @Description Cria um único pedido com os itens do carrinho. Todos os vendors precisam entregar no endereço informado, senão a resposta lista os vendors que não entregam (undeliverable_vendors)
 // @Tags Checkout
 // @Accept  json
 // @Produce  json
 // @Param user_id path int true "ID do usuário"
+// @Param X-User-ID header int false "ID do usuário que faz a compra; obrigatório com buyers_id"
 // @Param checkout body CheckoutRequest true "Dados do checkout"
 // @Success 201 {object} Order "Pedido criado com sucesso"
 // @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Usuário não pode comprar em nome do comprador"
 // @Failure 404 {object} map[string]string "Carrinho não encontrado"
+// @Failure 409 {object} map[string]string "Carrinho com produtos sem vendor"
 // @Failure 500 {object} map[string]string "Erro ao processar pedido"
 // @Router /checkout/{user_id} [post]
 func FinalizeCheckout(db *sql.DB) fiber.Handler {
@@ -799,10 +1031,21 @@ func FinalizeCheckout(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
 		}
 
+		// Com address_id os dados de entrega são copiados do catálogo do comprador
+		if err := checkoutData.applySavedAddress(db, userID); err != nil {
+			return respondError(c, err, "Erro ao buscar endereço de entrega:", "Erro ao processar pedido")
+		}
+		if err := checkoutData.authorizeBuyer(db, c, userID); err != nil {
+			return respondError(c, err, "Erro ao verificar comprador do pedido:", "Erro ao processar pedido")
+		}
+
 		// Validar campos obrigatórios
 		if checkoutData.PaymentMethod == "" {
 			return c.Status(400).JSON(fiber.Map{"error": "Método de pagamento é obrigatório"})
 		}
+		if len(checkoutData.Slots) > 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Janelas de entrega são escolhidas por vendor no checkout multi-vendor"})
+		}
 		if checkoutData.ShippingAddress == "" {
 			return c.Status(400).JSON(fiber.Map{"error": "Endereço de entrega é obrigatório"})
 		}
@@ -815,6 +1058,9 @@ func FinalizeCheckout(db *sql.DB) fiber.Handler {
 		if checkoutData.ShippingCEP == "" {
 			return c.Status(400).JSON(fiber.Map{"error": "CEP é obrigatório"})
 		}
+		if err := checkoutData.normalizeShippingAddress(); err != nil {
+			return respondError(c, err, "Erro ao validar endereço de entrega:", "Erro ao processar pedido")
+		}
 
 		// Iniciar transação
 		tx, err := db.Begin()
@@ -859,18 +1105,43 @@ func FinalizeCheckout(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "Carrinho vazio"})
 		}
 
+		if err := ensureCartVendors(tx, cart.ID); err != nil {
+			return respondError(c, err, "Erro ao verificar vendors do carrinho:", "Erro ao processar pedido")
+		}
+
+		// Todos os vendors do carrinho precisam entregar no endereço
+		cartVendorIDs, err := scanIDs(tx.Query(`
+			SELECT DISTINCT v.id
+			FROM cart_items ci
+			INNER JOIN products p ON p.id = ci.products_id
+			INNER JOIN vendors v ON v.id = p.vendors_id
+			WHERE ci.cart_id = ?`, cart.ID))
+		if err != nil {
+			log.Println("Erro ao buscar vendors do carrinho:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar pedido"})
+		}
+		if err := checkoutData.checkDelivery(tx, cartVendorIDs); err != nil {
+			return respondDeliveryError(c, err, "Erro ao verificar regiões de entrega:", "Erro ao processar pedido")
+		}
+
 		// Calcular total e verificar estoque
 		var orderTotal float64
 		for _, item := range cartItems {
 			var price float64
 			var availableStock int
-			err := tx.QueryRow("SELECT price, quantity FROM products WHERE id = ?", item.ProductsID).
-				Scan(&price, &availableStock)
+			var purchasable bool
+			// O estoque reservado pelo próprio carrinho continua disponível para ele
+			err := tx.QueryRow("SELECT p.price, "+sellableQuantityExpr+" + "+cartHeldQuantityExpr+", "+publicProductCondition+" AND NOT "+productHasVariantsCondition+" FROM products p WHERE p.id = ?", cart.ID, item.ProductsID).
+				Scan(&price, &availableStock, &purchasable)
 			if err != nil {
 				log.Println("Erro ao buscar produto:", err)
 				return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar produtos"})
 			}
 
+			if !purchasable {
+				return c.Status(400).JSON(fiber.Map{"error": "Um ou mais produtos não estão mais disponíveis"})
+			}
+
 			// Verificar estoque
 			if availableStock < item.Quantity {
 				return c.Status(400).JSON(fiber.Map{
@@ -924,19 +1195,35 @@ func FinalizeCheckout(db *sql.DB) fiber.Handler {
 			}
 
 			// Inserir item do pedido
-			_, err = tx.Exec(`INSERT INTO order_items (quantity, price, orders_id, products_id) 
-				VALUES (?, ?, ?, ?)`,
+			itemResult, err := tx.Exec(`INSERT INTO order_items (quantity, price, orders_id, products_id, variant_label)
+				SELECT ?, ?, ?, p.id, `+variantLabelExpr+` FROM products p WHERE p.id = ?`,
 				item.Quantity, price, orderID, item.ProductsID)
 			if err != nil {
 				log.Println("Erro ao criar item do pedido:", err)
 				return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar itens do pedido"})
 			}
+			orderItemID, err := itemResult.LastInsertId()
+			if err != nil {
+				log.Println("Erro ao obter ID do item do pedido:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar itens do pedido"})
+			}
+
+			// Separar dos lotes que vencem primeiro (FEFO)
+			if err := allocateLotsFEFO(tx, item.ProductsID, orderItemID, item.Quantity); err != nil {
+				log.Println("Erro ao alocar lotes do item:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar estoque"})
+			}
 
 			// Atualizar estoque
-			_, err = tx.Exec("UPDATE products SET quantity = quantity - ? WHERE id = ?",
-				item.Quantity, item.ProductsID)
+			buyerUserID, _ := strconv.ParseInt(userID, 10, 64)
+			_, err = adjustStock(tx, item.ProductsID, MovementSale, -item.Quantity, buyerUserID, orderID, "Pedido "+orderNumber)
 			if err != nil {
-				log.Println("Erro ao atualizar estoque:", err)
+				return respondError(c, err, "Erro ao atualizar estoque:", "Erro ao atualizar estoque")
+			}
+
+			// A reserva do item vira venda
+			if err := convertCartItemReservation(tx, item.ID, orderID); err != nil {
+				log.Println("Erro ao converter reserva do item:", err)
 				return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar estoque"})
 			}
 		}
@@ -1026,19 +1313,30 @@ func GetOrderByID(db *sql.DB) fiber.Handler {
 // @Accept  json
 // @Produce  json
 // @Param user_id path int true "ID do usuário"
-// @Success 200 {array} Order
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros de paginação inválidos"
 // @Router /orders/user/{user_id} [get]
 func GetUserOrders(db *sql.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userID := c.Params("user_id")
 
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		keyset, keysetArgs := params.Keyset("id")
+
 		// ⭐ ADICIONADO vendors_id na query
 		query := `SELECT id, order_number, status, total, payment_method, 
 			shipping_address, shipping_city, shipping_state, shipping_cep, 
 			created_at, users_id, vendors_id, buyers_id 
-			FROM orders WHERE users_id = ? ORDER BY created_at DESC`
+			FROM orders WHERE users_id = ? AND ` + keyset + ` ORDER BY id DESC LIMIT ?`
 
-		rows, err := db.Query(query, userID)
+		args := append(append([]interface{}{userID}, keysetArgs...), params.FetchLimit())
+		rows, err := db.Query(query, args...)
 		if err != nil {
 			log.Println("Erro ao buscar pedidos:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar pedidos"})
@@ -1062,20 +1360,36 @@ func GetUserOrders(db *sql.DB) fiber.Handler {
 			orders = append(orders, order)
 		}
 
-		return c.Status(200).JSON(orders)
+		page := pagination.NewPage(orders, params, func(o Order) int { return o.ID })
+
+		if params.IncludeTotal {
+			var total int
+			if err := db.QueryRow("SELECT COUNT(*) FROM orders WHERE users_id = ?", userID).Scan(&total); err != nil {
+				log.Println("Erro ao contar pedidos:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao contar pedidos"})
+			}
+			page = page.WithTotal(total)
+		}
+
+		return c.Status(200).JSON(page)
 	}
 }
 
 // FinalizeCheckoutMultiVendor processa checkout separando por vendors
 // @Summary Finaliza a compra criando pedidos separados por vendor
+// @Description Cria um pedido por vendor do carrinho. Em pickups o comprador escolhe, por vendor, retirar no local de retirada do vendor; os demais vendors precisam entregar no endereço informado, senão a resposta lista os vendors que não entregam (undeliverable_vendors). Em slots o comprador escolhe a janela de entrega de cada vendor; janela esgotada ou fechada devolve 409
 // @Tags Checkout
 // @Accept  json
 // @Produce  json
 // @Param user_id path int true "ID do usuário"
+// @Param X-User-ID header int false "ID do usuário que faz a compra; obrigatório com buyers_id"
 // @Param checkout body CheckoutRequest true "Dados do checkout"
 // @Success 200 {object} CheckoutResponse "Pedidos criados com sucesso"
 // @Failure 400 {object} map[string]string "Dados inválidos"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Usuário não pode comprar em nome do comprador"
 // @Failure 404 {object} map[string]string "Carrinho não encontrado"
+// @Failure 409 {object} map[string]string "Janela de entrega esgotada ou indisponível, ou carrinho com produtos sem vendor"
 // @Failure 500 {object} map[string]string "Erro ao processar pedido"
 // @Router /checkout-multi-vendor/{user_id} [post]
 func FinalizeCheckoutMultiVendor(db *sql.DB) fiber.Handler {
@@ -1093,21 +1407,27 @@ func FinalizeCheckoutMultiVendor(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
 		}
 
+		// Com address_id os dados de entrega são copiados do catálogo do comprador
+		if err := checkoutData.applySavedAddress(db, userID); err != nil {
+			return respondError(c, err, "Erro ao buscar endereço de entrega:", "Erro ao processar pedido")
+		}
+		if err := checkoutData.authorizeBuyer(db, c, userID); err != nil {
+			return respondError(c, err, "Erro ao verificar comprador do pedido:", "Erro ao processar pedido")
+		}
+
 		// Validações
 		if checkoutData.PaymentMethod == "" {
 			return c.Status(400).JSON(fiber.Map{"error": "Método de pagamento é obrigatório"})
 		}
-		if checkoutData.ShippingAddress == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "Endereço de entrega é obrigatório"})
-		}
-		if checkoutData.ShippingCity == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "Cidade é obrigatória"})
-		}
-		if checkoutData.ShippingState == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "Estado é obrigatório"})
+		// Com retirada, o endereço de entrega só é exigido se algum vendor do
+		// carrinho ficar sem retirada (conferido depois de agrupar os itens)
+		if len(checkoutData.Pickups) == 0 {
+			if err := checkoutData.requireShippingAddress(); err != nil {
+				return respondError(c, err, "Erro ao validar endereço de entrega:", "Erro ao processar pedido")
+			}
 		}
-		if checkoutData.ShippingCEP == "" {
-			return c.Status(400).JSON(fiber.Map{"error": "CEP é obrigatório"})
+		if err := checkoutData.normalizeShippingAddress(); err != nil {
+			return respondError(c, err, "Erro ao validar endereço de entrega:", "Erro ao processar pedido")
 		}
 
 		// Iniciar transação
@@ -1132,22 +1452,28 @@ func FinalizeCheckoutMultiVendor(db *sql.DB) fiber.Handler {
 
 		log.Printf("✅ [CHECKOUT] Carrinho encontrado - ID: %d", cart.ID)
 
+		// Itens sem vendor ficariam fora dos pedidos agrupados abaixo
+		if err := ensureCartVendors(tx, cart.ID); err != nil {
+			return respondError(c, err, "❌ [CHECKOUT] Erro ao verificar vendors do carrinho:", "Erro ao processar pedido")
+		}
+
 		// ⭐ Query otimizada com JOIN para pegar vendor info
 		query := `
 			SELECT 
 				ci.id, ci.quantity, ci.cart_id, ci.products_id,
-				p.name, p.price, p.quantity as stock,
+				p.name, p.price, ` + sellableQuantityExpr + ` + ` + cartHeldQuantityExpr + ` as stock,
+				(` + publicProductCondition + ` AND NOT ` + productHasVariantsCondition + `) as purchasable,
 				v.id as vendor_id,
 				v.name as vendor_name,
 				v.email as vendor_email,
 				v.phone as vendor_phone
 			FROM cart_items ci
 			INNER JOIN products p ON ci.products_id = p.id
-			INNER JOIN vendors v ON p.users_id = v.users_id
+			INNER JOIN vendors v ON v.id = p.vendors_id
 			WHERE ci.cart_id = ?
 		`
 
-		rows, err := tx.Query(query, cart.ID)
+		rows, err := tx.Query(query, cart.ID, cart.ID)
 		if err != nil {
 			log.Println("❌ [CHECKOUT] Erro ao buscar itens:", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar itens do carrinho"})
@@ -1181,10 +1507,11 @@ func FinalizeCheckoutMultiVendor(db *sql.DB) fiber.Handler {
 				price                                float64
 				stock, vendorID                      int
 				vendorName, vendorEmail, vendorPhone string
+				purchasable                          bool
 			)
 
 			if err := rows.Scan(&cartItemID, &quantity, &cartID,
-				&productID, &productName, &price, &stock,
+				&productID, &productName, &price, &stock, &purchasable,
 				&vendorID, &vendorName, &vendorEmail, &vendorPhone); err != nil {
 				log.Println("❌ [CHECKOUT] Erro ao ler item:", err)
 				return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar itens"})
@@ -1193,6 +1520,13 @@ func FinalizeCheckoutMultiVendor(db *sql.DB) fiber.Handler {
 			itemCount++
 			log.Printf("✅ [CHECKOUT] Item %d: %s (Vendor: %s)", itemCount, productName, vendorName)
 
+			// Produtos despublicados ou excluídos não podem ser comprados
+			if !purchasable {
+				return c.Status(400).JSON(fiber.Map{
+					"error": "Produto indisponível: " + productName,
+				})
+			}
+
 			// Verificar estoque
 			if stock < quantity {
 				log.Printf("❌ [CHECKOUT] Estoque insuficiente: %s", productName)
@@ -1244,6 +1578,34 @@ func FinalizeCheckoutMultiVendor(db *sql.DB) fiber.Handler {
 			return c.Status(400).JSON(fiber.Map{"error": "Carrinho vazio"})
 		}
 
+		// Retirada escolhida por vendor; os demais precisam entregar no endereço
+		cartVendorIDs := make([]int, 0, len(vendorGroups))
+		for vendorID := range vendorGroups {
+			cartVendorIDs = append(cartVendorIDs, vendorID)
+		}
+		pickupPoints, err := checkoutData.pickupPoints(tx, cartVendorIDs)
+		if err != nil {
+			return respondError(c, err, "❌ [CHECKOUT] Erro ao buscar locais de retirada:", "Erro ao processar pedido")
+		}
+		var deliveryVendorIDs []int
+		for _, vendorID := range cartVendorIDs {
+			if _, pickup := pickupPoints[vendorID]; !pickup {
+				deliveryVendorIDs = append(deliveryVendorIDs, vendorID)
+			}
+		}
+		if len(deliveryVendorIDs) > 0 {
+			if err := checkoutData.requireShippingAddress(); err != nil {
+				return respondError(c, err, "❌ [CHECKOUT] Erro ao validar endereço de entrega:", "Erro ao processar pedido")
+			}
+			if err := checkoutData.checkDelivery(tx, deliveryVendorIDs); err != nil {
+				return respondDeliveryError(c, err, "❌ [CHECKOUT] Erro ao verificar regiões de entrega:", "Erro ao processar pedido")
+			}
+		}
+		deliverySlots, err := checkoutData.slotsByVendor(cartVendorIDs)
+		if err != nil {
+			return respondError(c, err, "❌ [CHECKOUT] Erro ao validar janelas de entrega:", "Erro ao processar pedido")
+		}
+
 		createdAt := time.Now().Format("2006-01-02 15:04:05")
 		var createdOrders []OrderDetail // ⭐ Usar OrderDetail
 
@@ -1260,22 +1622,50 @@ func FinalizeCheckoutMultiVendor(db *sql.DB) fiber.Handler {
 
 			orderNumber := generateOrderNumber()
 
+			// Na retirada o pedido guarda o endereço do local do vendor
+			fulfillment := FulfillmentDelivery
+			shippingAddress, shippingCity := checkoutData.ShippingAddress, checkoutData.ShippingCity
+			shippingState, shippingCEP := checkoutData.ShippingState, checkoutData.ShippingCEP
+			var pickupPoint *VendorPickupPoint
+			var pickupPointID *int
+			if point, pickup := pickupPoints[vendorID]; pickup {
+				fulfillment = FulfillmentPickup
+				pickupPoint, pickupPointID = &point, &point.ID
+				shippingAddress = point.Address
+				if point.Neighborhood != nil {
+					shippingAddress += " - " + *point.Neighborhood
+				}
+				shippingCity, shippingState, shippingCEP = point.City, point.State, point.Cep
+			}
+
+			// Ocupa a vaga da janela escolhida; janela esgotada cancela o checkout
+			var deliverySlot *DeliverySlot
+			var deliverySlotID *int
+			if slotID, scheduled := deliverySlots[vendorID]; scheduled {
+				slot, err := bookDeliverySlot(tx, vendorID, slotID)
+				if err != nil {
+					return respondError(c, err, "❌ [CHECKOUT] Erro ao reservar janela de entrega:", "Erro ao processar pedido")
+				}
+				deliverySlot, deliverySlotID = &slot, &slot.ID
+			}
+
 			// Inserir pedido
 			orderQuery := `INSERT INTO orders 
 				(order_number, status, total, payment_method, shipping_address, 
 				shipping_city, shipping_state, shipping_cep, created_at, users_id, 
-				vendors_id, buyers_id) 
-				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+				vendors_id, buyers_id, fulfillment, pickup_points_id, delivery_slots_id) 
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 			result, err := tx.Exec(orderQuery,
 				orderNumber, "pending", orderTotal,
 				checkoutData.PaymentMethod,
-				checkoutData.ShippingAddress,
-				checkoutData.ShippingCity,
-				checkoutData.ShippingState,
-				checkoutData.ShippingCEP,
+				shippingAddress,
+				shippingCity,
+				shippingState,
+				shippingCEP,
 				createdAt, userID, vendorID,
 				checkoutData.BuyersID,
+				fulfillment, pickupPointID, deliverySlotID,
 			)
 			if err != nil {
 				log.Printf("❌ [CHECKOUT] Erro ao criar pedido vendor %d: %v", vendorID, err)
@@ -1292,18 +1682,34 @@ func FinalizeCheckoutMultiVendor(db *sql.DB) fiber.Handler {
 
 			// Criar itens e atualizar estoque
 			for _, item := range group.Items {
-				_, err = tx.Exec(`INSERT INTO order_items (quantity, price, orders_id, products_id) 
-					VALUES (?, ?, ?, ?)`,
+				itemResult, err := tx.Exec(`INSERT INTO order_items (quantity, price, orders_id, products_id, variant_label)
+					SELECT ?, ?, ?, p.id, `+variantLabelExpr+` FROM products p WHERE p.id = ?`,
 					item.Quantity, item.Price, orderID, item.ProductID)
 				if err != nil {
 					log.Printf("❌ [CHECKOUT] Erro ao criar item: %v", err)
 					return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar itens do pedido"})
 				}
+				orderItemID, err := itemResult.LastInsertId()
+				if err != nil {
+					log.Printf("❌ [CHECKOUT] Erro ao obter ID do item: %v", err)
+					return c.Status(500).JSON(fiber.Map{"error": "Erro ao criar itens do pedido"})
+				}
 
-				_, err = tx.Exec("UPDATE products SET quantity = quantity - ? WHERE id = ?",
-					item.Quantity, item.ProductID)
+				// Separar dos lotes que vencem primeiro (FEFO)
+				if err := allocateLotsFEFO(tx, item.ProductID, orderItemID, item.Quantity); err != nil {
+					log.Printf("❌ [CHECKOUT] Erro ao alocar lotes: %v", err)
+					return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar estoque"})
+				}
+
+				buyerUserID, _ := strconv.ParseInt(userID, 10, 64)
+				_, err = adjustStock(tx, item.ProductID, MovementSale, -item.Quantity, buyerUserID, orderID, "Pedido "+orderNumber)
 				if err != nil {
-					log.Printf("❌ [CHECKOUT] Erro ao atualizar estoque: %v", err)
+					return respondError(c, err, "❌ [CHECKOUT] Erro ao atualizar estoque:", "Erro ao atualizar estoque")
+				}
+
+				// A reserva do item vira venda
+				if err := convertCartItemReservation(tx, item.CartItemID, orderID); err != nil {
+					log.Printf("❌ [CHECKOUT] Erro ao converter reserva: %v", err)
 					return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar estoque"})
 				}
 			}
@@ -1315,10 +1721,10 @@ func FinalizeCheckoutMultiVendor(db *sql.DB) fiber.Handler {
 				Status:          "pending",
 				Total:           orderTotal,
 				PaymentMethod:   checkoutData.PaymentMethod,
-				ShippingAddress: checkoutData.ShippingAddress,
-				ShippingCity:    checkoutData.ShippingCity,
-				ShippingState:   checkoutData.ShippingState,
-				ShippingCEP:     checkoutData.ShippingCEP,
+				ShippingAddress: shippingAddress,
+				ShippingCity:    shippingCity,
+				ShippingState:   shippingState,
+				ShippingCEP:     shippingCEP,
 				CreatedAt:       createdAt,
 				UsersID:         *cart.UsersID,
 				BuyersID:        checkoutData.BuyersID,
@@ -1328,6 +1734,9 @@ func FinalizeCheckoutMultiVendor(db *sql.DB) fiber.Handler {
 					Email: group.VendorEmail,
 					Phone: group.VendorPhone,
 				},
+				Fulfillment:  fulfillment,
+				PickupPoint:  pickupPoint,
+				DeliverySlot: deliverySlot,
 			})
 		}
 
@@ -1473,7 +1882,7 @@ func GetOrderWithVendorInfo(db *sql.DB) fiber.Handler {
 		itemsQuery := `
 			SELECT 
 				oi.id, oi.quantity, oi.price, oi.orders_id, oi.products_id,
-				p.name as product_name
+				p.name as product_name, oi.variant_label
 			FROM order_items oi
 			INNER JOIN products p ON oi.products_id = p.id
 			WHERE oi.orders_id = ?
@@ -1490,7 +1899,7 @@ func GetOrderWithVendorInfo(db *sql.DB) fiber.Handler {
 		for rows.Next() {
 			var item OrderItemWithVendor
 			err := rows.Scan(&item.ID, &item.Quantity, &item.Price,
-				&item.OrdersID, &item.ProductsID, &item.ProductName)
+				&item.OrdersID, &item.ProductsID, &item.ProductName, &item.VariantLabel)
 			if err != nil {
 				log.Println("Erro ao ler item:", err)
 				continue
@@ -0,0 +1,718 @@
+package controllers
+
+import (
+	"api/pagination"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Status do cadastro do vendor
+const (
+	VendorStatusSubmitted   = "submitted"
+	VendorStatusUnderReview = "under_review"
+	VendorStatusApproved    = "approved"
+	VendorStatusRejected    = "rejected"
+	VendorStatusSuspended   = "suspended"
+)
+
+// Tipos de documento do cadastro do vendor
+const (
+	VendorDocumentContratoSocial = "contrato_social"
+	VendorDocumentProofOfAddress = "proof_of_address"
+)
+
+// Documentos exigidos para aprovar o cadastro
+var requiredVendorDocuments = []string{VendorDocumentContratoSocial, VendorDocumentProofOfAddress}
+
+// Tamanho máximo de cada documento enviado
+const maxVendorDocumentSize = 10 << 20
+
+// Extensões aceitas para os documentos e o tipo de conteúdo de cada uma
+var vendorDocumentContentTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+}
+
+// Diretório padrão dos documentos enviados pelos vendors
+const defaultVendorDocumentsDir = "uploads/vendor-documents"
+
+// Transições de status que os administradores podem fazer. O vendor
+// reprovado volta para submitted ao reenviar o cadastro.
+var vendorStatusTransitions = map[string][]string{
+	VendorStatusSubmitted:   {VendorStatusUnderReview, VendorStatusApproved, VendorStatusRejected},
+	VendorStatusUnderReview: {VendorStatusApproved, VendorStatusRejected},
+	VendorStatusRejected:    {VendorStatusUnderReview, VendorStatusApproved},
+	VendorStatusApproved:    {VendorStatusSuspended},
+	VendorStatusSuspended:   {VendorStatusApproved},
+}
+
+// Condição SQL dos produtos (alias p) cujo vendor tem cadastro aprovado.
+// Produtos sem vendor não são vendidos.
+const approvedSellerCondition = "EXISTS (SELECT 1 FROM vendors sv WHERE sv.id = p.vendors_id AND sv.status = 'approved')"
+
+// VendorDocument é um documento enviado no cadastro do vendor
+type VendorDocument struct {
+	ID          int    `json:"id"`
+	VendorsID   int    `json:"vendors_id"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+	UsersID     *int   `json:"users_id"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// VendorStatusChange é um registro do histórico de status do cadastro
+type VendorStatusChange struct {
+	ID         int     `json:"id"`
+	FromStatus *string `json:"from_status"`
+	ToStatus   string  `json:"to_status"`
+	Reason     *string `json:"reason"`
+	UsersID    *int    `json:"users_id"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+// VendorStatusRequest é o corpo da decisão do administrador sobre o cadastro.
+// O motivo é obrigatório para reprovar ou suspender.
+type VendorStatusRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// vendorDocumentsDir lê o diretório dos documentos (VENDOR_DOCUMENTS_DIR),
+// usando o padrão quando ausente
+func vendorDocumentsDir() string {
+	if dir := os.Getenv("VENDOR_DOCUMENTS_DIR"); dir != "" {
+		return dir
+	}
+	return defaultVendorDocumentsDir
+}
+
+// validVendorStatus indica se o status informado é um status de cadastro
+func validVendorStatus(status string) bool {
+	_, ok := vendorStatusTransitions[status]
+	return ok
+}
+
+// ensureVendorApproved impede que vendors com cadastro ainda não aprovado
+// publiquem produtos, seguindo approvedSellerCondition. Produtos sem vendor
+// (vendorID nulo) também não são publicados.
+func ensureVendorApproved(q sqlQueryer, vendorID interface{}) error {
+	var status string
+	err := q.QueryRow("SELECT status FROM vendors WHERE id = ?", vendorID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return &requestError{403, "O produto precisa pertencer a um vendor com cadastro aprovado para ser publicado"}
+	} else if err != nil {
+		return err
+	}
+	if status != VendorStatusApproved {
+		return &requestError{403, "O cadastro do vendor precisa estar aprovado para publicar produtos (status atual: " + status + ")"}
+	}
+	return nil
+}
+
+// vendorApproved indica se o vendor do produto pode vendê-lo
+func vendorApproved(q sqlQueryer, vendorID interface{}) (bool, error) {
+	err := ensureVendorApproved(q, vendorID)
+	if _, ok := err.(*requestError); ok {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// missingVendorDocuments devolve os tipos de documento exigidos que o vendor
+// ainda não enviou
+func missingVendorDocuments(q sqlQueryer, vendorID int) ([]string, error) {
+	rows, err := q.Query("SELECT DISTINCT type FROM vendor_documents WHERE vendors_id = ?", vendorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sent := make(map[string]bool)
+	for rows.Next() {
+		var documentType string
+		if err := rows.Scan(&documentType); err != nil {
+			return nil, err
+		}
+		sent[documentType] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	missing := []string{}
+	for _, documentType := range requiredVendorDocuments {
+		if !sent[documentType] {
+			missing = append(missing, documentType)
+		}
+	}
+	return missing, nil
+}
+
+// changeVendorStatus grava o novo status do cadastro e registra a mudança no
+// histórico
+func changeVendorStatus(q sqlQueryer, vendorID int, fromStatus, toStatus string, reason interface{}, userID interface{}) error {
+	_, err := q.Exec(`
+		UPDATE vendors SET status = ?, status_reason = ?, reviewed_by = ?, reviewed_at = NOW()
+		WHERE id = ?`, toStatus, reason, userID, vendorID)
+	if err != nil {
+		return err
+	}
+
+	var from interface{}
+	if fromStatus != "" {
+		from = fromStatus
+	}
+	_, err = q.Exec("INSERT INTO vendor_status_history (vendors_id, from_status, to_status, reason, users_id) VALUES (?, ?, ?, ?, ?)",
+		vendorID, from, toStatus, reason, userID)
+	return err
+}
+
+// reindexVendorProducts atualiza no índice de busca os produtos do vendor
+// depois que ele entra ou sai da situação aprovada
+func reindexVendorProducts(q sqlQueryer, vendorID int) {
+	reindexProductsLogged(q, "p.vendors_id = ?", vendorID)
+}
+
+// loadVendorDocuments busca os documentos enviados pelo vendor
+func loadVendorDocuments(q sqlQueryer, vendorID int) ([]VendorDocument, error) {
+	rows, err := q.Query(`
+		SELECT id, vendors_id, type, name, content_type, size, users_id, created_at
+		FROM vendor_documents
+		WHERE vendors_id = ?
+		ORDER BY id DESC`, vendorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	documents := []VendorDocument{}
+	for rows.Next() {
+		var document VendorDocument
+		if err := rows.Scan(&document.ID, &document.VendorsID, &document.Type, &document.Name, &document.ContentType,
+			&document.Size, &document.UsersID, &document.CreatedAt); err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+	return documents, rows.Err()
+}
+
+// @Summary Situação do cadastro do vendor
+// @Description Status do cadastro, motivo da última decisão, documentos enviados, documentos pendentes e histórico de status. Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Success 200 {object} map[string]interface{} "Situação do cadastro"
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao buscar cadastro"
+// @Router /vendors/{vendor_id}/onboarding [get]
+func GetVendorOnboarding(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		var status string
+		var reason, reviewedAt *string
+		err = db.QueryRow("SELECT status, status_reason, reviewed_at FROM vendors WHERE id = ?", vendorID).
+			Scan(&status, &reason, &reviewedAt)
+		if err != nil {
+			log.Println("Erro ao buscar cadastro do vendor:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar cadastro"})
+		}
+
+		documents, err := loadVendorDocuments(db, vendorID)
+		if err != nil {
+			log.Println("Erro ao buscar documentos do vendor:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar cadastro"})
+		}
+
+		missing, err := missingVendorDocuments(db, vendorID)
+		if err != nil {
+			log.Println("Erro ao verificar documentos do vendor:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar cadastro"})
+		}
+
+		rows, err := db.Query(`
+			SELECT id, from_status, to_status, reason, users_id, created_at
+			FROM vendor_status_history
+			WHERE vendors_id = ?
+			ORDER BY id DESC`, vendorID)
+		if err != nil {
+			log.Println("Erro ao buscar histórico do cadastro:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar cadastro"})
+		}
+		defer rows.Close()
+
+		history := []VendorStatusChange{}
+		for rows.Next() {
+			var change VendorStatusChange
+			if err := rows.Scan(&change.ID, &change.FromStatus, &change.ToStatus, &change.Reason, &change.UsersID, &change.CreatedAt); err != nil {
+				log.Println("Erro ao ler histórico do cadastro:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar cadastro"})
+			}
+			history = append(history, change)
+		}
+		if err := rows.Err(); err != nil {
+			log.Println("Erro com as linhas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar cadastro"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{
+			"vendors_id":        vendorID,
+			"status":            status,
+			"status_reason":     reason,
+			"reviewed_at":       reviewedAt,
+			"documents":         documents,
+			"missing_documents": missing,
+			"history":           history,
+		})
+	}
+}
+
+// @Summary Enviar documento do cadastro
+// @Description Envia o contrato social ou o comprovante de endereço do vendor (PDF, JPG ou PNG de até 10 MB). Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Accept multipart/form-data
+// @Produce json
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param type formData string true "contrato_social ou proof_of_address"
+// @Param file formData file true "Documento"
+// @Success 201 {object} VendorDocument
+// @Failure 400 {object} map[string]string "Documento inválido"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao enviar documento"
+// @Router /vendors/{vendor_id}/documents [post]
+func UploadVendorDocument(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+		userID, _ := callerUserID(c)
+
+		documentType := c.FormValue("type")
+		if documentType != VendorDocumentContratoSocial && documentType != VendorDocumentProofOfAddress {
+			return c.Status(400).JSON(fiber.Map{"error": "Tipo inválido. Use: contrato_social ou proof_of_address"})
+		}
+
+		header, err := c.FormFile("file")
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Envie o documento no campo file"})
+		}
+		if header.Size == 0 || header.Size > maxVendorDocumentSize {
+			return c.Status(400).JSON(fiber.Map{"error": "O documento deve ter até 10 MB"})
+		}
+		extension := strings.ToLower(filepath.Ext(header.Filename))
+		contentType, ok := vendorDocumentContentTypes[extension]
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{"error": "Formato inválido. Envie PDF, JPG ou PNG"})
+		}
+
+		dir := filepath.Join(vendorDocumentsDir(), strconv.Itoa(vendorID))
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			log.Println("Erro ao criar diretório de documentos:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao enviar documento"})
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%s-%d%s", documentType, time.Now().UnixNano(), extension))
+		if err := c.SaveFile(header, path); err != nil {
+			log.Println("Erro ao salvar documento:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao enviar documento"})
+		}
+
+		result, err := db.Exec(`
+			INSERT INTO vendor_documents (vendors_id, type, name, path, content_type, size, users_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			vendorID, documentType, filepath.Base(header.Filename), path, contentType, header.Size, userID)
+		if err != nil {
+			os.Remove(path)
+			log.Println("Erro ao registrar documento:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao enviar documento"})
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			log.Println("Erro ao obter o ID do documento:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao enviar documento"})
+		}
+
+		document := VendorDocument{
+			ID:          int(id),
+			VendorsID:   vendorID,
+			Type:        documentType,
+			Name:        filepath.Base(header.Filename),
+			ContentType: contentType,
+			Size:        int(header.Size),
+			UsersID:     &userID,
+		}
+		if err := db.QueryRow("SELECT created_at FROM vendor_documents WHERE id = ?", id).Scan(&document.CreatedAt); err != nil {
+			log.Println("Erro ao buscar documento:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao enviar documento"})
+		}
+
+		return c.Status(201).JSON(document)
+	}
+}
+
+// @Summary Baixar documento do cadastro
+// @Description Baixa um documento enviado pelo vendor. Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param document_id path int true "ID do documento"
+// @Success 200 {file} file "Documento"
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Documento não encontrado"
+// @Failure 500 {object} map[string]string "Erro ao baixar documento"
+// @Router /vendors/{vendor_id}/documents/{document_id} [get]
+func DownloadVendorDocument(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+		documentID, err := strconv.Atoi(c.Params("document_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do documento inválido"})
+		}
+
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		var name, path, contentType string
+		err = db.QueryRow("SELECT name, path, content_type FROM vendor_documents WHERE id = ? AND vendors_id = ?", documentID, vendorID).
+			Scan(&name, &path, &contentType)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Documento não encontrado"})
+		} else if err != nil {
+			log.Println("Erro ao buscar documento:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao baixar documento"})
+		}
+
+		c.Set(fiber.HeaderContentType, contentType)
+		if err := c.Download(path, name); err != nil {
+			log.Println("Erro ao ler documento:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao baixar documento"})
+		}
+		return nil
+	}
+}
+
+// @Summary Remover documento do cadastro
+// @Description Remove um documento enviado pelo vendor. Documentos de cadastros em análise não podem ser removidos. Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Param document_id path int true "ID do documento"
+// @Success 200 {object} map[string]string "Documento removido"
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Documento não encontrado"
+// @Failure 409 {object} map[string]string "Cadastro em análise"
+// @Failure 500 {object} map[string]string "Erro ao remover documento"
+// @Router /vendors/{vendor_id}/documents/{document_id} [delete]
+func DeleteVendorDocument(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+		documentID, err := strconv.Atoi(c.Params("document_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do documento inválido"})
+		}
+
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+
+		var status, path string
+		err = db.QueryRow(`
+			SELECT v.status, d.path
+			FROM vendor_documents d
+			INNER JOIN vendors v ON v.id = d.vendors_id
+			WHERE d.id = ? AND d.vendors_id = ?`, documentID, vendorID).Scan(&status, &path)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Documento não encontrado"})
+		} else if err != nil {
+			log.Println("Erro ao buscar documento:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover documento"})
+		}
+		if status == VendorStatusUnderReview {
+			return c.Status(409).JSON(fiber.Map{"error": "O cadastro está em análise; aguarde a decisão para alterar os documentos"})
+		}
+
+		if _, err := db.Exec("DELETE FROM vendor_documents WHERE id = ?", documentID); err != nil {
+			log.Println("Erro ao remover documento:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao remover documento"})
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Println("Erro ao apagar arquivo do documento:", err)
+		}
+
+		return c.Status(200).JSON(fiber.Map{"message": "Documento removido com sucesso"})
+	}
+}
+
+// @Summary Reenviar cadastro para análise
+// @Description Devolve para a fila de análise o cadastro reprovado, depois que o vendor corrigiu os dados ou os documentos. Restrito a owners e managers do vendor e a administradores
+// @Tags Vendors
+// @Param X-User-ID header int true "ID do usuário"
+// @Param vendor_id path int true "ID do vendor"
+// @Success 200 {object} map[string]interface{} "Cadastro reenviado"
+// @Failure 400 {object} map[string]string "ID inválido ou documentos pendentes"
+// @Failure 401 {object} map[string]string "Usuário não identificado"
+// @Failure 403 {object} map[string]string "Sem permissão sobre o vendor"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 409 {object} map[string]string "Cadastro não está reprovado"
+// @Failure 500 {object} map[string]string "Erro ao reenviar cadastro"
+// @Router /vendors/{vendor_id}/onboarding/resubmit [post]
+func ResubmitVendorOnboarding(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("vendor_id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+
+		if err := authorizeVendorManager(db, c, vendorID); err != nil {
+			return respondError(c, err, "Erro ao verificar permissões do usuário:", "Erro ao verificar permissões")
+		}
+		userID, _ := callerUserID(c)
+
+		missing, err := missingVendorDocuments(db, vendorID)
+		if err != nil {
+			log.Println("Erro ao verificar documentos do vendor:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao reenviar cadastro"})
+		}
+		if len(missing) > 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Envie os documentos pendentes antes de reenviar o cadastro", "missing_documents": missing})
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao reenviar cadastro"})
+		}
+		defer tx.Rollback()
+
+		var status string
+		if err := tx.QueryRow("SELECT status FROM vendors WHERE id = ? FOR UPDATE", vendorID).Scan(&status); err != nil {
+			log.Println("Erro ao buscar cadastro do vendor:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao reenviar cadastro"})
+		}
+		if status != VendorStatusRejected {
+			return c.Status(409).JSON(fiber.Map{"error": "Apenas cadastros reprovados podem ser reenviados (status atual: " + status + ")"})
+		}
+
+		if err := changeVendorStatus(tx, vendorID, status, VendorStatusSubmitted, nil, userID); err != nil {
+			log.Println("Erro ao reenviar cadastro:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao reenviar cadastro"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao confirmar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao reenviar cadastro"})
+		}
+
+		return c.Status(200).JSON(fiber.Map{"message": "Cadastro reenviado para análise", "vendors_id": vendorID, "status": VendorStatusSubmitted})
+	}
+}
+
+// @Summary Fila de cadastros de vendors
+// @Description Lista os vendors por status do cadastro (padrão submitted), dos mais recentes para os mais antigos
+// @Tags Admin
+// @Param X-User-ID header int true "ID do administrador"
+// @Param status query string false "submitted, under_review, approved, rejected ou suspended" default(submitted)
+// @Param cursor query string false "Cursor da próxima página"
+// @Param limit query int false "Limite de itens por página" default(20)
+// @Param include_total query bool false "Inclui o total de registros"
+// @Success 200 {object} map[string]interface{} "Envelope com data, next_cursor e total"
+// @Failure 400 {object} map[string]string "Parâmetros inválidos"
+// @Failure 500 {object} map[string]string "Erro ao buscar vendors"
+// @Router /admin/vendors [get]
+func GetVendorsForReview(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		status := c.Query("status", VendorStatusSubmitted)
+		if !validVendorStatus(status) {
+			return c.Status(400).JSON(fiber.Map{"error": "Status inválido. Use: submitted, under_review, approved, rejected ou suspended"})
+		}
+
+		params, err := pagination.FromRequest(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		keyset, keysetArgs := params.Keyset("id")
+
+		args := append(append([]interface{}{status}, keysetArgs...), params.FetchLimit())
+		rows, err := db.Query(`
+			SELECT `+vendorColumns+`
+			FROM agrofood.vendors
+			WHERE status = ? AND `+keyset+`
+			ORDER BY id DESC
+			LIMIT ?`, args...)
+		if err != nil {
+			log.Println("Erro ao buscar vendors:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar vendors"})
+		}
+		defer rows.Close()
+
+		var vendors []Vendor
+		for rows.Next() {
+			vendor, err := scanVendor(rows)
+			if err != nil {
+				log.Println("Erro ao escanear vendor:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao ler vendor"})
+			}
+			vendors = append(vendors, vendor)
+		}
+
+		if err := rows.Err(); err != nil {
+			log.Println("Erro com as linhas:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao processar vendors"})
+		}
+
+		page := pagination.NewPage(vendors, params, func(v Vendor) int { return v.ID })
+
+		if params.IncludeTotal {
+			var total int
+			if err := db.QueryRow("SELECT COUNT(*) FROM agrofood.vendors WHERE status = ?", status).Scan(&total); err != nil {
+				log.Println("Erro ao contar vendors:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao contar vendors"})
+			}
+			page = page.WithTotal(total)
+		}
+
+		return c.Status(200).JSON(page)
+	}
+}
+
+// @Summary Decidir cadastro do vendor
+// @Description Coloca o cadastro em análise, aprova, reprova, suspende ou reativa o vendor. A aprovação exige os documentos obrigatórios; reprovar ou suspender exige o motivo. Produtos de vendors não aprovados deixam as vitrines e não podem ser vendidos
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param X-User-ID header int true "ID do administrador"
+// @Param id path int true "ID do vendor"
+// @Param decision body VendorStatusRequest true "Novo status e motivo"
+// @Success 200 {object} map[string]interface{} "Status atualizado"
+// @Failure 400 {object} map[string]string "Status inválido, motivo ausente ou documentos pendentes"
+// @Failure 404 {object} map[string]string "Vendor não encontrado"
+// @Failure 409 {object} map[string]string "Transição de status não permitida"
+// @Failure 500 {object} map[string]string "Erro ao atualizar cadastro"
+// @Router /admin/vendors/{id}/status [post]
+func UpdateVendorStatus(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vendorID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "ID do vendor inválido"})
+		}
+		adminID, _ := c.Locals("admin_user_id").(int)
+
+		var request VendorStatusRequest
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Dados de entrada inválidos"})
+		}
+		if !validVendorStatus(request.Status) || request.Status == VendorStatusSubmitted {
+			return c.Status(400).JSON(fiber.Map{"error": "Status inválido. Use: under_review, approved, rejected ou suspended"})
+		}
+		request.Reason = strings.TrimSpace(request.Reason)
+		if len(request.Reason) > 500 {
+			return c.Status(400).JSON(fiber.Map{"error": "O motivo deve ter até 500 caracteres"})
+		}
+		if request.Reason == "" && (request.Status == VendorStatusRejected || request.Status == VendorStatusSuspended) {
+			return c.Status(400).JSON(fiber.Map{"error": "Informe o motivo da reprovação ou suspensão"})
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			log.Println("Erro ao iniciar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar cadastro"})
+		}
+		defer tx.Rollback()
+
+		var status string
+		err = tx.QueryRow("SELECT status FROM vendors WHERE id = ? FOR UPDATE", vendorID).Scan(&status)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"message": "Vendor não encontrado"})
+		} else if err != nil {
+			log.Println("Erro ao buscar cadastro do vendor:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar cadastro"})
+		}
+
+		allowed := false
+		for _, next := range vendorStatusTransitions[status] {
+			allowed = allowed || next == request.Status
+		}
+		if !allowed {
+			return c.Status(409).JSON(fiber.Map{"error": fmt.Sprintf("Não é possível mudar o cadastro de %s para %s", status, request.Status)})
+		}
+
+		if request.Status == VendorStatusApproved {
+			missing, err := missingVendorDocuments(tx, vendorID)
+			if err != nil {
+				log.Println("Erro ao verificar documentos do vendor:", err)
+				return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar cadastro"})
+			}
+			if len(missing) > 0 {
+				return c.Status(400).JSON(fiber.Map{"error": "O vendor ainda não enviou todos os documentos obrigatórios", "missing_documents": missing})
+			}
+		}
+
+		var reason interface{}
+		if request.Reason != "" {
+			reason = request.Reason
+		}
+		if err := changeVendorStatus(tx, vendorID, status, request.Status, reason, adminID); err != nil {
+			log.Println("Erro ao atualizar cadastro do vendor:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar cadastro"})
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("Erro ao confirmar transação:", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Erro ao atualizar cadastro"})
+		}
+
+		// Os produtos entram ou saem das vitrines junto com a aprovação do vendor
+		if status == VendorStatusApproved || request.Status == VendorStatusApproved {
+			reindexVendorProducts(db, vendorID)
+		}
+
+		return c.Status(200).JSON(fiber.Map{
+			"message":     "Cadastro do vendor atualizado",
+			"vendors_id":  vendorID,
+			"from_status": status,
+			"status":      request.Status,
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Package document valida, normaliza e formata os documentos de cadastro
+// brasileiros: CPF e CNPJ, incluindo o CNPJ alfanumérico. Os documentos são
+// gravados normalizados (sem pontuação, letras maiúsculas) para que a
+// verificação de duplicidade não dependa da forma como foram digitados, e
+// formatados apenas na leitura.
+package document
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	cpfLength  = 11
+	cnpjLength = 14
+)
+
+var (
+	ErrInvalidCPF  = errors.New("CPF inválido")
+	ErrInvalidCNPJ = errors.New("CNPJ inválido")
+)
+
+// Pesos do cálculo dos dígitos verificadores do CNPJ; o primeiro dígito usa
+// os pesos a partir da segunda posição
+var cnpjWeights = []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// strip remove os separadores aceitos na digitação (ponto, hífen, barra e
+// espaços) e converte as letras para maiúsculas. Qualquer outro caractere
+// torna o documento inválido.
+func strip(value string) (string, bool) {
+	var builder strings.Builder
+	builder.Grow(len(value))
+	for _, r := range strings.ToUpper(value) {
+		switch {
+		case r == '.' || r == '-' || r == '/' || r == ' ' || r == '\t':
+		case (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z'):
+			builder.WriteRune(r)
+		default:
+			return "", false
+		}
+	}
+	return builder.String(), true
+}
+
+// repeated indica se todos os caracteres são iguais ("00000000000"), padrão
+// que passa no cálculo dos dígitos mas não é um documento válido
+func repeated(value string) bool {
+	return strings.Count(value, value[:1]) == len(value)
+}
+
+// NormalizeCPF valida o CPF, com ou sem pontuação, e o devolve só com os 11
+// dígitos
+func NormalizeCPF(value string) (string, error) {
+	cpf, ok := strip(value)
+	if !ok || len(cpf) != cpfLength || repeated(cpf) {
+		return "", ErrInvalidCPF
+	}
+	for _, r := range cpf {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidCPF
+		}
+	}
+
+	for length := 9; length <= 10; length++ {
+		sum := 0
+		for i := 0; i < length; i++ {
+			sum += int(cpf[i]-'0') * (length + 1 - i)
+		}
+		digit := sum * 10 % 11
+		if digit == 10 {
+			digit = 0
+		}
+		if int(cpf[length]-'0') != digit {
+			return "", ErrInvalidCPF
+		}
+	}
+	return cpf, nil
+}
+
+// NormalizeCNPJ valida o CNPJ numérico ou alfanumérico, com ou sem pontuação,
+// e o devolve com os 14 caracteres em maiúsculas. No formato alfanumérico as
+// 12 primeiras posições aceitam letras e os dois dígitos verificadores são
+// sempre numéricos.
+func NormalizeCNPJ(value string) (string, error) {
+	cnpj, ok := strip(value)
+	if !ok || len(cnpj) != cnpjLength || repeated(cnpj) {
+		return "", ErrInvalidCNPJ
+	}
+	for i := cnpjLength - 2; i < cnpjLength; i++ {
+		if cnpj[i] < '0' || cnpj[i] > '9' {
+			return "", ErrInvalidCNPJ
+		}
+	}
+
+	for length := 12; length <= 13; length++ {
+		weights := cnpjWeights[13-length:]
+		sum := 0
+		for i := 0; i < length; i++ {
+			// Cada caractere vale o seu código ASCII menos 48 ('0' = 0, 'A' = 17)
+			sum += int(cnpj[i]-'0') * weights[i]
+		}
+		digit := 0
+		if remainder := sum % 11; remainder >= 2 {
+			digit = 11 - remainder
+		}
+		if int(cnpj[length]-'0') != digit {
+			return "", ErrInvalidCNPJ
+		}
+	}
+	return cnpj, nil
+}
+
+// ValidCPF indica se o CPF é válido
+func ValidCPF(value string) bool {
+	_, err := NormalizeCPF(value)
+	return err == nil
+}
+
+// ValidCNPJ indica se o CNPJ é válido
+func ValidCNPJ(value string) bool {
+	_, err := NormalizeCNPJ(value)
+	return err == nil
+}
+
+// FormatCPF formata o CPF normalizado como 000.000.000-00. Valores fora do
+// formato normalizado (cadastros antigos inválidos) são devolvidos sem alteração.
+func FormatCPF(cpf string) string {
+	if len(cpf) != cpfLength {
+		return cpf
+	}
+	return cpf[:3] + "." + cpf[3:6] + "." + cpf[6:9] + "-" + cpf[9:]
+}
+
+// FormatCNPJ formata o CNPJ normalizado como 00.000.000/0000-00. Valores fora
+// do formato normalizado são devolvidos sem alteração.
+func FormatCNPJ(cnpj string) string {
+	if len(cnpj) != cnpjLength {
+		return cnpj
+	}
+	return cnpj[:2] + "." + cnpj[2:5] + "." + cnpj[5:8] + "/" + cnpj[8:12] + "-" + cnpj[12:]
+}
@@ -0,0 +1,85 @@
+package document
+
+import "testing"
+
+func TestNormalizeCPF(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+		valid bool
+	}{
+		{"formatado", "529.982.247-25", "52998224725", true},
+		{"só dígitos", "11144477735", "11144477735", true},
+		{"com espaços", " 111 444 777 35 ", "11144477735", true},
+		{"primeiro dígito verificador zero", "123.456.789-09", "12345678909", true},
+		{"primeiro dígito errado", "529.982.247-35", "", false},
+		{"segundo dígito errado", "529.982.247-26", "", false},
+		{"dígitos repetidos", "000.000.000-00", "", false},
+		{"todos iguais a nove", "99999999999", "", false},
+		{"curto demais", "5299822472", "", false},
+		{"longo demais", "529982247250", "", false},
+		{"com letras", "52998224A25", "", false},
+		{"caractere não aceito", "529,982,247-25", "", false},
+		{"vazio", "", "", false},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeCPF(tt.value)
+		if (err == nil) != tt.valid || got != tt.want {
+			t.Errorf("%s: NormalizeCPF(%q) = %q, %v", tt.name, tt.value, got, err)
+		}
+		if ValidCPF(tt.value) != tt.valid {
+			t.Errorf("%s: ValidCPF(%q) = %v", tt.name, tt.value, !tt.valid)
+		}
+	}
+}
+
+func TestNormalizeCNPJ(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+		valid bool
+	}{
+		{"formatado", "11.222.333/0001-81", "11222333000181", true},
+		{"só dígitos", "11222333000181", "11222333000181", true},
+		{"alfanumérico", "12.ABC.345/01DE-35", "12ABC34501DE35", true},
+		{"alfanumérico em minúsculas", "12abc34501de35", "12ABC34501DE35", true},
+		{"primeiro dígito errado", "11.222.333/0001-91", "", false},
+		{"segundo dígito errado", "11.222.333/0001-80", "", false},
+		{"letra no dígito verificador", "12ABC34501DE3A", "", false},
+		{"alfanumérico com dígito errado", "12ABC34501DE36", "", false},
+		{"repetido", "00000000000000", "", false},
+		{"curto demais", "1122233300018", "", false},
+		{"caractere não aceito", "11.222.333_0001-81", "", false},
+		{"vazio", "", "", false},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeCNPJ(tt.value)
+		if (err == nil) != tt.valid || got != tt.want {
+			t.Errorf("%s: NormalizeCNPJ(%q) = %q, %v", tt.name, tt.value, got, err)
+		}
+		if ValidCNPJ(tt.value) != tt.valid {
+			t.Errorf("%s: ValidCNPJ(%q) = %v", tt.name, tt.value, !tt.valid)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		format func(string) string
+		value  string
+		want   string
+	}{
+		{FormatCPF, "52998224725", "529.982.247-25"},
+		{FormatCPF, "123", "123"},
+		{FormatCNPJ, "11222333000181", "11.222.333/0001-81"},
+		{FormatCNPJ, "12ABC34501DE35", "12.ABC.345/01DE-35"},
+		{FormatCNPJ, "invalido", "invalido"},
+	}
+	for _, tt := range tests {
+		if got := tt.format(tt.value); got != tt.want {
+			t.Errorf("format(%q) = %q, quero %q", tt.value, got, tt.want)
+		}
+	}
+}
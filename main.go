@@ -1,9 +1,11 @@
 package main
 
 import (
+	"api/controllers"
 	"api/routes"
 	"database/sql"
 	"log"
+	"time"
 
 	_ "api/docs" // Certifique-se de importar o pacote docs gerado pelo swag
 
@@ -21,6 +23,7 @@ var db *sql.DB
 // @title API do AgroFood
 // @version 1.0
 // @description API para gerenciar o sistema Agrofood.
+// @description O usuário é identificado pelo cabeçalho X-User-ID, definido pelo gateway que o autentica. Com AUTH_GATEWAY_SECRET configurado, o gateway também envia X-User-Timestamp (Unix, em segundos) e X-User-Signature (HMAC-SHA256 em hexadecimal de "id:timestamp"); sem o segredo o cabeçalho não é verificado e as rotas /admin respondem 503.
 // @host localhost:3002
 // @BasePath /
 func main() {
@@ -37,6 +40,34 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// A identidade dos usuários vem do gateway; avisa se ela não é verificada
+	controllers.WarnUnverifiedIdentity()
+
+	// Carrega o índice de busca textual dos produtos
+	if err := controllers.InitSearchIndex(db); err != nil {
+		log.Fatal(err)
+	}
+
+	// Gera o endereço da vitrine dos vendors cadastrados antes das vitrines
+	if err := controllers.EnsureVendorSlugs(db); err != nil {
+		log.Fatal(err)
+	}
+
+	// Baixa periodicamente o saldo dos lotes vencidos
+	controllers.StartLotExpiryJob(db, time.Hour)
+
+	// Libera as reservas de estoque vencidas dos carrinhos
+	controllers.StartReservationSweeper(db, time.Minute)
+
+	// Avalia periodicamente os alertas de estoque dos vendors
+	controllers.StartAlertEvaluator(db, 15*time.Minute)
+
+	// Aplica e reverte os preços programados dos produtos
+	controllers.StartPriceScheduler(db, time.Minute)
+
+	// Reconstrói as sugestões de busca depois de alterações no catálogo
+	controllers.StartSuggestionRefresher(db, 30*time.Second)
+
 	// Inicializa o Fiber
 	app := fiber.New()
 
@@ -51,10 +82,13 @@ func main() {
 	routes.RegisterProductRoutes(app, db)
 	routes.RegisterImageRoutes(app, db)
 	routes.RegisterVendorRoutes(app, db)
+	routes.RegisterStorefrontRoutes(app, db)
+	routes.RegisterAddressRoutes(app, db)
 	routes.RegisterCategoryRoutes(app, db)
 	routes.RegisterCartRoutes(app, db)
 
 	routes.RegisterBuyerRoutes(app, db)
+	routes.RegisterAdminRoutes(app, db)
 
 	// Adicionar rota para a documentação Swagger
 	app.Get("/swagger/*", swagger.HandlerDefault) // serve swagger
@@ -0,0 +1,29 @@
+-- Esquemas de atributos por categoria (herdados pelas subcategorias)
+CREATE TABLE IF NOT EXISTS category_attributes (
+    id INT NOT NULL AUTO_INCREMENT,
+    categories_products_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    type ENUM('text', 'number', 'integer', 'boolean', 'enum') NOT NULL,
+    unit VARCHAR(20) NULL,
+    required TINYINT(1) NOT NULL DEFAULT 0,
+    allowed_values JSON NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_category_attributes_name (categories_products_id, name),
+    CONSTRAINT fk_category_attributes_category
+        FOREIGN KEY (categories_products_id) REFERENCES categories_products (id)
+        ON DELETE CASCADE
+);
+
+-- Valores validados dos atributos de cada produto
+CREATE TABLE IF NOT EXISTS product_attributes (
+    products_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    value_number DOUBLE NULL,
+    PRIMARY KEY (products_id, name),
+    KEY idx_product_attributes_value (name, value),
+    KEY idx_product_attributes_number (name, value_number),
+    CONSTRAINT fk_product_attributes_product
+        FOREIGN KEY (products_id) REFERENCES products (id)
+        ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+-- Descrição dos produtos, indexada pela busca textual junto com nome,
+-- categoria e vendor
+ALTER TABLE products
+    ADD COLUMN description TEXT NULL AFTER name;
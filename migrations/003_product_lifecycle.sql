@@ -0,0 +1,6 @@
+-- Ciclo de vida dos produtos: status de publicação e exclusão lógica.
+-- Produtos existentes continuam visíveis como publicados.
+ALTER TABLE products
+    ADD COLUMN status ENUM('draft', 'published', 'archived', 'out_of_season') NOT NULL DEFAULT 'published' AFTER categories_products_id,
+    ADD COLUMN deleted_at DATETIME NULL AFTER status,
+    ADD INDEX idx_products_status_deleted (status, deleted_at);
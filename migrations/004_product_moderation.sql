@@ -0,0 +1,23 @@
+-- Moderação de produtos: categorias que exigem revisão (herdado pelas
+-- subcategorias), novos status e fila de revisões com o histórico de decisões.
+ALTER TABLE categories_products
+    ADD COLUMN requires_moderation TINYINT(1) NOT NULL DEFAULT 0;
+
+ALTER TABLE products
+    MODIFY COLUMN status ENUM('draft', 'published', 'archived', 'out_of_season', 'pending_review', 'rejected') NOT NULL DEFAULT 'published';
+
+CREATE TABLE product_moderation (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    products_id INT NOT NULL,
+    users_id INT NULL,
+    action ENUM('create', 'update') NOT NULL,
+    payload JSON NULL,
+    status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending',
+    reason VARCHAR(500) NULL,
+    reviewed_by INT NULL,
+    reviewed_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_product_moderation_status (status, id),
+    INDEX idx_product_moderation_product (products_id, id),
+    CONSTRAINT fk_product_moderation_product FOREIGN KEY (products_id) REFERENCES products (id) ON DELETE CASCADE
+);
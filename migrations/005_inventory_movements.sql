@@ -0,0 +1,22 @@
+-- Livro-razão de estoque: cada alteração de products.quantity gera um
+-- movimento com o saldo resultante.
+CREATE TABLE inventory_movements (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    products_id INT NOT NULL,
+    type ENUM('receipt', 'sale', 'cancellation_return', 'adjustment', 'loss') NOT NULL,
+    delta INT NOT NULL,
+    balance_after INT NOT NULL,
+    users_id INT NULL,
+    orders_id INT NULL,
+    reason VARCHAR(255) NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_inventory_movements_product (products_id, id),
+    INDEX idx_inventory_movements_order (orders_id),
+    CONSTRAINT fk_inventory_movements_product FOREIGN KEY (products_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+-- Saldo de abertura dos produtos existentes, para que o livro-razão concilie
+-- com o estoque atual
+INSERT INTO inventory_movements (products_id, type, delta, balance_after, reason)
+SELECT id, 'adjustment', quantity, quantity, 'Saldo inicial'
+FROM products;
@@ -0,0 +1,26 @@
+-- Lotes de estoque com datas de produção/colheita e validade. O estoque do
+-- produto (products.quantity) continua sendo o saldo físico total; lotes
+-- vencidos deixam de ser vendáveis e são baixados como perda.
+CREATE TABLE product_lots (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    products_id INT NOT NULL,
+    lot_code VARCHAR(64) NOT NULL,
+    produced_at DATE NULL,
+    expires_at DATE NULL,
+    quantity_received INT NOT NULL,
+    quantity_remaining INT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_product_lots_code (products_id, lot_code),
+    INDEX idx_product_lots_fefo (products_id, expires_at),
+    CONSTRAINT fk_product_lots_product FOREIGN KEY (products_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+-- Lotes de onde saiu cada item de pedido
+CREATE TABLE order_item_lots (
+    order_items_id INT NOT NULL,
+    product_lots_id INT NOT NULL,
+    quantity INT NOT NULL,
+    PRIMARY KEY (order_items_id, product_lots_id),
+    CONSTRAINT fk_order_item_lots_item FOREIGN KEY (order_items_id) REFERENCES order_items (id) ON DELETE CASCADE,
+    CONSTRAINT fk_order_item_lots_lot FOREIGN KEY (product_lots_id) REFERENCES product_lots (id)
+);
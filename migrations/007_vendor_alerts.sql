@@ -0,0 +1,20 @@
+-- Limite de reposição por produto; NULL desativa o alerta de estoque baixo
+ALTER TABLE products ADD COLUMN reorder_threshold INT NULL;
+
+-- Alertas de estoque dos vendors, abertos e resolvidos pelo avaliador
+-- periódico do servidor
+CREATE TABLE vendor_alerts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    vendors_id INT NOT NULL,
+    products_id INT NOT NULL,
+    product_lots_id INT NULL,
+    type ENUM('low_stock', 'out_of_stock', 'lot_expiring') NOT NULL,
+    message VARCHAR(255) NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    resolved_at DATETIME NULL,
+    INDEX idx_vendor_alerts_open (vendors_id, resolved_at),
+    CONSTRAINT fk_vendor_alerts_vendor FOREIGN KEY (vendors_id) REFERENCES vendors (id) ON DELETE CASCADE,
+    CONSTRAINT fk_vendor_alerts_product FOREIGN KEY (products_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_vendor_alerts_lot FOREIGN KEY (product_lots_id) REFERENCES product_lots (id) ON DELETE CASCADE
+);
@@ -0,0 +1,19 @@
+-- Reservas temporárias de estoque feitas pelos itens de carrinho. Reservas
+-- ativas e não vencidas saem do estoque vendável; o checkout as converte em
+-- venda e a varredura periódica marca as vencidas como expired.
+CREATE TABLE stock_reservations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    cart_items_id INT NOT NULL,
+    cart_id INT NOT NULL,
+    products_id INT NOT NULL,
+    quantity INT NOT NULL,
+    status ENUM('active', 'converted', 'released', 'expired') NOT NULL DEFAULT 'active',
+    orders_id INT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_stock_reservations_product (products_id, status, expires_at),
+    INDEX idx_stock_reservations_item (cart_items_id, status),
+    INDEX idx_stock_reservations_cart (cart_id, status),
+    CONSTRAINT fk_stock_reservations_product FOREIGN KEY (products_id) REFERENCES products (id) ON DELETE CASCADE
+);
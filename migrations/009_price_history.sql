@@ -0,0 +1,35 @@
+-- Histórico de preços: cada alteração de products.price gera um registro com
+-- o preço anterior, o novo e quem alterou
+CREATE TABLE product_price_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    products_id INT NOT NULL,
+    old_price DECIMAL(10, 2) NULL,
+    new_price DECIMAL(10, 2) NOT NULL,
+    users_id INT NULL,
+    product_price_schedules_id INT NULL,
+    reason VARCHAR(255) NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_product_price_history_product (products_id, id),
+    CONSTRAINT fk_product_price_history_product FOREIGN KEY (products_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+-- Preços programados: aplicados em starts_at e revertidos em ends_at
+CREATE TABLE product_price_schedules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    products_id INT NOT NULL,
+    price DECIMAL(10, 2) NOT NULL,
+    starts_at DATETIME NOT NULL,
+    ends_at DATETIME NULL,
+    previous_price DECIMAL(10, 2) NULL,
+    status ENUM('scheduled', 'active', 'completed', 'cancelled') NOT NULL DEFAULT 'scheduled',
+    users_id INT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_product_price_schedules_due (status, starts_at),
+    INDEX idx_product_price_schedules_product (products_id, status),
+    CONSTRAINT fk_product_price_schedules_product FOREIGN KEY (products_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+-- Preço de abertura dos produtos existentes
+INSERT INTO product_price_history (products_id, old_price, new_price, reason)
+SELECT id, NULL, price, 'Preço inicial'
+FROM products;
@@ -0,0 +1,21 @@
+-- Variantes de produto: cada variante (embalagem, classificação, tamanho) é
+-- um produto com SKU, preço e estoque próprios, ligado ao produto principal
+-- por parent_id. As vitrines e a busca exibem apenas o produto principal.
+ALTER TABLE products
+    ADD COLUMN parent_id INT NULL AFTER id,
+    ADD INDEX idx_products_parent (parent_id, deleted_at),
+    ADD CONSTRAINT fk_products_parent FOREIGN KEY (parent_id) REFERENCES products (id);
+
+-- Atributos que distinguem a variante das demais (ex.: embalagem = 25 kg)
+CREATE TABLE product_variant_attributes (
+    products_id INT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    value VARCHAR(100) NOT NULL,
+    PRIMARY KEY (products_id, name),
+    CONSTRAINT fk_product_variant_attributes_product FOREIGN KEY (products_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+-- Descrição da variante escolhida, preservada no pedido mesmo que os
+-- atributos da variante mudem depois
+ALTER TABLE order_items
+    ADD COLUMN variant_label VARCHAR(255) NULL;
@@ -0,0 +1,30 @@
+-- Avaliações de produtos: uma por item de pedido entregue, com nota de 1 a 5,
+-- resposta do vendor e moderação pelos administradores. Avaliações de
+-- variantes contam para o produto principal.
+CREATE TABLE product_reviews (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    products_id INT NOT NULL,
+    order_items_id INT NOT NULL,
+    users_id INT NOT NULL,
+    rating TINYINT NOT NULL,
+    comment TEXT NULL,
+    status ENUM('published', 'hidden') NOT NULL DEFAULT 'published',
+    moderation_reason VARCHAR(500) NULL,
+    moderated_by INT NULL,
+    moderated_at DATETIME NULL,
+    vendor_reply TEXT NULL,
+    vendor_replied_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_product_reviews_order_item (order_items_id),
+    INDEX idx_product_reviews_product (products_id, status, id),
+    INDEX idx_product_reviews_status (status, id),
+    CONSTRAINT chk_product_reviews_rating CHECK (rating BETWEEN 1 AND 5),
+    CONSTRAINT fk_product_reviews_product FOREIGN KEY (products_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+-- Média e quantidade das avaliações publicadas, mantidas a cada avaliação
+-- para ordenar e filtrar a busca
+ALTER TABLE products
+    ADD COLUMN rating_average DECIMAL(3, 2) NULL,
+    ADD COLUMN rating_count INT NOT NULL DEFAULT 0,
+    ADD INDEX idx_products_rating (rating_average);
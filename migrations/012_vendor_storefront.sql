@@ -0,0 +1,19 @@
+-- Vitrine pública do vendor: endereço amigável (/stores/:slug), logo e banner.
+-- O slug dos vendors existentes é gerado pela API na inicialização.
+ALTER TABLE vendors
+    ADD COLUMN slug VARCHAR(120) NULL,
+    ADD COLUMN logo_path VARCHAR(255) NULL,
+    ADD COLUMN banner_path VARCHAR(255) NULL,
+    ADD UNIQUE KEY uq_vendors_slug (slug);
+
+-- Regiões atendidas pelo vendor: um estado inteiro ou uma cidade do estado
+CREATE TABLE vendor_delivery_zones (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    vendors_id INT NOT NULL,
+    type ENUM('state', 'city') NOT NULL,
+    state CHAR(2) NOT NULL,
+    city VARCHAR(100) NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_vendor_delivery_zones (vendors_id, type, state, city),
+    CONSTRAINT fk_vendor_delivery_zones_vendor FOREIGN KEY (vendors_id) REFERENCES vendors (id) ON DELETE CASCADE
+);
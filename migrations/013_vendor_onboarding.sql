@@ -0,0 +1,40 @@
+-- Cadastro de vendors com aprovação: o vendor nasce submetido e só vende
+-- depois de aprovado pelos administradores, com base nos documentos enviados.
+-- Os vendors já cadastrados são mantidos aprovados.
+ALTER TABLE vendors
+    ADD COLUMN status ENUM('submitted', 'under_review', 'approved', 'rejected', 'suspended') NOT NULL DEFAULT 'submitted',
+    ADD COLUMN status_reason VARCHAR(500) NULL,
+    ADD COLUMN reviewed_by INT NULL,
+    ADD COLUMN reviewed_at DATETIME NULL,
+    ADD INDEX idx_vendors_status (status, id),
+    ADD INDEX idx_vendors_users (users_id, status);
+
+UPDATE vendors SET status = 'approved';
+
+-- Documentos do cadastro (contrato social, comprovante de endereço)
+CREATE TABLE vendor_documents (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    vendors_id INT NOT NULL,
+    type ENUM('contrato_social', 'proof_of_address') NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size INT NOT NULL,
+    users_id INT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_vendor_documents_vendor (vendors_id, type, id),
+    CONSTRAINT fk_vendor_documents_vendor FOREIGN KEY (vendors_id) REFERENCES vendors (id) ON DELETE CASCADE
+);
+
+-- Histórico das mudanças de status do cadastro
+CREATE TABLE vendor_status_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    vendors_id INT NOT NULL,
+    from_status VARCHAR(20) NULL,
+    to_status VARCHAR(20) NOT NULL,
+    reason VARCHAR(500) NULL,
+    users_id INT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_vendor_status_history_vendor (vendors_id, id),
+    CONSTRAINT fk_vendor_status_history_vendor FOREIGN KEY (vendors_id) REFERENCES vendors (id) ON DELETE CASCADE
+);
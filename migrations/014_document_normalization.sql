@@ -0,0 +1,40 @@
+-- CPF e CNPJ passam a ser gravados normalizados (sem pontuação, letras
+-- maiúsculas no CNPJ alfanumérico), para que a verificação de duplicidade não
+-- dependa da forma como o documento foi digitado. A API formata na leitura.
+
+-- Antes de alterar os cadastros, a migração falha (chave duplicada em
+-- uq_document_normalization) se a normalização deixar dois cadastros com o
+-- mesmo documento. Os conflitos e os documentos com dígitos verificadores
+-- inválidos são listados por `go run ./cmd/documentcheck` e devem ser
+-- corrigidos antes de rodar a migração novamente.
+CREATE TEMPORARY TABLE document_normalization_check (
+    tabela VARCHAR(16) NOT NULL,
+    documento VARCHAR(32) NOT NULL,
+    UNIQUE KEY uq_document_normalization (tabela, documento)
+);
+
+INSERT INTO document_normalization_check (tabela, documento)
+SELECT 'users', REPLACE(REPLACE(REPLACE(REPLACE(TRIM(cpf), '.', ''), '-', ''), '/', ''), ' ', '')
+FROM users WHERE cpf IS NOT NULL AND TRIM(cpf) <> '';
+
+INSERT INTO document_normalization_check (tabela, documento)
+SELECT 'vendors', UPPER(REPLACE(REPLACE(REPLACE(REPLACE(TRIM(cnpj), '.', ''), '-', ''), '/', ''), ' ', ''))
+FROM vendors WHERE cnpj IS NOT NULL AND TRIM(cnpj) <> '';
+
+INSERT INTO document_normalization_check (tabela, documento)
+SELECT 'buyers', UPPER(REPLACE(REPLACE(REPLACE(REPLACE(TRIM(cnpj), '.', ''), '-', ''), '/', ''), ' ', ''))
+FROM buyers WHERE cnpj IS NOT NULL AND TRIM(cnpj) <> '';
+
+DROP TEMPORARY TABLE document_normalization_check;
+
+UPDATE users
+SET cpf = REPLACE(REPLACE(REPLACE(REPLACE(TRIM(cpf), '.', ''), '-', ''), '/', ''), ' ', '')
+WHERE cpf IS NOT NULL;
+
+UPDATE vendors
+SET cnpj = UPPER(REPLACE(REPLACE(REPLACE(REPLACE(TRIM(cnpj), '.', ''), '-', ''), '/', ''), ' ', ''))
+WHERE cnpj IS NOT NULL;
+
+UPDATE buyers
+SET cnpj = UPPER(REPLACE(REPLACE(REPLACE(REPLACE(TRIM(cnpj), '.', ''), '-', ''), '/', ''), ' ', ''))
+WHERE cnpj IS NOT NULL;
@@ -0,0 +1,38 @@
+-- Base local de CEPs usada no preenchimento automático de endereços. É
+-- carregada pelos administradores a partir de uma planilha (CSV ou XLSX),
+-- sem depender de serviços externos.
+CREATE TABLE cep_addresses (
+    cep CHAR(8) PRIMARY KEY,
+    street VARCHAR(255) NULL,
+    neighborhood VARCHAR(120) NULL,
+    city VARCHAR(120) NOT NULL,
+    state CHAR(2) NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_cep_addresses_city (state, city)
+);
+
+-- CEPs passam a ser gravados só com os dígitos e estados como a sigla da UF.
+-- A comparação pelo nome usa a collation padrão, que ignora acentos e caixa.
+CREATE TEMPORARY TABLE state_names (uf CHAR(2) NOT NULL, name VARCHAR(30) NOT NULL);
+INSERT INTO state_names (uf, name) VALUES
+    ('AC', 'Acre'), ('AL', 'Alagoas'), ('AP', 'Amapá'), ('AM', 'Amazonas'), ('BA', 'Bahia'),
+    ('CE', 'Ceará'), ('DF', 'Distrito Federal'), ('ES', 'Espírito Santo'), ('GO', 'Goiás'),
+    ('MA', 'Maranhão'), ('MT', 'Mato Grosso'), ('MS', 'Mato Grosso do Sul'), ('MG', 'Minas Gerais'),
+    ('PA', 'Pará'), ('PB', 'Paraíba'), ('PR', 'Paraná'), ('PE', 'Pernambuco'), ('PI', 'Piauí'),
+    ('RJ', 'Rio de Janeiro'), ('RN', 'Rio Grande do Norte'), ('RS', 'Rio Grande do Sul'),
+    ('RO', 'Rondônia'), ('RR', 'Roraima'), ('SC', 'Santa Catarina'), ('SP', 'São Paulo'),
+    ('SE', 'Sergipe'), ('TO', 'Tocantins');
+
+UPDATE vendors SET cep = REPLACE(REPLACE(REPLACE(TRIM(cep), '-', ''), '.', ''), ' ', '') WHERE cep IS NOT NULL;
+UPDATE buyers SET cep = REPLACE(REPLACE(REPLACE(TRIM(cep), '-', ''), '.', ''), ' ', '') WHERE cep IS NOT NULL;
+UPDATE orders SET shipping_cep = REPLACE(REPLACE(REPLACE(TRIM(shipping_cep), '-', ''), '.', ''), ' ', '') WHERE shipping_cep IS NOT NULL;
+
+UPDATE vendors SET state = UPPER(TRIM(state)) WHERE CHAR_LENGTH(TRIM(state)) = 2;
+UPDATE buyers SET state = UPPER(TRIM(state)) WHERE CHAR_LENGTH(TRIM(state)) = 2;
+UPDATE orders SET shipping_state = UPPER(TRIM(shipping_state)) WHERE CHAR_LENGTH(TRIM(shipping_state)) = 2;
+
+UPDATE vendors v INNER JOIN state_names s ON TRIM(v.state) = s.name SET v.state = s.uf;
+UPDATE buyers b INNER JOIN state_names s ON TRIM(b.state) = s.name SET b.state = s.uf;
+UPDATE orders o INNER JOIN state_names s ON TRIM(o.shipping_state) = s.name SET o.shipping_state = s.uf;
+
+DROP TEMPORARY TABLE state_names;
@@ -0,0 +1,27 @@
+-- Catálogo de endereços de entrega do comprador (fazendas, depósitos), para
+-- que o checkout não precise repetir os dados a cada pedido. O pedido continua
+-- gravando uma cópia do endereço escolhido, que não muda se o endereço for
+-- alterado ou removido depois.
+CREATE TABLE buyer_addresses (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    buyers_id INT NOT NULL,
+    label VARCHAR(60) NOT NULL,
+    address VARCHAR(255) NOT NULL,
+    neighborhood VARCHAR(120) NULL,
+    city VARCHAR(120) NOT NULL,
+    state CHAR(2) NOT NULL,
+    cep CHAR(8) NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_buyer_addresses_label (buyers_id, label),
+    INDEX idx_buyer_addresses_default (buyers_id, is_default),
+    CONSTRAINT fk_buyer_addresses_buyer FOREIGN KEY (buyers_id) REFERENCES buyers (id) ON DELETE CASCADE
+);
+
+-- O endereço do cadastro de cada comprador vira o endereço padrão
+INSERT INTO buyer_addresses (buyers_id, label, address, neighborhood, city, state, cep, is_default)
+SELECT id, 'Principal', address, NULLIF(TRIM(neighborhood), ''), city, state, cep, TRUE
+FROM buyers
+WHERE TRIM(COALESCE(address, '')) <> '' AND TRIM(COALESCE(city, '')) <> ''
+    AND CHAR_LENGTH(state) = 2 AND CHAR_LENGTH(cep) = 8;
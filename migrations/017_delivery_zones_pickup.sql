@@ -0,0 +1,49 @@
+-- Regiões de entrega por faixa de CEP e por raio a partir do CEP do vendor,
+-- além das regiões por estado e cidade. O estado da região é o da faixa de
+-- CEP ou, no raio, o do CEP do vendor.
+ALTER TABLE vendor_delivery_zones
+    MODIFY COLUMN type ENUM('state', 'city', 'cep_range', 'radius') NOT NULL,
+    ADD COLUMN cep_start CHAR(8) NULL AFTER city,
+    ADD COLUMN cep_end CHAR(8) NULL AFTER cep_start,
+    ADD COLUMN radius_km DECIMAL(6, 1) NULL AFTER cep_end;
+
+-- Coordenadas do CEP, usadas nas regiões por raio
+ALTER TABLE cep_addresses
+    ADD COLUMN latitude DECIMAL(9, 6) NULL,
+    ADD COLUMN longitude DECIMAL(9, 6) NULL;
+
+-- Locais de retirada do vendor (ex.: a própria fazenda)
+CREATE TABLE vendor_pickup_points (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    vendors_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    address VARCHAR(255) NOT NULL,
+    neighborhood VARCHAR(120) NULL,
+    city VARCHAR(120) NOT NULL,
+    state CHAR(2) NOT NULL,
+    cep CHAR(8) NOT NULL,
+    instructions VARCHAR(500) NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_vendor_pickup_points_vendor (vendors_id, active),
+    CONSTRAINT fk_vendor_pickup_points_vendor FOREIGN KEY (vendors_id) REFERENCES vendors (id) ON DELETE CASCADE
+);
+
+-- Horário de funcionamento dos locais de retirada (0 = domingo)
+CREATE TABLE vendor_pickup_hours (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    pickup_points_id INT NOT NULL,
+    weekday TINYINT NOT NULL,
+    opens_at TIME NOT NULL,
+    closes_at TIME NOT NULL,
+    INDEX idx_vendor_pickup_hours_point (pickup_points_id, weekday, opens_at),
+    CONSTRAINT fk_vendor_pickup_hours_point FOREIGN KEY (pickup_points_id) REFERENCES vendor_pickup_points (id) ON DELETE CASCADE
+);
+
+-- Pedido com entrega no endereço do comprador ou retirada no local do vendor.
+-- Na retirada, os campos de entrega do pedido guardam o endereço do local.
+ALTER TABLE orders
+    ADD COLUMN fulfillment ENUM('delivery', 'pickup') NOT NULL DEFAULT 'delivery',
+    ADD COLUMN pickup_points_id INT NULL,
+    ADD CONSTRAINT fk_orders_pickup_point FOREIGN KEY (pickup_points_id) REFERENCES vendor_pickup_points (id) ON DELETE SET NULL;
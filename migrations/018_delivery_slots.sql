@@ -0,0 +1,22 @@
+-- Janelas de entrega publicadas pelo vendor, com capacidade em pedidos. Cada
+-- pedido com janela ocupa uma vaga (booked), devolvida no cancelamento.
+CREATE TABLE delivery_slots (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    vendors_id INT NOT NULL,
+    slot_date DATE NOT NULL,
+    starts_at TIME NOT NULL,
+    ends_at TIME NOT NULL,
+    capacity INT NOT NULL,
+    booked INT NOT NULL DEFAULT 0,
+    status ENUM('open', 'closed') NOT NULL DEFAULT 'open',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_delivery_slots (vendors_id, slot_date, starts_at),
+    CONSTRAINT chk_delivery_slots_capacity CHECK (booked >= 0 AND booked <= capacity),
+    CONSTRAINT fk_delivery_slots_vendor FOREIGN KEY (vendors_id) REFERENCES vendors (id) ON DELETE CASCADE
+);
+
+ALTER TABLE orders
+    ADD COLUMN delivery_slots_id INT NULL,
+    ADD INDEX idx_orders_delivery_slot (delivery_slots_id),
+    ADD CONSTRAINT fk_orders_delivery_slot FOREIGN KEY (delivery_slots_id) REFERENCES delivery_slots (id) ON DELETE SET NULL;
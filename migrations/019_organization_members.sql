@@ -0,0 +1,78 @@
+-- Equipes de vendors e compradores: vários usuários por organização, com o
+-- papel de cada um (owner, manager, operator). vendors.users_id e
+-- buyers.users_id passam a indicar apenas quem criou o cadastro.
+CREATE TABLE vendor_members (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    vendors_id INT NOT NULL,
+    users_id INT NOT NULL,
+    role ENUM('owner', 'manager', 'operator') NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_vendor_members (vendors_id, users_id),
+    INDEX idx_vendor_members_user (users_id),
+    CONSTRAINT fk_vendor_members_vendor FOREIGN KEY (vendors_id) REFERENCES vendors (id) ON DELETE CASCADE
+);
+
+CREATE TABLE buyer_members (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    buyers_id INT NOT NULL,
+    users_id INT NOT NULL,
+    role ENUM('owner', 'manager', 'operator') NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_buyer_members (buyers_id, users_id),
+    INDEX idx_buyer_members_user (users_id),
+    CONSTRAINT fk_buyer_members_buyer FOREIGN KEY (buyers_id) REFERENCES buyers (id) ON DELETE CASCADE
+);
+
+-- Convites por e-mail; só o hash do token é gravado
+CREATE TABLE vendor_invitations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    vendors_id INT NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    role ENUM('owner', 'manager', 'operator') NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    status ENUM('pending', 'accepted', 'revoked') NOT NULL DEFAULT 'pending',
+    invited_by INT NULL,
+    accepted_by INT NULL,
+    expires_at DATETIME NOT NULL,
+    accepted_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_vendor_invitations_token (token_hash),
+    INDEX idx_vendor_invitations_email (vendors_id, email, status),
+    CONSTRAINT fk_vendor_invitations_vendor FOREIGN KEY (vendors_id) REFERENCES vendors (id) ON DELETE CASCADE
+);
+
+CREATE TABLE buyer_invitations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    buyers_id INT NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    role ENUM('owner', 'manager', 'operator') NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    status ENUM('pending', 'accepted', 'revoked') NOT NULL DEFAULT 'pending',
+    invited_by INT NULL,
+    accepted_by INT NULL,
+    expires_at DATETIME NOT NULL,
+    accepted_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_buyer_invitations_token (token_hash),
+    INDEX idx_buyer_invitations_email (buyers_id, email, status),
+    CONSTRAINT fk_buyer_invitations_buyer FOREIGN KEY (buyers_id) REFERENCES buyers (id) ON DELETE CASCADE
+);
+
+-- O usuário de cada cadastro existente vira o owner da organização
+INSERT INTO vendor_members (vendors_id, users_id, role)
+SELECT id, users_id, 'owner' FROM vendors WHERE users_id IS NOT NULL;
+
+INSERT INTO buyer_members (buyers_id, users_id, role)
+SELECT id, users_id, 'owner' FROM buyers WHERE users_id IS NOT NULL;
+
+-- Produtos passam a pertencer ao vendor; products.users_id fica como o
+-- usuário que cadastrou o produto
+ALTER TABLE products
+    ADD COLUMN vendors_id INT NULL AFTER users_id,
+    ADD INDEX idx_products_vendor (vendors_id),
+    ADD CONSTRAINT fk_products_vendor FOREIGN KEY (vendors_id) REFERENCES vendors (id) ON DELETE SET NULL;
+
+UPDATE products p
+SET p.vendors_id = (SELECT MIN(v.id) FROM vendors v WHERE v.users_id = p.users_id);
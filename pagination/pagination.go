@@ -0,0 +1,131 @@
+// Package pagination implementa a paginação por cursor usada nas listagens da
+// API. As páginas são percorridas pelo ID em ordem decrescente (keyset), o que
+// mantém o custo constante e evita itens repetidos ou pulados quando novos
+// registros são inseridos entre uma página e outra.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// DefaultLimit é o tamanho de página usado quando limit não é informado
+	DefaultLimit = 20
+	// MaxLimit é o maior tamanho de página aceito
+	MaxLimit = 100
+)
+
+var (
+	ErrInvalidCursor = errors.New("cursor inválido")
+	ErrInvalidLimit  = errors.New("limit deve estar entre 1 e " + strconv.Itoa(MaxLimit))
+)
+
+// cursor é o conteúdo do token opaco devolvido em next_cursor
+type cursor struct {
+	LastID int `json:"id"`
+}
+
+// Params são os parâmetros de paginação de uma requisição
+type Params struct {
+	Limit        int
+	AfterID      int
+	IncludeTotal bool
+}
+
+// Page é o envelope padrão das listagens
+type Page[T any] struct {
+	Data       []T     `json:"data"`
+	NextCursor *string `json:"next_cursor"`
+	Total      *int    `json:"total,omitempty"`
+}
+
+// FromRequest lê os parâmetros cursor, limit e include_total da requisição
+func FromRequest(c *fiber.Ctx) (Params, error) {
+	params := Params{
+		Limit:        DefaultLimit,
+		IncludeTotal: c.QueryBool("include_total", false),
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return params, ErrInvalidLimit
+		}
+		params.Limit = limit
+	}
+
+	if token := c.Query("cursor"); token != "" {
+		afterID, err := decodeCursor(token)
+		if err != nil {
+			return params, ErrInvalidCursor
+		}
+		params.AfterID = afterID
+	}
+
+	return params, nil
+}
+
+// Keyset devolve a condição que posiciona a consulta após o cursor, sobre a
+// coluna de ID informada. Na primeira página a condição é sempre verdadeira,
+// de forma que pode ser combinada com AND em qualquer consulta.
+func (p Params) Keyset(column string) (string, []interface{}) {
+	if p.AfterID == 0 {
+		return "1 = 1", nil
+	}
+	return column + " < ?", []interface{}{p.AfterID}
+}
+
+// FetchLimit é a quantidade de linhas a buscar: uma a mais que a página,
+// para saber se existe página seguinte
+func (p Params) FetchLimit() int {
+	return p.Limit + 1
+}
+
+// NewPage monta o envelope a partir das linhas buscadas com FetchLimit,
+// usando idOf para gerar o cursor da próxima página
+func NewPage[T any](items []T, params Params, idOf func(T) int) Page[T] {
+	page := Page[T]{Data: items}
+	if page.Data == nil {
+		page.Data = []T{}
+	}
+
+	if len(page.Data) > params.Limit {
+		page.Data = page.Data[:params.Limit]
+		next := encodeCursor(idOf(page.Data[len(page.Data)-1]))
+		page.NextCursor = &next
+	}
+
+	return page
+}
+
+// WithTotal inclui o total de registros no envelope
+func (p Page[T]) WithTotal(total int) Page[T] {
+	p.Total = &total
+	return p
+}
+
+func encodeCursor(lastID int) string {
+	payload, _ := json.Marshal(cursor{LastID: lastID})
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func decodeCursor(token string) (int, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+
+	var decoded cursor
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return 0, err
+	}
+	if decoded.LastID < 1 {
+		return 0, ErrInvalidCursor
+	}
+	return decoded.LastID, nil
+}
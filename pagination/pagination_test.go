@@ -0,0 +1,114 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, id := range []int{1, 42, 1 << 40} {
+		got, err := decodeCursor(encodeCursor(id))
+		if err != nil || got != id {
+			t.Errorf("decodeCursor(encodeCursor(%d)) = %d, %v", id, got, err)
+		}
+	}
+}
+
+func TestDecodeTamperedCursor(t *testing.T) {
+	encode := func(payload string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(payload))
+	}
+	valid := encodeCursor(10)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"base64 inválido", "!!!"},
+		{"base64 com padding", base64.URLEncoding.EncodeToString([]byte(`{"id":1}`))},
+		{"cursor truncado", valid[:len(valid)-2]},
+		{"não é JSON", encode("id=10")},
+		{"ID como texto", encode(`{"id":"10"}`)},
+		{"ID zero", encode(`{"id":0}`)},
+		{"ID negativo", encode(`{"id":-5}`)},
+		{"sem ID", encode(`{}`)},
+		{"ID fracionário", encode(`{"id":1.5}`)},
+	}
+	for _, tt := range tests {
+		if id, err := decodeCursor(tt.token); err == nil {
+			t.Errorf("%s: decodeCursor(%q) = %d, quero erro", tt.name, tt.token, id)
+		}
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    Params
+		wantErr error
+	}{
+		{"", Params{Limit: DefaultLimit}, nil},
+		{"?limit=5&include_total=true", Params{Limit: 5, IncludeTotal: true}, nil},
+		{"?cursor=" + encodeCursor(7), Params{Limit: DefaultLimit, AfterID: 7}, nil},
+		{"?limit=0", Params{}, ErrInvalidLimit},
+		{"?limit=101", Params{}, ErrInvalidLimit},
+		{"?limit=abc", Params{}, ErrInvalidLimit},
+		{"?cursor=adulterado", Params{}, ErrInvalidCursor},
+	}
+	for _, tt := range tests {
+		app := fiber.New()
+		var got Params
+		var gotErr error
+		app.Get("/", func(c *fiber.Ctx) error {
+			got, gotErr = FromRequest(c)
+			return nil
+		})
+		if _, err := app.Test(httptest.NewRequest("GET", "/"+tt.query, nil)); err != nil {
+			t.Fatal(err)
+		}
+
+		if gotErr != tt.wantErr {
+			t.Errorf("FromRequest(%q) erro = %v, quero %v", tt.query, gotErr, tt.wantErr)
+			continue
+		}
+		if tt.wantErr == nil && got != tt.want {
+			t.Errorf("FromRequest(%q) = %+v, quero %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	params := Params{Limit: 2}
+	idOf := func(id int) int { return id }
+
+	page := NewPage([]int{9, 8, 7}, params, idOf)
+	if len(page.Data) != 2 || page.NextCursor == nil {
+		t.Fatalf("NewPage = %+v, quero 2 itens e próximo cursor", page)
+	}
+	if next, err := decodeCursor(*page.NextCursor); err != nil || next != 8 {
+		t.Errorf("próximo cursor aponta para %d (%v), quero 8", next, err)
+	}
+
+	last := NewPage([]int{6}, params, idOf)
+	if last.NextCursor != nil {
+		t.Errorf("última página com próximo cursor %q", *last.NextCursor)
+	}
+
+	empty := NewPage[int](nil, params, idOf)
+	if empty.Data == nil || len(empty.Data) != 0 {
+		t.Errorf("página vazia deve ter data = [], veio %#v", empty.Data)
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	if clause, args := (Params{}).Keyset("p.id"); clause != "1 = 1" || args != nil {
+		t.Errorf("primeira página: %q %v", clause, args)
+	}
+	clause, args := (Params{AfterID: 15}).Keyset("p.id")
+	if clause != "p.id < ?" || len(args) != 1 || args[0] != 15 {
+		t.Errorf("página seguinte: %q %v", clause, args)
+	}
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"api/controllers"
+	"database/sql"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterAddressRoutes(app *fiber.App, db *sql.DB) {
+	// Consulta de endereço pelo CEP para o preenchimento automático
+	addressGroup := app.Group("/addresses")
+	addressGroup.Get("/cep/:cep", controllers.GetAddressByCEP(db))
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"api/controllers"
+	"database/sql"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterAdminRoutes registra as rotas restritas a administradores
+func RegisterAdminRoutes(app *fiber.App, db *sql.DB) {
+	adminGroup := app.Group("/admin", controllers.RequireAdmin(db))
+
+	// Moderação de produtos
+	adminGroup.Get("/moderation", controllers.GetModerationQueue(db))
+	adminGroup.Post("/moderation/:id/approve", controllers.ApproveModeration(db))
+	adminGroup.Post("/moderation/:id/reject", controllers.RejectModeration(db))
+
+	// Moderação de avaliações
+	adminGroup.Get("/reviews", controllers.GetReviewsForModeration(db))
+	adminGroup.Post("/reviews/:id/hide", controllers.HideProductReview(db))
+	adminGroup.Post("/reviews/:id/publish", controllers.PublishProductReview(db))
+
+	// Esquema de atributos das categorias
+	adminGroup.Post("/categories/:id/attributes", controllers.CreateCategoryAttribute(db))
+	adminGroup.Delete("/categories/:id/attributes/:attribute_id", controllers.DeleteCategoryAttribute(db))
+
+	// Aprovação do cadastro de vendors
+	adminGroup.Get("/vendors", controllers.GetVendorsForReview(db))
+	adminGroup.Post("/vendors/:id/status", controllers.UpdateVendorStatus(db))
+
+	// Base local de CEPs
+	adminGroup.Post("/addresses/cep/import", controllers.ImportCEPAddresses(db))
+}
@@ -16,4 +16,18 @@ func RegisterBuyerRoutes(app *fiber.App, db *sql.DB) {
 	buyerGroup.Patch("/:id", controllers.UpdateBuyer(db))     
 	buyerGroup.Get("/user/:users_id", controllers.GetBuyerByUserID(db))
 
+	// Catálogo de endereços de entrega
+	buyerGroup.Get("/:id/addresses", controllers.GetBuyerAddresses(db))
+	buyerGroup.Post("/:id/addresses", controllers.CreateBuyerAddress(db))
+	buyerGroup.Put("/:id/addresses/:address_id", controllers.UpdateBuyerAddress(db))
+	buyerGroup.Delete("/:id/addresses/:address_id", controllers.DeleteBuyerAddress(db))
+
+	// Equipe do comprador e convites por e-mail
+	buyerGroup.Get("/:id/members", controllers.GetBuyerMembers(db))
+	buyerGroup.Patch("/:id/members/:user_id", controllers.UpdateBuyerMember(db))
+	buyerGroup.Delete("/:id/members/:user_id", controllers.DeleteBuyerMember(db))
+	buyerGroup.Get("/:id/invitations", controllers.GetBuyerInvitations(db))
+	buyerGroup.Post("/:id/invitations", controllers.CreateBuyerInvitation(db))
+	buyerGroup.Delete("/:id/invitations/:invitation_id", controllers.DeleteBuyerInvitation(db))
+
 }
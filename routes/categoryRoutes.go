@@ -15,5 +15,9 @@ func RegisterCategoryRoutes(app *fiber.App, db *sql.DB) {
 	categoryGroup.Get("/:id", controllers.GetCategoryByID(db))
 	categoryGroup.Patch("/:id", controllers.UpdateCategory(db))
 	categoryGroup.Delete("/:id", controllers.DeleteCategoryByID(db))
+	categoryGroup.Post("/:id/move", controllers.MoveCategory(db))
+
+	// Esquema de atributos da categoria; alterações ficam em /admin
+	categoryGroup.Get("/:id/attributes", controllers.GetCategoryAttributes(db))
 
 }
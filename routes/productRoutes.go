@@ -12,6 +12,7 @@ func RegisterProductRoutes(app *fiber.App, db *sql.DB) {
 	productGroup := app.Group("/products")
 
 	productGroup.Get("/search", controllers.SearchProducts(db))
+	productGroup.Get("/suggest", controllers.SuggestProducts(db))
 
 	productGroup.Get("/home", controllers.GetAllProductsHome(db))
 	productGroup.Get("/", controllers.GetAllProducts(db))
@@ -23,7 +24,25 @@ func RegisterProductRoutes(app *fiber.App, db *sql.DB) {
 	productGroup.Post("/", controllers.CreateProduct(db))
 	productGroup.Get("/id/:id", controllers.GetProductByID(db))
 	productGroup.Delete("/id/:id", controllers.DeleteProductByID(db))
+	productGroup.Post("/id/:id/restore", controllers.RestoreProductByID(db))
+	productGroup.Get("/id/:id/moderation", controllers.GetProductModerationHistory(db))
+	productGroup.Get("/id/:id/inventory", controllers.GetInventoryMovements(db))
+	productGroup.Post("/id/:id/inventory", controllers.CreateInventoryMovement(db))
+	productGroup.Get("/id/:id/lots", controllers.GetProductLots(db))
+	productGroup.Post("/id/:id/lots", controllers.CreateProductLot(db))
+	productGroup.Get("/id/:id/variants", controllers.GetProductVariants(db))
+	productGroup.Post("/id/:id/variants", controllers.CreateProductVariant(db))
+	productGroup.Put("/id/:id/variants/:variant_id", controllers.UpdateProductVariant(db))
+	productGroup.Delete("/id/:id/variants/:variant_id", controllers.DetachProductVariant(db))
+	productGroup.Get("/id/:id/reviews", controllers.GetProductReviews(db))
+	productGroup.Post("/id/:id/reviews", controllers.CreateProductReview(db))
+	productGroup.Put("/id/:id/reviews/:review_id/reply", controllers.ReplyProductReview(db))
+	productGroup.Get("/id/:id/price-history", controllers.GetProductPriceHistory(db))
+	productGroup.Get("/id/:id/price-schedules", controllers.GetPriceSchedules(db))
+	productGroup.Post("/id/:id/price-schedules", controllers.CreatePriceSchedule(db))
+	productGroup.Delete("/id/:id/price-schedules/:schedule_id", controllers.CancelPriceSchedule(db))
 
 	productGroup.Patch("/id/:id", controllers.UpdateProductByID(db))
+	productGroup.Patch("/bulk", controllers.BulkUpdateProducts(db))
 
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"api/controllers"
+	"database/sql"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterStorefrontRoutes(app *fiber.App, db *sql.DB) {
+	// Vitrine pública dos vendors
+	storeGroup := app.Group("/stores")
+	storeGroup.Get("/:slug", controllers.GetStorefront(db))
+}
@@ -18,4 +18,8 @@ func RegisterUserRoutes(app *fiber.App, db *sql.DB) {
 
 	userGroup.Get("/username/:username", controllers.GetUserByUsername(db))
 
+	// Vendors e compradores de que o usuário faz parte
+	userGroup.Get("/:id/memberships", controllers.GetUserMemberships(db))
+	app.Post("/invitations/accept", controllers.AcceptInvitation(db))
+
 }
@@ -31,4 +31,47 @@ func RegisterVendorRoutes(app *fiber.App, db *sql.DB) {
 	vendorGroup.Get("/:vendor_id/orders/:order_id/details", controllers.GetVendorOrderDetails(db))
 	vendorGroup.Patch("/:vendor_id/orders/:order_id/status", controllers.UpdateOrderStatus(db))
 
+	// Catálogo do vendor, cadastrado por qualquer membro da equipe
+	vendorGroup.Get("/:vendor_id/products", controllers.GetVendorProducts(db))
+
+	// Importação e exportação do catálogo em planilha
+	vendorGroup.Post("/:vendor_id/products/import", controllers.ImportVendorProducts(db))
+	vendorGroup.Get("/:vendor_id/products/export", controllers.ExportVendorProducts(db))
+
+	// Alertas de estoque
+	vendorGroup.Get("/:vendor_id/alerts", controllers.GetVendorAlerts(db))
+
+	// Regiões de entrega exibidas na vitrine
+	vendorGroup.Get("/:vendor_id/delivery-zones", controllers.GetVendorDeliveryZones(db))
+	vendorGroup.Post("/:vendor_id/delivery-zones", controllers.CreateVendorDeliveryZone(db))
+	vendorGroup.Delete("/:vendor_id/delivery-zones/:zone_id", controllers.DeleteVendorDeliveryZone(db))
+
+	// Locais de retirada e horários de funcionamento
+	vendorGroup.Get("/:vendor_id/pickup-points", controllers.GetVendorPickupPoints(db))
+	vendorGroup.Post("/:vendor_id/pickup-points", controllers.CreateVendorPickupPoint(db))
+	vendorGroup.Put("/:vendor_id/pickup-points/:point_id", controllers.UpdateVendorPickupPoint(db))
+	vendorGroup.Delete("/:vendor_id/pickup-points/:point_id", controllers.DeleteVendorPickupPoint(db))
+
+	// Janelas de entrega com capacidade e manifesto do dia
+	vendorGroup.Get("/:vendor_id/delivery-slots", controllers.GetVendorDeliverySlots(db))
+	vendorGroup.Post("/:vendor_id/delivery-slots", controllers.CreateVendorDeliverySlots(db))
+	vendorGroup.Patch("/:vendor_id/delivery-slots/:slot_id", controllers.UpdateVendorDeliverySlot(db))
+	vendorGroup.Delete("/:vendor_id/delivery-slots/:slot_id", controllers.DeleteVendorDeliverySlot(db))
+	vendorGroup.Get("/:vendor_id/delivery-manifest", controllers.GetVendorDeliveryManifest(db))
+
+	// Equipe do vendor e convites por e-mail
+	vendorGroup.Get("/:vendor_id/members", controllers.GetVendorMembers(db))
+	vendorGroup.Patch("/:vendor_id/members/:user_id", controllers.UpdateVendorMember(db))
+	vendorGroup.Delete("/:vendor_id/members/:user_id", controllers.DeleteVendorMember(db))
+	vendorGroup.Get("/:vendor_id/invitations", controllers.GetVendorInvitations(db))
+	vendorGroup.Post("/:vendor_id/invitations", controllers.CreateVendorInvitation(db))
+	vendorGroup.Delete("/:vendor_id/invitations/:invitation_id", controllers.DeleteVendorInvitation(db))
+
+	// Cadastro e documentos para aprovação do vendor
+	vendorGroup.Get("/:vendor_id/onboarding", controllers.GetVendorOnboarding(db))
+	vendorGroup.Post("/:vendor_id/onboarding/resubmit", controllers.ResubmitVendorOnboarding(db))
+	vendorGroup.Post("/:vendor_id/documents", controllers.UploadVendorDocument(db))
+	vendorGroup.Get("/:vendor_id/documents/:document_id", controllers.DownloadVendorDocument(db))
+	vendorGroup.Delete("/:vendor_id/documents/:document_id", controllers.DeleteVendorDocument(db))
+
 }
@@ -0,0 +1,253 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Parâmetros padrão do BM25
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Pesos aplicados às ocorrências de termos aproximados em relação ao termo exato
+const (
+	prefixMatchWeight = 0.8
+	fuzzyMatchWeight  = 0.6
+)
+
+// Document é a unidade indexada: um ID e seus campos de texto
+type Document struct {
+	ID     int
+	Fields map[string]string
+}
+
+// Hit é um documento encontrado com sua pontuação BM25
+type Hit struct {
+	ID    int
+	Score float64
+}
+
+type indexedDocument struct {
+	length float64
+	terms  map[string]float64
+}
+
+// Index é um índice invertido em memória com ranking BM25, seguro para uso
+// concorrente. Os campos de cada documento podem ter pesos diferentes.
+type Index struct {
+	mu           sync.RWMutex
+	fieldWeights map[string]float64
+	documents    map[int]*indexedDocument
+	postings     map[string]map[int]float64
+	totalLength  float64
+}
+
+// NewIndex cria um índice vazio. Campos ausentes em fieldWeights têm peso 1.
+func NewIndex(fieldWeights map[string]float64) *Index {
+	return &Index{
+		fieldWeights: fieldWeights,
+		documents:    make(map[int]*indexedDocument),
+		postings:     make(map[string]map[int]float64),
+	}
+}
+
+// Len retorna a quantidade de documentos indexados
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.documents)
+}
+
+// Upsert indexa o documento, substituindo uma versão anterior de mesmo ID
+func (idx *Index) Upsert(doc Document) {
+	entry := &indexedDocument{terms: make(map[string]float64)}
+	for field, text := range doc.Fields {
+		weight, ok := idx.fieldWeights[field]
+		if !ok {
+			weight = 1
+		}
+		for _, term := range Analyze(text) {
+			entry.terms[term] += weight
+			entry.length += weight
+		}
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeLocked(doc.ID)
+	idx.documents[doc.ID] = entry
+	idx.totalLength += entry.length
+	for term, frequency := range entry.terms {
+		if idx.postings[term] == nil {
+			idx.postings[term] = make(map[int]float64)
+		}
+		idx.postings[term][doc.ID] = frequency
+	}
+}
+
+// Remove retira o documento do índice
+func (idx *Index) Remove(id int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(id)
+}
+
+func (idx *Index) removeLocked(id int) {
+	entry, exists := idx.documents[id]
+	if !exists {
+		return
+	}
+	for term := range entry.terms {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	idx.totalLength -= entry.length
+	delete(idx.documents, id)
+}
+
+// Search retorna os documentos que contêm todos os termos da consulta,
+// ordenados por relevância. Cada termo aceita correspondência exata (após
+// remoção de acentos e stemming), por prefixo (para o último termo digitado) e
+// com erros de digitação.
+func (idx *Index) Search(query string) []Hit {
+	rawTerms := Tokenize(query)
+	if len(rawTerms) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	totalDocs := float64(len(idx.documents))
+	if totalDocs == 0 {
+		return nil
+	}
+	averageLength := idx.totalLength / totalDocs
+
+	var scores map[int]float64
+	for i, rawTerm := range rawTerms {
+		expansions := idx.expandLocked(rawTerm, i == len(rawTerms)-1)
+
+		// O IDF é calculado sobre todos os documentos encontrados pelas
+		// expansões, como um único termo; com IDFs separados, um termo
+		// aproximado raro superaria o termo exato da consulta
+		matched := make(map[int]bool)
+		for term := range expansions {
+			for id := range idx.postings[term] {
+				matched[id] = true
+			}
+		}
+		documentFrequency := float64(len(matched))
+		idf := math.Log(1 + (totalDocs-documentFrequency+0.5)/(documentFrequency+0.5))
+
+		termScores := make(map[int]float64)
+		for term, weight := range expansions {
+			for id, frequency := range idx.postings[term] {
+				length := idx.documents[id].length
+				tf := frequency * (bm25K1 + 1) / (frequency + bm25K1*(1-bm25B+bm25B*length/averageLength))
+				// Fica com a melhor expansão do termo em cada documento
+				if score := weight * idf * tf; score > termScores[id] {
+					termScores[id] = score
+				}
+			}
+		}
+
+		// Todos os termos precisam ser encontrados
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			if termScore, found := termScores[id]; found {
+				scores[id] += termScore
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID > hits[j].ID
+	})
+
+	return hits
+}
+
+// expandLocked devolve os termos do vocabulário que correspondem ao termo da
+// consulta, com o peso de cada correspondência
+func (idx *Index) expandLocked(rawTerm string, allowPrefix bool) map[string]float64 {
+	stemmed := Stem(rawTerm)
+	expansions := make(map[string]float64)
+	if _, exists := idx.postings[stemmed]; exists {
+		expansions[stemmed] = 1
+	}
+
+	maxDistance := 0
+	switch {
+	case len(stemmed) >= 8:
+		maxDistance = 2
+	case len(stemmed) >= 4:
+		maxDistance = 1
+	}
+
+	for term := range idx.postings {
+		if term == stemmed {
+			continue
+		}
+		if allowPrefix && len(rawTerm) >= 2 && strings.HasPrefix(term, rawTerm) {
+			expansions[term] = math.Max(expansions[term], prefixMatchWeight)
+			continue
+		}
+		if maxDistance > 0 && withinDistance(stemmed, term, maxDistance) {
+			expansions[term] = math.Max(expansions[term], fuzzyMatchWeight)
+		}
+	}
+
+	return expansions
+}
+
+// withinDistance indica se a distância de Levenshtein entre a e b é no máximo max
+func withinDistance(a, b string, max int) bool {
+	if diff := len(a) - len(b); diff > max || -diff > max {
+		return false
+	}
+
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		rowMin := current[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			rowMin = min(rowMin, current[j])
+		}
+		if rowMin > max {
+			return false
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)] <= max
+}
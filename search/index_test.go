@@ -0,0 +1,111 @@
+package search
+
+import "testing"
+
+func newTestIndex() *Index {
+	idx := NewIndex(map[string]float64{"name": 3, "description": 1})
+	idx.Upsert(Document{ID: 1, Fields: map[string]string{"name": "Feijão carioca", "description": "Grão tipo 1"}})
+	idx.Upsert(Document{ID: 2, Fields: map[string]string{"name": "Arroz integral", "description": "Combina com feijão"}})
+	idx.Upsert(Document{ID: 3, Fields: map[string]string{"name": "Tomate italiano", "description": "Orgânico"}})
+	return idx
+}
+
+func hitIDs(hits []Hit) []int {
+	ids := make([]int, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx := newTestIndex()
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{"campo com maior peso primeiro", "feijão", []int{1, 2}},
+		{"sem acento e no plural", "FEIJOES", []int{1, 2}},
+		{"prefixo do último termo", "integ", []int{2}},
+		{"erro de digitação", "tomatte", []int{3}},
+		{"todos os termos são exigidos", "arroz tomate", []int{}},
+		{"apenas stopwords", "de com", []int{}},
+		{"termo desconhecido", "banana", []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hitIDs(idx.Search(tt.query))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search(%q) = %v, quero %v", tt.query, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Search(%q) = %v, quero %v", tt.query, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexScoresExactAboveFuzzy(t *testing.T) {
+	idx := NewIndex(nil)
+	idx.Upsert(Document{ID: 1, Fields: map[string]string{"name": "batata doce"}})
+	idx.Upsert(Document{ID: 2, Fields: map[string]string{"name": "batata"}})
+	idx.Upsert(Document{ID: 3, Fields: map[string]string{"name": "barata"}})
+
+	hits := idx.Search("batata")
+	if len(hits) != 3 {
+		t.Fatalf("Search devolveu %v, quero 3 documentos", hitIDs(hits))
+	}
+	// O documento mais curto com o termo exato vence; a correspondência
+	// aproximada fica por último
+	if hits[0].ID != 2 || hits[2].ID != 3 {
+		t.Errorf("ordem = %v, quero [2 1 3]", hitIDs(hits))
+	}
+	if !(hits[0].Score > hits[1].Score && hits[1].Score > hits[2].Score) {
+		t.Errorf("pontuações fora de ordem: %+v", hits)
+	}
+}
+
+func TestIndexUpsertAndRemove(t *testing.T) {
+	idx := newTestIndex()
+
+	idx.Upsert(Document{ID: 3, Fields: map[string]string{"name": "Cebola roxa"}})
+	if got := idx.Search("tomate"); len(got) != 0 {
+		t.Errorf("versão anterior continua indexada: %v", hitIDs(got))
+	}
+	if got := hitIDs(idx.Search("cebola")); len(got) != 1 || got[0] != 3 {
+		t.Errorf("Search(cebola) = %v, quero [3]", got)
+	}
+
+	idx.Remove(1)
+	idx.Remove(42)
+	if idx.Len() != 2 {
+		t.Errorf("Len() = %d, quero 2", idx.Len())
+	}
+	if got := hitIDs(idx.Search("carioca")); len(got) != 0 {
+		t.Errorf("documento removido encontrado: %v", got)
+	}
+}
+
+func TestWithinDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want bool
+	}{
+		{"tomate", "tomate", 0, true},
+		{"tomate", "tomatte", 1, true},
+		{"tomate", "tomat", 1, true},
+		{"tomate", "batata", 1, false},
+		{"cebola", "cebolinha", 2, false},
+		{"abobrinha", "aboborinha", 2, true},
+	}
+	for _, tt := range tests {
+		if got := withinDistance(tt.a, tt.b, tt.max); got != tt.want {
+			t.Errorf("withinDistance(%q, %q, %d) = %v, quero %v", tt.a, tt.b, tt.max, got, tt.want)
+		}
+	}
+}
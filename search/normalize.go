@@ -0,0 +1,131 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Mapeamento de caracteres acentuados para a forma sem acento
+var accentFolding = map[rune]string{
+	'á': "a", 'à': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a",
+	'é': "e", 'è': "e", 'ê': "e", 'ë': "e",
+	'í': "i", 'ì': "i", 'î': "i", 'ï': "i",
+	'ó': "o", 'ò': "o", 'ô': "o", 'õ': "o", 'ö': "o",
+	'ú': "u", 'ù': "u", 'û': "u", 'ü': "u",
+	'ç': "c", 'ñ': "n", 'ý': "y", 'ÿ': "y",
+	'æ': "ae", 'œ': "oe", 'ß': "ss",
+}
+
+// Palavras muito frequentes em português que não ajudam na busca
+var stopwords = map[string]bool{
+	"a": true, "o": true, "as": true, "os": true, "e": true, "de": true, "da": true,
+	"do": true, "das": true, "dos": true, "em": true, "na": true, "no": true,
+	"nas": true, "nos": true, "um": true, "uma": true, "com": true, "para": true,
+	"por": true, "ou": true, "sem": true, "ao": true, "aos": true, "pra": true,
+}
+
+// Fold converte o texto para minúsculas e remove acentos ("Feijão" -> "feijao")
+func Fold(text string) string {
+	var builder strings.Builder
+	builder.Grow(len(text))
+	for _, r := range strings.ToLower(text) {
+		if folded, ok := accentFolding[r]; ok {
+			builder.WriteString(folded)
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// Tokenize divide o texto em termos normalizados (sem acento, minúsculos),
+// descartando pontuação e stopwords
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(Fold(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := fields[:0]
+	for _, field := range fields {
+		if !stopwords[field] {
+			tokens = append(tokens, field)
+		}
+	}
+	return tokens
+}
+
+// Analyze tokeniza e reduz cada termo ao seu radical
+func Analyze(text string) []string {
+	tokens := Tokenize(text)
+	for i, token := range tokens {
+		tokens[i] = Stem(token)
+	}
+	return tokens
+}
+
+type suffixRule struct {
+	suffix      string
+	replacement string
+	minStem     int
+}
+
+// Regras do stemmer, inspiradas nas etapas do RSLP (Removedor de Sufixos da
+// Língua Portuguesa), aplicadas sobre o texto já sem acentos
+var (
+	pluralRules = []suffixRule{
+		{"oes", "ao", 1}, {"aes", "ao", 1}, {"ais", "al", 1}, {"eis", "el", 2},
+		{"ois", "ol", 1}, {"les", "l", 2}, {"res", "r", 2}, {"zes", "z", 2},
+		{"ns", "m", 1}, {"s", "", 2},
+	}
+	diminutiveRules = []suffixRule{
+		{"zinho", "", 3}, {"zinha", "", 3}, {"inho", "", 3}, {"inha", "", 3},
+		{"issimo", "", 3}, {"issima", "", 3}, {"mente", "", 4},
+	}
+	feminineRules = []suffixRule{
+		{"eira", "eiro", 3}, {"ona", "ao", 3}, {"ora", "or", 3}, {"osa", "oso", 3},
+		{"ica", "ico", 3}, {"ada", "ado", 2}, {"ida", "ido", 3}, {"iva", "ivo", 3},
+	}
+)
+
+func applyFirstRule(word string, rules []suffixRule) string {
+	for _, rule := range rules {
+		if strings.HasSuffix(word, rule.suffix) && len(word)-len(rule.suffix) >= rule.minStem {
+			return word[:len(word)-len(rule.suffix)] + rule.replacement
+		}
+	}
+	return word
+}
+
+// Stem reduz um termo já normalizado por Fold ao seu radical aproximado, de
+// forma que variações como "feijões", "feijão" e "feijãozinho" coincidam
+func Stem(word string) string {
+	if len(word) <= 3 || !isAlpha(word) {
+		return word
+	}
+
+	// Terminações em "ss" e "us" não são plurais (ex.: "ônibus")
+	if !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") {
+		word = applyFirstRule(word, pluralRules)
+	}
+	word = applyFirstRule(word, diminutiveRules)
+	word = applyFirstRule(word, feminineRules)
+
+	// Remove a vogal temática final
+	if len(word) > 4 {
+		switch word[len(word)-1] {
+		case 'a', 'e', 'o':
+			word = word[:len(word)-1]
+		}
+	}
+
+	return word
+}
+
+func isAlpha(word string) bool {
+	for _, r := range word {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Feijão", "feijao"},
+		{"AÇÚCAR Orgânico", "acucar organico"},
+		{"Maçã-verde", "maca-verde"},
+		{"sem acento", "sem acento"},
+	}
+	for _, tt := range tests {
+		if got := Fold(tt.text); got != tt.want {
+			t.Errorf("Fold(%q) = %q, quero %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Arroz, do Feijão!", []string{"arroz", "feijao"}},
+		{"Café com leite 500g", []string{"cafe", "leite", "500g"}},
+		{"de a o", []string{}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		got := Tokenize(tt.text)
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, quero %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestStemGroupsVariations(t *testing.T) {
+	// Cada grupo de palavras deve chegar ao mesmo radical
+	groups := [][]string{
+		{"feijões", "feijão", "feijãozinho"},
+		{"limões", "limão"},
+		{"tomates", "tomate"},
+		{"queijos", "queijo"},
+		{"cenouras", "cenoura"},
+		{"orgânica", "orgânico"},
+	}
+	for _, group := range groups {
+		want := Analyze(group[0])
+		for _, word := range group[1:] {
+			if got := Analyze(word); !reflect.DeepEqual(got, want) {
+				t.Errorf("Analyze(%q) = %q, quero %q (de %q)", word, got, want, group[0])
+			}
+		}
+	}
+}
+
+func TestStemKeepsWords(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		// Palavras curtas, com dígitos e terminadas em "us" não são alteradas
+		{"sal", "sal"},
+		{"500g", "500g"},
+		{"onibus", "onibus"},
+		{"arroz", "arroz"},
+		{"tomates", "tomat"},
+	}
+	for _, tt := range tests {
+		if got := Stem(tt.word); got != tt.want {
+			t.Errorf("Stem(%q) = %q, quero %q", tt.word, got, tt.want)
+		}
+	}
+}
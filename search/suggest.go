@@ -0,0 +1,104 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Suggestion é um item sugerido no autocompletar
+type Suggestion struct {
+	ID         int     `json:"id"`
+	Text       string  `json:"text"`
+	Popularity float64 `json:"popularity"`
+}
+
+// suggestKey aponta de uma chave normalizada para a sugestão de origem. Cada
+// sugestão gera uma chave por palavra, permitindo completar qualquer palavra
+// do texto ("arroz integral" é encontrado por "arr" e por "int").
+type suggestKey struct {
+	key   string
+	entry int
+}
+
+type suggestGroup struct {
+	entries []Suggestion
+	keys    []suggestKey
+}
+
+// Suggester mantém grupos de sugestões ordenados por chave para consultas de
+// prefixo por busca binária. É seguro para uso concorrente.
+type Suggester struct {
+	mu     sync.RWMutex
+	groups map[string]*suggestGroup
+}
+
+// NewSuggester cria um Suggester vazio
+func NewSuggester() *Suggester {
+	return &Suggester{groups: make(map[string]*suggestGroup)}
+}
+
+// Replace substitui todas as sugestões de um grupo
+func (s *Suggester) Replace(group string, items []Suggestion) {
+	built := &suggestGroup{entries: items}
+	for i, item := range items {
+		words := Tokenize(item.Text)
+		for w := range words {
+			built.keys = append(built.keys, suggestKey{key: strings.Join(words[w:], " "), entry: i})
+		}
+	}
+	sort.Slice(built.keys, func(i, j int) bool {
+		return built.keys[i].key < built.keys[j].key
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.groups[group] = built
+}
+
+// Suggest retorna, para cada grupo, até limit sugestões cujo texto contém uma
+// palavra iniciada pela consulta, ordenadas pela popularidade
+func (s *Suggester) Suggest(query string, limit int) map[string][]Suggestion {
+	prefix := strings.Join(Tokenize(query), " ")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make(map[string][]Suggestion, len(s.groups))
+	for name, group := range s.groups {
+		matches := []Suggestion{}
+		if prefix != "" {
+			matches = group.match(prefix)
+		}
+		if len(matches) > limit {
+			matches = matches[:limit]
+		}
+		results[name] = matches
+	}
+	return results
+}
+
+func (g *suggestGroup) match(prefix string) []Suggestion {
+	start := sort.Search(len(g.keys), func(i int) bool {
+		return g.keys[i].key >= prefix
+	})
+
+	seen := make(map[int]bool)
+	matches := []Suggestion{}
+	for i := start; i < len(g.keys) && strings.HasPrefix(g.keys[i].key, prefix); i++ {
+		entry := g.keys[i].entry
+		if seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		matches = append(matches, g.entries[entry])
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Popularity != matches[j].Popularity {
+			return matches[i].Popularity > matches[j].Popularity
+		}
+		return matches[i].Text < matches[j].Text
+	})
+	return matches
+}
@@ -0,0 +1,38 @@
+package search
+
+import "testing"
+
+func TestSuggesterPrefix(t *testing.T) {
+	s := NewSuggester()
+	s.Replace("products", []Suggestion{
+		{ID: 1, Text: "Arroz integral", Popularity: 10},
+		{ID: 2, Text: "Arroz branco", Popularity: 30},
+		{ID: 3, Text: "Feijão preto", Popularity: 5},
+	})
+
+	tests := []struct {
+		query string
+		limit int
+		want  []int
+	}{
+		{"arr", 5, []int{2, 1}},
+		{"INTEG", 5, []int{1}},
+		{"feijao p", 5, []int{3}},
+		{"arroz", 1, []int{2}},
+		{"", 5, []int{}},
+		{"milho", 5, []int{}},
+	}
+	for _, tt := range tests {
+		got := s.Suggest(tt.query, tt.limit)["products"]
+		if len(got) != len(tt.want) {
+			t.Errorf("Suggest(%q) = %+v, quero IDs %v", tt.query, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i].ID != tt.want[i] {
+				t.Errorf("Suggest(%q) = %+v, quero IDs %v", tt.query, got, tt.want)
+				break
+			}
+		}
+	}
+}
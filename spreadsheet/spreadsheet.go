@@ -0,0 +1,143 @@
+// Package spreadsheet lê e grava planilhas simples (uma aba, primeira linha
+// de cabeçalho) nos formatos CSV e XLSX, usados na importação e exportação de
+// catálogos. Todas as células são tratadas como texto.
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Format é o formato de arquivo da planilha
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("formato de planilha não suportado; use csv ou xlsx")
+	ErrEmpty             = errors.New("planilha vazia")
+	ErrTooManyRows       = errors.New("a planilha excede o limite de linhas")
+	ErrTooManyColumns    = errors.New("a planilha excede o limite de colunas")
+)
+
+// Limits restringe o tamanho da planilha lida, contando o cabeçalho. Os
+// limites são verificados durante a leitura, antes de alocar as linhas e
+// células, de forma que uma referência como "XFD1048576" não consome memória.
+type Limits struct {
+	MaxRows    int
+	MaxColumns int
+}
+
+func (l Limits) checkRow(index int) error {
+	if index > l.MaxRows {
+		return ErrTooManyRows
+	}
+	return nil
+}
+
+func (l Limits) checkColumn(index int) error {
+	if index >= l.MaxColumns {
+		return ErrTooManyColumns
+	}
+	return nil
+}
+
+// ParseFormat interpreta o nome de um formato ("csv" ou "xlsx")
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(name))) {
+	case CSV:
+		return CSV, nil
+	case XLSX:
+		return XLSX, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// FormatFromFilename deduz o formato pela extensão do arquivo
+func FormatFromFilename(filename string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// ContentType devolve o tipo MIME do formato
+func (f Format) ContentType() string {
+	if f == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Read lê todas as linhas da planilha dentro dos limites informados. Linhas
+// totalmente vazias são mantidas como fatias vazias para preservar a
+// numeração das linhas.
+func Read(format Format, data []byte, limits Limits) ([][]string, error) {
+	var rows [][]string
+	var err error
+	switch format {
+	case CSV:
+		rows, err = readCSV(data, limits)
+	case XLSX:
+		rows, err = readXLSX(data, limits)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrEmpty
+	}
+	return rows, nil
+}
+
+// Write grava as linhas no formato informado
+func Write(w io.Writer, format Format, rows [][]string) error {
+	switch format {
+	case CSV:
+		writer := csv.NewWriter(w)
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	case XLSX:
+		return writeXLSX(w, rows)
+	}
+	return ErrUnsupportedFormat
+}
+
+// readCSV aceita vírgula ou ponto e vírgula como separador (o Excel em
+// português grava CSV com ponto e vírgula) e ignora o BOM do UTF-8
+func readCSV(data []byte, limits Limits) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := limits.checkRow(len(rows) + 1); err != nil {
+			return nil, err
+		}
+		if err := limits.checkColumn(len(record) - 1); err != nil {
+			return nil, err
+		}
+		rows = append(rows, record)
+	}
+}
@@ -0,0 +1,270 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testLimits = Limits{MaxRows: 10, MaxColumns: 8}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{"csv", CSV, false},
+		{" XLSX ", XLSX, false},
+		{"xls", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.name)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseFormat(%q) = %q, %v", tt.name, got, err)
+		}
+	}
+
+	if got, err := FormatFromFilename("catalogo.XLSX"); err != nil || got != XLSX {
+		t.Errorf("FormatFromFilename = %q, %v", got, err)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    [][]string
+		wantErr error
+	}{
+		{
+			name: "vírgula",
+			data: "sku,name\nA1,Arroz\n",
+			want: [][]string{{"sku", "name"}, {"A1", "Arroz"}},
+		},
+		{
+			name: "ponto e vírgula e BOM",
+			data: "\xef\xbb\xbfsku;name;price\nA1;Feijão;10,50\n",
+			want: [][]string{{"sku", "name", "price"}, {"A1", "Feijão", "10,50"}},
+		},
+		{
+			name: "linhas com quantidades diferentes de colunas",
+			data: "sku,name\nA1\n",
+			want: [][]string{{"sku", "name"}, {"A1"}},
+		},
+		{name: "vazia", data: "", wantErr: ErrEmpty},
+		{name: "linhas demais", data: strings.Repeat("a\n", 11), wantErr: ErrTooManyRows},
+		{name: "colunas demais", data: "a,b,c,d,e,f,g,h,i\n", wantErr: ErrTooManyColumns},
+	}
+	for _, tt := range tests {
+		got, err := Read(CSV, []byte(tt.data), testLimits)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: erro = %v, quero %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Read = %q, quero %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"sku", "name", "description"},
+		{"A1", "Arroz <integral> & cia", "  com espaços  "},
+		{"B2", "Feijão", ""},
+	}
+	for _, format := range []Format{CSV, XLSX} {
+		var buffer bytes.Buffer
+		if err := Write(&buffer, format, rows); err != nil {
+			t.Fatalf("%s: Write: %v", format, err)
+		}
+		got, err := Read(format, buffer.Bytes(), testLimits)
+		if err != nil {
+			t.Fatalf("%s: Read: %v", format, err)
+		}
+		if !reflect.DeepEqual(got, rows) {
+			t.Errorf("%s: Read = %q, quero %q", format, got, rows)
+		}
+	}
+}
+
+// buildXLSX monta uma pasta de trabalho com a aba e as partes extras informadas
+func buildXLSX(t *testing.T, sheetData string, extra map[string]string) []byte {
+	t.Helper()
+	parts := map[string]string{
+		"xl/workbook.xml":            xlsxWorkbookXML,
+		"xl/_rels/workbook.xml.rels": xlsxWorkbookRels,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			sheetData + `</sheetData></worksheet>`,
+	}
+	for name, content := range extra {
+		parts[name] = content
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for name, content := range parts {
+		file, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestReadXLSX(t *testing.T) {
+	shared := map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>sku</t></si><si><r><t>Feij</t></r><r><t>ão</t></r></si></sst>`,
+	}
+
+	tests := []struct {
+		name  string
+		sheet string
+		extra map[string]string
+		want  [][]string
+	}{
+		{
+			name:  "textos compartilhados e formatados",
+			sheet: `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>`,
+			extra: shared,
+			want:  [][]string{{"sku", "Feijão"}},
+		},
+		{
+			name:  "linhas e células puladas são preenchidas",
+			sheet: `<row r="1"><c r="A1"><v>1</v></c></row><row r="3"><c r="C3" t="inlineStr"><is><t>x</t></is></c></row>`,
+			want:  [][]string{{"1"}, {}, {"", "", "x"}},
+		},
+		{
+			name:  "sem referências",
+			sheet: `<row><c><v>a</v></c><c><v>b</v></c></row><row><c><v>c</v></c></row>`,
+			want:  [][]string{{"a", "b"}, {"c"}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := Read(XLSX, buildXLSX(t, tt.sheet, tt.extra), testLimits)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Read = %q, quero %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadXLSXRejectsMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    func(t *testing.T) []byte
+		wantErr error
+	}{
+		{
+			name: "linha além do limite",
+			data: func(t *testing.T) []byte {
+				return buildXLSX(t, `<row r="1048576"><c r="A1048576"><v>1</v></c></row>`, nil)
+			},
+			wantErr: ErrTooManyRows,
+		},
+		{
+			name:    "coluna além do limite",
+			data:    func(t *testing.T) []byte { return buildXLSX(t, `<row r="1"><c r="XFD1"><v>1</v></c></row>`, nil) },
+			wantErr: ErrTooManyColumns,
+		},
+		{
+			name: "referência de coluna longa demais",
+			data: func(t *testing.T) []byte {
+				return buildXLSX(t, `<row r="1"><c r="ZZZZZZZZZZZZZZ1"><v>1</v></c></row>`, nil)
+			},
+			wantErr: errInvalidXLSX,
+		},
+		{
+			name: "células sem referência além do limite",
+			data: func(t *testing.T) []byte {
+				return buildXLSX(t, `<row>`+strings.Repeat(`<c><v>1</v></c>`, 9)+`</row>`, nil)
+			},
+			wantErr: ErrTooManyColumns,
+		},
+		{
+			name:    "linhas fora de ordem",
+			data:    func(t *testing.T) []byte { return buildXLSX(t, `<row r="3"></row><row r="2"></row>`, nil) },
+			wantErr: errInvalidXLSX,
+		},
+		{
+			name:    "linha repetida",
+			data:    func(t *testing.T) []byte { return buildXLSX(t, `<row r="1"></row><row r="1"></row>`, nil) },
+			wantErr: errInvalidXLSX,
+		},
+		{
+			name:    "índice de linha negativo",
+			data:    func(t *testing.T) []byte { return buildXLSX(t, `<row r="-5"></row>`, nil) },
+			wantErr: errInvalidXLSX,
+		},
+		{
+			name: "células fora de ordem",
+			data: func(t *testing.T) []byte {
+				return buildXLSX(t, `<row r="1"><c r="C1"><v>1</v></c><c r="A1"><v>2</v></c></row>`, nil)
+			},
+			wantErr: errInvalidXLSX,
+		},
+		{
+			name:    "texto compartilhado inexistente",
+			data:    func(t *testing.T) []byte { return buildXLSX(t, `<row r="1"><c r="A1" t="s"><v>7</v></c></row>`, nil) },
+			wantErr: errInvalidXLSX,
+		},
+		{
+			name: "parte grande demais depois de descompactada",
+			data: func(t *testing.T) []byte {
+				padding := strings.Repeat(" ", maxXLSXPartSize)
+				return buildXLSX(t, `<row r="1"><c r="A1"><v>1</v></c></row>`+padding, nil)
+			},
+			wantErr: errXLSXTooLarge,
+		},
+		{
+			name:    "não é zip",
+			data:    func(t *testing.T) []byte { return []byte("sku,name") },
+			wantErr: errInvalidXLSX,
+		},
+		{
+			name: "sem a aba",
+			data: func(t *testing.T) []byte {
+				return buildXLSX(t, "", map[string]string{"xl/_rels/workbook.xml.rels": `<Relationships/>`})
+			},
+			wantErr: errInvalidXLSX,
+		},
+	}
+	for _, tt := range tests {
+		if _, err := Read(XLSX, tt.data(t), testLimits); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: erro = %v, quero %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestColumnIndexAndName(t *testing.T) {
+	tests := []struct {
+		ref   string
+		index int
+	}{
+		{"A1", 0}, {"Z9", 25}, {"AA10", 26}, {"XFD1", 16383}, {"1", -1}, {"ABCD1", -1},
+	}
+	for _, tt := range tests {
+		if got := columnIndex(tt.ref); got != tt.index {
+			t.Errorf("columnIndex(%q) = %d, quero %d", tt.ref, got, tt.index)
+		}
+		if tt.index >= 0 {
+			if name := columnName(tt.index); !strings.HasPrefix(tt.ref, name) {
+				t.Errorf("columnName(%d) = %q, quero prefixo de %q", tt.index, name, tt.ref)
+			}
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidXLSX  = errors.New("arquivo xlsx inválido")
+	errXLSXTooLarge = errors.New("arquivo xlsx grande demais depois de descompactado")
+)
+
+const (
+	// Tamanho máximo descompactado de cada parte lida do arquivo xlsx
+	maxXLSXPartSize = 32 << 20
+	// Maior quantidade de letras na referência da coluna ("XFD" no Excel)
+	maxColumnLetters = 3
+)
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxText é um texto que pode vir inteiro em <t> ou em trechos formatados <r><t>
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var builder strings.Builder
+	for _, run := range t.Runs {
+		builder.WriteString(run.Text)
+	}
+	return builder.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+// xlsxCell é uma célula <c> da aba
+type xlsxCell struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	Value  string   `xml:"v"`
+	Inline xlsxText `xml:"is"`
+}
+
+// readXLSX lê a primeira aba da pasta de trabalho
+func readXLSX(data []byte, limits Limits) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errInvalidXLSX
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, errInvalidXLSX
+	}
+	var relationships xlsxRelationships
+	if err := decodeZipXML(files, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, err
+	}
+
+	sheetPath := ""
+	for _, rel := range relationships.Relationships {
+		if rel.ID == workbook.Sheets[0].RelID {
+			sheetPath = rel.Target
+			if strings.HasPrefix(sheetPath, "/") {
+				sheetPath = strings.TrimPrefix(sheetPath, "/")
+			} else {
+				sheetPath = path.Join("xl", sheetPath)
+			}
+		}
+	}
+	if sheetPath == "" {
+		return nil, errInvalidXLSX
+	}
+
+	// A tabela de textos compartilhados é opcional
+	var shared xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeZipXML(files, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	reader, err := openZipPart(files, sheetPath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	rows, err := readXLSXSheet(reader, shared.Items, limits)
+	if reader.exceeded() {
+		return nil, errXLSXTooLarge
+	}
+	return rows, err
+}
+
+// readXLSXSheet percorre a aba célula por célula, conferindo a ordem e os
+// limites de cada linha e coluna antes de alocá-las
+func readXLSXSheet(reader io.Reader, shared []xlsxText, limits Limits) ([][]string, error) {
+	decoder := xml.NewDecoder(reader)
+
+	var rows [][]string
+	var values []string
+	inRow := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errInvalidXLSX
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "row":
+				if inRow {
+					return nil, errInvalidXLSX
+				}
+				// Linhas vazias não aparecem no XML; o índice preserva a
+				// numeração. Índices fora de ordem ou repetidos tornam o
+				// arquivo inválido.
+				index := len(rows) + 1
+				for _, attr := range element.Attr {
+					if attr.Name.Local == "r" {
+						if index, err = strconv.Atoi(attr.Value); err != nil {
+							return nil, errInvalidXLSX
+						}
+					}
+				}
+				if index <= len(rows) {
+					return nil, errInvalidXLSX
+				}
+				if err := limits.checkRow(index); err != nil {
+					return nil, err
+				}
+				for len(rows) < index-1 {
+					rows = append(rows, []string{})
+				}
+				values, inRow = []string{}, true
+			case "c":
+				if !inRow {
+					return nil, errInvalidXLSX
+				}
+				var cell xlsxCell
+				if err := decoder.DecodeElement(&cell, &element); err != nil {
+					return nil, errInvalidXLSX
+				}
+
+				column := len(values)
+				if cell.Ref != "" {
+					column = columnIndex(cell.Ref)
+					if column < len(values) {
+						return nil, errInvalidXLSX
+					}
+				}
+				if err := limits.checkColumn(column); err != nil {
+					return nil, err
+				}
+				for len(values) < column {
+					values = append(values, "")
+				}
+
+				var value string
+				switch cell.Type {
+				case "s":
+					i, err := strconv.Atoi(cell.Value)
+					if err != nil || i < 0 || i >= len(shared) {
+						return nil, errInvalidXLSX
+					}
+					value = shared[i].String()
+				case "inlineStr":
+					value = cell.Inline.String()
+				default:
+					value = cell.Value
+				}
+				values = append(values, value)
+			}
+		case xml.EndElement:
+			if element.Name.Local == "row" {
+				rows = append(rows, values)
+				inRow = false
+			}
+		}
+	}
+	return rows, nil
+}
+
+// zipPart lê uma parte do arquivo limitada a maxXLSXPartSize bytes
+// descompactados, mesmo que o cabeçalho do zip informe um tamanho menor
+type zipPart struct {
+	io.ReadCloser
+	limited *io.LimitedReader
+}
+
+func (p *zipPart) Read(b []byte) (int, error) {
+	return p.limited.Read(b)
+}
+
+// exceeded indica se a leitura atingiu o limite de tamanho
+func (p *zipPart) exceeded() bool {
+	return p.limited.N <= 0
+}
+
+func openZipPart(files map[string]*zip.File, name string) (*zipPart, error) {
+	file, ok := files[name]
+	if !ok {
+		return nil, errInvalidXLSX
+	}
+	if file.UncompressedSize64 > maxXLSXPartSize {
+		return nil, errXLSXTooLarge
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return nil, errInvalidXLSX
+	}
+	return &zipPart{ReadCloser: reader, limited: &io.LimitedReader{R: reader, N: maxXLSXPartSize + 1}}, nil
+}
+
+func decodeZipXML(files map[string]*zip.File, name string, target interface{}) error {
+	reader, err := openZipPart(files, name)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err := xml.NewDecoder(reader).Decode(target); err != nil {
+		if reader.exceeded() {
+			return errXLSXTooLarge
+		}
+		return errInvalidXLSX
+	}
+	return nil
+}
+
+// columnIndex converte a referência da célula ("C7") no índice da coluna (2),
+// ou -1 quando a referência não começa por uma coluna válida
+func columnIndex(ref string) int {
+	index, letters := 0, 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		if letters++; letters > maxColumnLetters {
+			return -1
+		}
+		index = index*26 + int(r-'A'+1)
+	}
+	return index - 1
+}
+
+// columnName converte o índice da coluna (2) na sua letra ("C")
+func columnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+	xlsxWorkbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Planilha1" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+)
+
+// writeXLSX grava uma pasta de trabalho mínima com uma aba e textos inline
+func writeXLSX(w io.Writer, rows [][]string) error {
+	archive := zip.NewWriter(w)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbookXML},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	var buffer bytes.Buffer
+	buffer.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	buffer.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(&buffer, `<row r="%d">`, r+1)
+		for c, value := range row {
+			fmt.Fprintf(&buffer, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, columnName(c), r+1)
+			if err := xml.EscapeText(&buffer, []byte(value)); err != nil {
+				return err
+			}
+			buffer.WriteString(`</t></is></c>`)
+		}
+		buffer.WriteString(`</row>`)
+	}
+	buffer.WriteString(`</sheetData></worksheet>`)
+	if _, err := buffer.WriteTo(sheet); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}